@@ -0,0 +1,185 @@
+// The gRPC API of bgp_exporter, served in cleartext HTTP/2 with --grpc.listen-address, for automation
+// to query the collected neighbors and follow their state changes without parsing the metrics.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: api/v1/bgp_exporter.proto
+
+package bgpexporterv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	BgpExporter_ListNeighbors_FullMethodName     = "/bgp_exporter.v1.BgpExporter/ListNeighbors"
+	BgpExporter_WatchStateChanges_FullMethodName = "/bgp_exporter.v1.BgpExporter/WatchStateChanges"
+)
+
+// BgpExporterClient is the client API for BgpExporter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BgpExporterClient interface {
+	// ListNeighbors returns the neighbors of the last collection of each router
+	ListNeighbors(ctx context.Context, in *ListNeighborsRequest, opts ...grpc.CallOption) (*ListNeighborsResponse, error)
+	// WatchStateChanges streams the state changes of the neighbors as they are collected, until the
+	// exporter shuts down. The changes are dropped for a client too slow to keep up.
+	WatchStateChanges(ctx context.Context, in *WatchStateChangesRequest, opts ...grpc.CallOption) (BgpExporter_WatchStateChangesClient, error)
+}
+
+type bgpExporterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBgpExporterClient(cc grpc.ClientConnInterface) BgpExporterClient {
+	return &bgpExporterClient{cc}
+}
+
+func (c *bgpExporterClient) ListNeighbors(ctx context.Context, in *ListNeighborsRequest, opts ...grpc.CallOption) (*ListNeighborsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNeighborsResponse)
+	err := c.cc.Invoke(ctx, BgpExporter_ListNeighbors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bgpExporterClient) WatchStateChanges(ctx context.Context, in *WatchStateChangesRequest, opts ...grpc.CallOption) (BgpExporter_WatchStateChangesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BgpExporter_ServiceDesc.Streams[0], BgpExporter_WatchStateChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bgpExporterWatchStateChangesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BgpExporter_WatchStateChangesClient interface {
+	Recv() (*StateChange, error)
+	grpc.ClientStream
+}
+
+type bgpExporterWatchStateChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *bgpExporterWatchStateChangesClient) Recv() (*StateChange, error) {
+	m := new(StateChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BgpExporterServer is the server API for BgpExporter service.
+// All implementations must embed UnimplementedBgpExporterServer
+// for forward compatibility
+type BgpExporterServer interface {
+	// ListNeighbors returns the neighbors of the last collection of each router
+	ListNeighbors(context.Context, *ListNeighborsRequest) (*ListNeighborsResponse, error)
+	// WatchStateChanges streams the state changes of the neighbors as they are collected, until the
+	// exporter shuts down. The changes are dropped for a client too slow to keep up.
+	WatchStateChanges(*WatchStateChangesRequest, BgpExporter_WatchStateChangesServer) error
+	mustEmbedUnimplementedBgpExporterServer()
+}
+
+// UnimplementedBgpExporterServer must be embedded to have forward compatible implementations.
+type UnimplementedBgpExporterServer struct {
+}
+
+func (UnimplementedBgpExporterServer) ListNeighbors(context.Context, *ListNeighborsRequest) (*ListNeighborsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNeighbors not implemented")
+}
+func (UnimplementedBgpExporterServer) WatchStateChanges(*WatchStateChangesRequest, BgpExporter_WatchStateChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStateChanges not implemented")
+}
+func (UnimplementedBgpExporterServer) mustEmbedUnimplementedBgpExporterServer() {}
+
+// UnsafeBgpExporterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BgpExporterServer will
+// result in compilation errors.
+type UnsafeBgpExporterServer interface {
+	mustEmbedUnimplementedBgpExporterServer()
+}
+
+func RegisterBgpExporterServer(s grpc.ServiceRegistrar, srv BgpExporterServer) {
+	s.RegisterService(&BgpExporter_ServiceDesc, srv)
+}
+
+func _BgpExporter_ListNeighbors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNeighborsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BgpExporterServer).ListNeighbors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BgpExporter_ListNeighbors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BgpExporterServer).ListNeighbors(ctx, req.(*ListNeighborsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BgpExporter_WatchStateChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStateChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BgpExporterServer).WatchStateChanges(m, &bgpExporterWatchStateChangesServer{ServerStream: stream})
+}
+
+type BgpExporter_WatchStateChangesServer interface {
+	Send(*StateChange) error
+	grpc.ServerStream
+}
+
+type bgpExporterWatchStateChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *bgpExporterWatchStateChangesServer) Send(m *StateChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// BgpExporter_ServiceDesc is the grpc.ServiceDesc for BgpExporter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BgpExporter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bgp_exporter.v1.BgpExporter",
+	HandlerType: (*BgpExporterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNeighbors",
+			Handler:    _BgpExporter_ListNeighbors_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStateChanges",
+			Handler:       _BgpExporter_WatchStateChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/v1/bgp_exporter.proto",
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpASNNeighbors = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_asn_neighbors",
+		Help: "The number of BGP neighbors with a given remote ASN",
+	},
+		[]string{
+			"asn",
+		})
+)
+
+var (
+	bgpASNNeighborsEstablished = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_asn_neighbors_established",
+		Help: "The number of established BGP neighbors with a given remote ASN",
+	},
+		[]string{
+			"asn",
+		})
+)
+
+var (
+	bgpASNAcceptedPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_asn_accepted_prefixes",
+		Help: "The total number of accepted prefixes of the BGP neighbors with a given remote ASN",
+	},
+		[]string{
+			"asn",
+		})
+)
+
+func recordASNMetrics(c *collection) {
+	metric(c, bgpASNNeighbors).Reset()
+	metric(c, bgpASNNeighborsEstablished).Reset()
+	metric(c, bgpASNAcceptedPrefixes).Reset()
+	for _, n := range c.state.neighbors.List() {
+		if n.RemoteAS == "" {
+			continue
+		}
+		metric(c, bgpASNNeighbors).With(prometheus.Labels{"asn": n.RemoteAS}).Inc()
+		metric(c, bgpASNNeighborsEstablished).With(prometheus.Labels{"asn": n.RemoteAS}).Add(boolToFloat(n.State == 6))
+		metric(c, bgpASNAcceptedPrefixes).With(prometheus.Labels{"asn": n.RemoteAS}).Add(n.AcceptedPrefixes)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	auditFile     = flag.String("audit.file", "", "Append the result of each collection (the neighbors and their state changes) as a JSON line to this file")
+	auditMaxSize  = flag.Int64("audit.max-size", 100, "The size in megabytes beyond which the audit file is rotated")
+	auditMaxFiles = flag.Int("audit.max-files", 5, "The number of rotated audit files kept, named with the suffixes .1 (the most recent) to .N")
+)
+
+// AuditRecord : This represents a collection of the neighbors of a router in the audit log, with the
+// neighbors as collected and their changes since the previous collection, or the error
+type AuditRecord struct {
+	Time      time.Time     `json:"time"`
+	Target    string        `json:"target,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Neighbors []BgpNeighbor `json:"neighbors,omitempty"`
+	Changes   []StateChange `json:"changes,omitempty"`
+}
+
+var auditMutex sync.Mutex
+
+// auditCollection : Appends the collection to the audit log, rotating it when it reached its maximum size
+func auditCollection(target string, neighbors []BgpNeighbor, changes []StateChange, err error) {
+	record := AuditRecord{Time: time.Now(), Neighbors: neighbors, Changes: changes}
+	// The target is only named in multi-router mode
+	if target != localTarget {
+		record.Target = target
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("Failed to encode the audit record", "err", err)
+		return
+	}
+	if err := appendAudit(*auditFile, append(line, '\n')); err != nil {
+		logger.Error("Failed to write the audit log", "path", *auditFile, "err", err)
+		recordError(localTarget, err.Error())
+	}
+}
+
+func appendAudit(path string, line []byte) error {
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > *auditMaxSize*1024*1024 {
+		if err := rotateAudit(path); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotateAudit : Shifts the rotated files, dropping the oldest one, and renames the file to .1
+func rotateAudit(path string) error {
+	if *auditMaxFiles <= 0 {
+		return os.Remove(path)
+	}
+	os.Remove(fmt.Sprintf("%s.%d", path, *auditMaxFiles))
+	for i := *auditMaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+var (
+	dockerContainer = flag.String("docker.container", "", "Run vtysh in this container (name or ID) with \"docker exec\", for FRR running in a container")
+	dockerBinary    = flag.String("docker.binary", "docker", "The docker compatible binary used to exec into the container, e.g. podman")
+	netns           = flag.String("netns", "", "Run vtysh in this Linux network namespace with \"ip netns exec\", for bgpd running in a separate namespace")
+	vtyshPath       = flag.String("vtysh.path", "vtysh", "The vtysh binary, looked up in the PATH unless it is a path")
+	vtyshExtraArgs  = flag.String("vtysh.args", "", "Extra arguments given to vtysh before the command, separated by spaces, e.g. \"-N blue\" for the FRR instance of a namespace")
+	vtyshSudoUser   = flag.String("vtysh.sudo-user", "", "Run the local vtysh as this user with \"sudo -n -u\", e.g. frr, for the exporter to run unprivileged (sudo must allow it without a password)")
+)
+
+// vtyshArgs : Returns the command line running the vtysh command locally, through sudo if configured,
+// in the container or network namespace of FRR if one is configured. sudo only wraps vtysh, as entering
+// the namespace or container takes the privileges of the exporter.
+func vtyshArgs(command string) []string {
+	args := append(append([]string{*vtyshPath}, strings.Fields(*vtyshExtraArgs)...), "-c", command)
+	if *vtyshSudoUser != "" {
+		args = append([]string{"sudo", "-n", "-u", *vtyshSudoUser}, args...)
+	}
+	return backendArgs(args...)
+}
+
+// backendArgs : Returns the command line running the command in the container or network namespace
+// of FRR if one is configured
+func backendArgs(args ...string) []string {
+	if *dockerContainer != "" {
+		args = append([]string{*dockerBinary, "exec", *dockerContainer}, args...)
+	}
+	if *netns != "" {
+		args = append([]string{"ip", "netns", "exec", *netns}, args...)
+	}
+	return args
+}
+
+// runBackendCommand : Runs a command other than vtysh where FRR runs: over SSH, or else locally in
+// its container or network namespace
+func (c *collection) runBackendCommand(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, *vtyshTimeout)
+	defer cancel()
+	var stdout, stderr string
+	var err error
+	if c.ssh.enabled() {
+		stdout, stderr, err = runSSH(ctx, &c.ssh, shellQuote(args))
+	} else {
+		args = backendArgs(args...)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		var sout, serr bytes.Buffer
+		cmd.Stdout = &sout
+		cmd.Stderr = &serr
+		err = cmd.Run()
+		stdout, stderr = sout.String(), serr.String()
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", *vtyshTimeout)
+	}
+	if err != nil {
+		return stdout, fmt.Errorf("failed to execute %q: %s %s", args, err, strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	calicoMode      = flag.Bool("calico", false, "Collect the neighbors from the BIRD daemons of Calico (calico-node), found by their control sockets, instead of FRR")
+	calicoSocketDir = flag.String("calico.socket-dir", "/var/run/calico", "The directory of the bird.ctl and bird6.ctl control sockets of calico-node")
+)
+
+var (
+	bgpCalicoPeerInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_calico_peer_info",
+		Help: "The kind of Calico peering of a given BGP neighbor (mesh for the node-to-node mesh, node or global for BGPPeer resources) and its BIRD protocol",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"peer_type",
+			"protocol",
+		})
+)
+
+// birdSockets : The control sockets of calico-node, bird for IPv4 and bird6 for IPv6
+var birdSockets = []string{"bird.ctl", "bird6.ctl"}
+
+// birdProtocols : The BIRD protocols of the neighbors of the last collection, by neighbor key
+var birdProtocols = make(map[string]string)
+
+var birdRoutesRegex = regexp.MustCompile(`^Routes:\s+(\d+) imported`)
+var birdTimerRegex = regexp.MustCompile(`^(Hold|Keepalive) timer:\s+(?:\d+/)?(\d+)`)
+
+// calicoPeerTypes : The prefixes of the names given by Calico to the BIRD protocols
+var calicoPeerTypes = map[string]string{
+	"Mesh_":   "mesh",
+	"Node_":   "node",
+	"Global_": "global",
+}
+
+func calicoEnabled() bool {
+	return *calicoMode
+}
+
+// birdNeighbors : Collects the BGP protocols of every BIRD daemon whose control socket is found
+func birdNeighbors() ([]BgpNeighbor, error) {
+	var neighbors []BgpNeighbor
+	found := false
+	protocols := make(map[string]string)
+	for _, name := range birdSockets {
+		path := strings.TrimSuffix(*calicoSocketDir, "/") + "/" + name
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		found = true
+		o, err := birdCommand(path, "show protocols all")
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %s", path, err)
+		}
+		for protocol, n := range parseBirdProtocols(o) {
+			neighbors = append(neighbors, n)
+			protocols[n.key()] = protocol
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no BIRD control socket found in %s", *calicoSocketDir)
+	}
+	birdProtocols = protocols
+	return neighbors, nil
+}
+
+// birdCommand : Runs a command on a BIRD control socket. Every line of the reply starts with a four digit
+// code, followed by "-" if more lines follow, or is a continuation starting with a space. The reply
+// ends with the "0000" line, or an error code from 8000 on.
+func birdCommand(path string, command string) (string, error) {
+	conn, err := net.DialTimeout("unix", path, *vtyshTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(*vtyshTimeout))
+
+	r := bufio.NewReader(conn)
+	// The greeting, e.g. "0001 BIRD 1.6.8 ready."
+	if _, err := r.ReadString('\n'); err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return out.String(), err
+		}
+		line = strings.TrimRight(line, "\n")
+		if len(line) >= 5 && line[0] != ' ' {
+			code, _ := strconv.Atoi(line[:4])
+			if line[:4] == "0000" {
+				return out.String(), nil
+			}
+			if code >= 8000 {
+				return out.String(), fmt.Errorf("%s", strings.TrimSpace(line[5:]))
+			}
+			line = line[5:]
+		} else if line != "" {
+			line = line[1:]
+		}
+		out.WriteString(line + "\n")
+	}
+}
+
+// parseBirdProtocols : Parses "show protocols all" into the BGP neighbors, by name of the protocol
+func parseBirdProtocols(s string) map[string]BgpNeighbor {
+	neighbors := make(map[string]BgpNeighbor)
+	var name string
+	var n *BgpNeighbor
+	for _, line := range strings.Split(s, "\n") {
+		// A protocol starts with its name in the first column, e.g. "Mesh_10_0_0_2 BGP master up 10:20:30 Established"
+		if line != "" && line[0] != ' ' {
+			if n != nil && n.IP != nil {
+				neighbors[name] = *n
+			}
+			n = nil
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "BGP" {
+				name = fields[0]
+				n = &BgpNeighbor{AddressFamilies: make(map[string]*BgpAddressFamily)}
+			}
+			continue
+		}
+		if n == nil {
+			continue
+		}
+		t := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(t, "BGP state:"):
+			state := strings.TrimSpace(strings.TrimPrefix(t, "BGP state:"))
+			n.State = gnmiNeighborStates[strings.ToUpper(state)]
+			if state == "Down" {
+				n.State = 1
+			}
+		case strings.HasPrefix(t, "Neighbor address:"):
+			address := strings.TrimSpace(strings.TrimPrefix(t, "Neighbor address:"))
+			// Link local neighbors are printed with their interface, e.g. "fe80::1%eth0"
+			if i := strings.Index(address, "%"); i >= 0 {
+				n.Interface = address[i+1:]
+				address = address[:i]
+			}
+			n.IP = net.ParseIP(address)
+		case strings.HasPrefix(t, "Neighbor AS:"):
+			n.RemoteAS = strings.TrimSpace(strings.TrimPrefix(t, "Neighbor AS:"))
+		case strings.HasPrefix(t, "Description:"):
+			n.Description = strings.TrimSpace(strings.TrimPrefix(t, "Description:"))
+		case birdRoutesRegex.MatchString(t):
+			n.AcceptedPrefixes, _ = strconv.ParseFloat(birdRoutesRegex.FindStringSubmatch(t)[1], 64)
+		case birdTimerRegex.MatchString(t):
+			m := birdTimerRegex.FindStringSubmatch(t)
+			v, _ := strconv.ParseFloat(m[2], 64)
+			if m[1] == "Hold" {
+				n.HoldTime = v
+			} else {
+				n.KeepaliveInterval = v
+			}
+		}
+	}
+	if n != nil && n.IP != nil {
+		neighbors[name] = *n
+	}
+	return neighbors
+}
+
+// recordCalicoMetrics : Exports the kind of Calico peering of the neighbors, as given by the names
+// of their BIRD protocols
+func recordCalicoMetrics(c *collection) {
+	metric(c, bgpCalicoPeerInfo).Reset()
+	for _, n := range c.exportedNeighbors() {
+		protocol, ok := birdProtocols[n.key()]
+		if !ok {
+			continue
+		}
+		peerType := "other"
+		for prefix, t := range calicoPeerTypes {
+			if strings.HasPrefix(protocol, prefix) {
+				peerType = t
+			}
+		}
+		metric(c, bgpCalicoPeerInfo).With(n.labels("peer_type", peerType, "protocol", protocol)).Set(1)
+	}
+}
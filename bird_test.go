@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// startBird : Serves the control socket of BIRD in the directory, answering "show protocols all" with the reply
+// and the other commands with an error
+func startBird(t *testing.T, dir, name, reply string) {
+	t.Helper()
+	l, err := net.Listen("unix", filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Write([]byte("0001 BIRD 1.6.8 ready.\n"))
+				command, _ := bufio.NewReader(conn).ReadString('\n')
+				if strings.TrimSpace(command) != "show protocols all" {
+					conn.Write([]byte("9001 syntax error, unexpected CF_SYM_UNDEFINED\n"))
+					return
+				}
+				conn.Write([]byte(reply))
+			}()
+		}
+	}()
+}
+
+func TestBirdNeighbors(t *testing.T) {
+	dir := t.TempDir()
+	startBird(t, dir, "bird.ctl", readFile(t, "testdata/bird/bird.ctl.txt"))
+	startBird(t, dir, "bird6.ctl", readFile(t, "testdata/bird/bird6.ctl.txt"))
+	setFlags(t, map[string]string{"calico": "true", "calico.socket-dir": dir})
+	protocols := birdProtocols
+	t.Cleanup(func() { birdProtocols = protocols })
+
+	neighbors, err := birdNeighbors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].key() < neighbors[j].key() })
+	golden(t, "testdata/bird/neighbors.golden", neighbors)
+
+	// The kind of peering is given by the name of the protocol of each neighbor
+	c := testCollection(t, nil, bgpCalicoPeerInfo)
+	c.state.neighbors.Replace(neighbors)
+	recordCalicoMetrics(c)
+	want := `
+# HELP bgp_calico_peer_info The kind of Calico peering of a given BGP neighbor (mesh for the node-to-node mesh, node or global for BGPPeer resources) and its BIRD protocol
+# TYPE bgp_calico_peer_info gauge
+bgp_calico_peer_info{interface="",ip="10.0.0.2",peer_type="mesh",protocol="Mesh_10_0_0_2",view=""} 1
+bgp_calico_peer_info{interface="",ip="10.0.0.254",peer_type="global",protocol="Global_10_0_0_254",view=""} 1
+bgp_calico_peer_info{interface="",ip="10.0.1.1",peer_type="node",protocol="Node_10_0_1_1",view=""} 1
+bgp_calico_peer_info{interface="eth0",ip="fe80::2",peer_type="mesh",protocol="Mesh_fe80__2",view=""} 1
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBirdCommandError(t *testing.T) {
+	dir := t.TempDir()
+	startBird(t, dir, "bird.ctl", "")
+	setFlags(t, nil)
+	if _, err := birdCommand(filepath.Join(dir, "bird.ctl"), "show route count"); err == nil || err.Error() != "syntax error, unexpected CF_SYM_UNDEFINED" {
+		t.Errorf("got the error %v", err)
+	}
+}
+
+func TestBirdNeighborsWithoutSocket(t *testing.T) {
+	dir := t.TempDir()
+	setFlags(t, map[string]string{"calico": "true", "calico.socket-dir": dir})
+	if _, err := birdNeighbors(); err == nil || !strings.Contains(err.Error(), "no BIRD control socket found") {
+		t.Errorf("got the error %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// checkConfig : Validates the configuration file and that the routers can be queried with it,
+// printing what was checked. It returns an error describing the first problem found.
+func checkConfig(w io.Writer) error {
+	c, err := loadConfig(*configFile)
+	if err != nil {
+		return fmt.Errorf("configuration: %s", err)
+	}
+	config = c
+	if *configFile == "" {
+		fmt.Fprintln(w, "Configuration: no configuration file given, using the defaults")
+	} else {
+		fmt.Fprintf(w, "Configuration: %s is valid\n", *configFile)
+	}
+
+	if err := checkMetricPrefix(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := checkPlatform(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := validateNeighborsJSON(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := validateSummaryAddressFamilies(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := parseShard(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := validateNeighborsDetail(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+	if err := validatePrivsep(); err != nil {
+		return fmt.Errorf("flags: %s", err)
+	}
+
+	switch {
+	case multiRouter():
+		targets, err := checkTargets(w)
+		if err != nil {
+			return err
+		}
+		for i := range targets {
+			t := &targets[i]
+			backend, err := newCollection(context.Background(), t, nil).checkBackend()
+			if err != nil {
+				return fmt.Errorf("target %s: %s backend: %s", t.Name, backend, err)
+			}
+			fmt.Fprintf(w, "Target %s: %s is reachable\n", t.Name, backend)
+		}
+	case probesEnabled() && *probeOnly:
+		fmt.Fprintln(w, "Backend: only the routers given to /probe are collected")
+	default:
+		backend, err := newCollection(context.Background(), localTargetConfig, nil).checkBackend()
+		if err != nil {
+			return fmt.Errorf("backend: %s: %s", backend, err)
+		}
+		fmt.Fprintf(w, "Backend: %s is reachable\n", backend)
+	}
+
+	// The routers of the modules are only known once probed, so only their credentials are checked
+	var modules []string
+	for name := range config.Modules {
+		modules = append(modules, name)
+	}
+	sort.Strings(modules)
+	for _, name := range modules {
+		m := config.Modules[name]
+		if m.Backend == "northbound" {
+			fmt.Fprintf(w, "Module %s: the northbound backend takes no credentials\n", name)
+			continue
+		}
+		password, err := m.Secret.read(m.Password)
+		if err == nil {
+			_, err = m.SSHConfig.clientConfig(password)
+		}
+		if err != nil {
+			return fmt.Errorf("module %s: SSH credentials: %s", name, err)
+		}
+		fmt.Fprintf(w, "Module %s: the SSH credentials can be read\n", name)
+	}
+	return nil
+}
+
+// checkTargets : Returns the targets of the configuration and those discovered, of the shard if the
+// targets are sharded, reporting the discovery which fails
+func checkTargets(w io.Writer) ([]TargetConfig, error) {
+	targets := append([]TargetConfig(nil), config.Targets...)
+	if c := &config.Discovery; c.enabled() {
+		var found []TargetConfig
+		if len(c.Files) > 0 {
+			files, err := readTargetFiles(c)
+			if err != nil {
+				return nil, fmt.Errorf("target discovery: %s", err)
+			}
+			found = append(found, files...)
+		}
+		if len(c.DNSSRV) > 0 {
+			records, err := lookupTargets(c)
+			if err != nil {
+				return nil, fmt.Errorf("target discovery: %s", err)
+			}
+			found = append(found, records...)
+		}
+		fmt.Fprintf(w, "Target discovery: %d targets found\n", len(found))
+		targets = append(targets, found...)
+	}
+	var sharded []TargetConfig
+	for _, t := range targets {
+		if inShard(t.Name) {
+			sharded = append(sharded, t)
+		}
+	}
+	return sharded, nil
+}
+
+// checkBackend : Queries the router once with the backend of the collection, as the neighbors are
+// collected but without the retries, returning the name of the backend
+func (c *collection) checkBackend() (string, error) {
+	switch {
+	case config.GNMI.enabled():
+		return "gNMI", checkGNMI(c.ctx)
+	case exabgpEnabled():
+		return "ExaBGP", checkExabgp()
+	case c.northbound.enabled():
+		_, err := northboundNeighbors(c.ctx, &c.northbound)
+		return "northbound", err
+	case calicoEnabled():
+		_, err := birdNeighbors()
+		return "BIRD", err
+	case ciliumEnabled():
+		_, err := ciliumNeighbors()
+		return "Cilium", err
+	}
+	backend := "vtysh"
+	switch {
+	case c.ssh.enabled() && c.ios():
+		backend = "SSH (IOS)"
+	case c.ssh.enabled():
+		backend = "SSH"
+	case *vtySocket != "":
+		backend = "vty socket"
+	}
+	o, e, err := c.runVtysh(c.summaryCommand())
+	if err != nil {
+		if e = strings.TrimSpace(e); e != "" {
+			err = fmt.Errorf("%s: %s", err, e)
+		}
+		return backend, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(o), "%") {
+		return backend, fmt.Errorf("the command was refused: %s", strings.TrimSpace(o))
+	}
+	return backend, nil
+}
+
+// checkGNMI : Checks that the router answers a capabilities request of gnmic with the credentials of
+// the subscription
+func checkGNMI(ctx context.Context) error {
+	c := &config.GNMI
+	password, err := c.Secret.read(c.Password)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, *vtyshTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.Binary, append(c.connectionArgs(), "capabilities")...)
+	cmd.Env = append(os.Environ(), "GNMIC_PASSWORD="+password)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if out := strings.TrimSpace(string(out)); out != "" {
+			return fmt.Errorf("%s: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkExabgp : Checks that the pipe the messages of ExaBGP are read from exists. The messages POSTed
+// with --exabgp.http only come once the exporter runs.
+func checkExabgp() error {
+	if *exabgpPipe == "" {
+		return nil
+	}
+	fi, err := os.Stat(*exabgpPipe)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeNamedPipe == 0 {
+		return fmt.Errorf("%s is not a named pipe", *exabgpPipe)
+	}
+	return nil
+}
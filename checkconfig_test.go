@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// setFlags : Sets the flags for the test, restoring them and the configuration once it completes
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	c := config
+	t.Cleanup(func() { config = c })
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if f == nil {
+			t.Fatalf("unknown flag %s", name)
+		}
+		previous := f.Value.String()
+		t.Cleanup(func() { f.Value.Set(previous) })
+		if err := f.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	pipe := filepath.Join(dir, "exabgp")
+	if err := syscall.Mkfifo(pipe, 0600); err != nil {
+		t.Fatal(err)
+	}
+	notKey := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(notKey, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		flags  map[string]string
+		// want : The lines printed, or the error
+		want []string
+		err  string
+	}{
+		{
+			name: "ssh target",
+			config: `
+targets:
+  - name: edge1
+    address: 127.0.0.1:1
+    user: frr
+    password: secret
+    insecure_ignore_host_key: true
+`,
+			err: "target edge1: SSH backend: ssh 127.0.0.1:1: dial tcp 127.0.0.1:1: connect: connection refused",
+		},
+		{
+			name: "northbound target",
+			config: `
+targets:
+  - name: edge1
+    backend: northbound
+    address: 127.0.0.1:1
+`,
+			err: "target edge1: northbound backend: failed to get",
+		},
+		{
+			name:  "exabgp pipe",
+			flags: map[string]string{"exabgp.pipe": pipe},
+			want:  []string{"Backend: ExaBGP is reachable"},
+		},
+		{
+			name:  "exabgp pipe not a pipe",
+			flags: map[string]string{"exabgp.pipe": notKey},
+			err:   "backend: ExaBGP: " + notKey + " is not a named pipe",
+		},
+		{
+			name:  "calico without bird",
+			flags: map[string]string{"calico": "true", "calico.socket-dir": dir},
+			err:   "backend: BIRD: no BIRD control socket found in " + dir,
+		},
+		{
+			name:  "cilium without agent",
+			flags: map[string]string{"cilium": "true", "cilium.socket": filepath.Join(dir, "cilium.sock")},
+			err:   "backend: Cilium: failed to query the Cilium agent",
+		},
+		{
+			name: "modules",
+			config: `
+modules:
+  nb:
+    backend: northbound
+  ssh:
+    user: frr
+    password: secret
+    insecure_ignore_host_key: true
+`,
+			flags: map[string]string{"probe.only": "true"},
+			want: []string{
+				"Backend: only the routers given to /probe are collected",
+				"Module nb: the northbound backend takes no credentials",
+				"Module ssh: the SSH credentials can be read",
+			},
+		},
+		{
+			name: "module with an invalid key",
+			config: `
+modules:
+  default:
+    user: frr
+    private_key_file: ` + notKey + `
+    insecure_ignore_host_key: true
+`,
+			flags: map[string]string{"probe.only": "true"},
+			err:   "module default: SSH credentials: failed to parse " + notKey,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := map[string]string{"config.file": ""}
+			if tt.config != "" {
+				flags["config.file"] = filepath.Join(t.TempDir(), "config.yml")
+				if err := os.WriteFile(flags["config.file"], []byte(tt.config), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, value := range tt.flags {
+				flags[name] = value
+			}
+			setFlags(t, flags)
+
+			var out bytes.Buffer
+			err := checkConfig(&out)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got the error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if got := strings.Join(lines[1:], "\n"); got != strings.Join(tt.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", got, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ciliumMode   = flag.Bool("cilium", false, "Collect the neighbors from the BGP control plane of the Cilium agent, through its API, instead of FRR")
+	ciliumSocket = flag.String("cilium.socket", "/var/run/cilium/cilium.sock", "The API socket of the Cilium agent")
+)
+
+// CiliumBgpPeer : This represents a peer as returned by GET /v1/bgp/peers of the Cilium agent API
+type CiliumBgpPeer struct {
+	LocalASN                       uint32                `json:"local-asn"`
+	PeerAddress                    string                `json:"peer-address"`
+	PeerASN                        uint32                `json:"peer-asn"`
+	SessionState                   string                `json:"session-state"`
+	AppliedHoldTimeSeconds         float64               `json:"applied-hold-time-seconds"`
+	AppliedKeepAliveTimeSeconds    float64               `json:"applied-keep-alive-time-seconds"`
+	ConfiguredHoldTimeSeconds      float64               `json:"configured-hold-time-seconds"`
+	ConfiguredKeepAliveTimeSeconds float64               `json:"configured-keep-alive-time-seconds"`
+	GracefulRestart                CiliumGracefulRestart `json:"graceful-restart"`
+	Families                       []CiliumBgpFamily     `json:"families"`
+}
+
+// CiliumGracefulRestart : This represents the graceful restart settings of a Cilium peer
+type CiliumGracefulRestart struct {
+	Enabled            bool    `json:"enabled"`
+	RestartTimeSeconds float64 `json:"restart-time-seconds"`
+}
+
+// CiliumBgpFamily : This represents the routes of an address family of a Cilium peer
+type CiliumBgpFamily struct {
+	Afi        string  `json:"afi"`
+	Safi       string  `json:"safi"`
+	Received   float64 `json:"received"`
+	Accepted   float64 `json:"accepted"`
+	Advertised float64 `json:"advertised"`
+}
+
+// ciliumClient : The Cilium agent API is served over HTTP on a unix socket
+var ciliumClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", *ciliumSocket)
+		},
+	},
+}
+
+// ciliumFamilies : The address families of the peers of the last collection, by neighbor key
+var ciliumFamilies = make(map[string][]CiliumBgpFamily)
+
+func ciliumEnabled() bool {
+	return *ciliumMode
+}
+
+// ciliumNeighbors : Collects the peers of the BGP control plane from the Cilium agent
+func ciliumNeighbors() ([]BgpNeighbor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), *vtyshTimeout)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://cilium/v1/bgp/peers", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ciliumClient.Do(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the Cilium agent at %s: %s", *ciliumSocket, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// e.g. 501 when the BGP control plane is not enabled
+		return nil, fmt.Errorf("the Cilium agent at %s returned %s", *ciliumSocket, resp.Status)
+	}
+	var peers []CiliumBgpPeer
+	if err := json.NewDecoder(resp.Body).Decode(&peers); err != nil {
+		return nil, fmt.Errorf("failed to decode the Cilium BGP peers: %s", err)
+	}
+	var neighbors []BgpNeighbor
+	families := make(map[string][]CiliumBgpFamily)
+	for _, p := range peers {
+		n := ciliumNeighbor(p)
+		neighbors = append(neighbors, n)
+		families[n.key()] = p.Families
+	}
+	ciliumFamilies = families
+	return neighbors, nil
+}
+
+// ciliumNeighbor : Converts a Cilium peer, whose session states are the lower case OpenConfig ones
+func ciliumNeighbor(p CiliumBgpPeer) BgpNeighbor {
+	n := BgpNeighbor{
+		IP:                  net.ParseIP(p.PeerAddress),
+		RemoteAS:            strconv.FormatUint(uint64(p.PeerASN), 10),
+		State:               gnmiNeighborStates[strings.ToUpper(p.SessionState)],
+		HoldTime:            p.AppliedHoldTimeSeconds,
+		KeepaliveInterval:   p.AppliedKeepAliveTimeSeconds,
+		ConfiguredHoldTime:  p.ConfiguredHoldTimeSeconds,
+		ConfiguredKeepalive: p.ConfiguredKeepAliveTimeSeconds,
+		GRAdvertised:        p.GracefulRestart.Enabled,
+		GRRestartTimer:      p.GracefulRestart.RestartTimeSeconds,
+		AddressFamilies:     make(map[string]*BgpAddressFamily),
+	}
+	n.Type = "ebgp"
+	if p.LocalASN == p.PeerASN {
+		n.Type = "ibgp"
+	}
+	for _, f := range p.Families {
+		n.AcceptedPrefixes += f.Accepted
+	}
+	return n
+}
+
+// recordCiliumMetrics : Exports the prefixes received from and advertised to the peers per address
+// family (e.g. the PodCIDRs and LoadBalancer addresses), as the summary does for FRR
+func recordCiliumMetrics(c *collection) {
+	metric(c, bgpNeighborPrefixesReceived).Reset()
+	metric(c, bgpNeighborPrefixesSent).Reset()
+	for _, n := range c.exportedNeighbors() {
+		for _, f := range ciliumFamilies[n.key()] {
+			afi := f.Afi + "_" + f.Safi
+			metric(c, bgpNeighborPrefixesReceived).With(n.labels("afi", afi)).Set(f.Received)
+			metric(c, bgpNeighborPrefixesSent).With(n.labels("afi", afi)).Set(f.Advertised)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// startCilium : Serves the Cilium agent API on a unix socket with the handler
+func startCilium(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "cilium.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := httptest.NewUnstartedServer(handler)
+	s.Listener = l
+	s.Start()
+	t.Cleanup(s.Close)
+	setFlags(t, map[string]string{"cilium": "true", "cilium.socket": socket})
+	families := ciliumFamilies
+	t.Cleanup(func() { ciliumFamilies = families })
+}
+
+func TestCiliumNeighbors(t *testing.T) {
+	peers := readFile(t, "testdata/cilium/bgp_peers.json")
+	startCilium(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/bgp/peers" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(peers))
+	})
+
+	neighbors, err := ciliumNeighbors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].key() < neighbors[j].key() })
+	golden(t, "testdata/cilium/bgp_peers.golden", neighbors)
+
+	// The prefixes are exported per address family of each peer
+	c := testCollection(t, nil, bgpNeighborPrefixesReceived, bgpNeighborPrefixesSent)
+	c.state.neighbors.Replace(neighbors)
+	recordCiliumMetrics(c)
+	want := `
+# HELP bgp_neighbor_prefixes_received The number of prefixes received from a given BGP neighbor for an address family (PfxRcd)
+# TYPE bgp_neighbor_prefixes_received gauge
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.1",view=""} 2
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.2",view=""} 0
+bgp_neighbor_prefixes_received{afi="ipv6_unicast",interface="",ip="10.0.0.1",view=""} 4
+# HELP bgp_neighbor_prefixes_sent The number of prefixes sent to a given BGP neighbor for an address family (PfxSnt)
+# TYPE bgp_neighbor_prefixes_sent gauge
+bgp_neighbor_prefixes_sent{afi="ipv4_unicast",interface="",ip="10.0.0.1",view=""} 3
+bgp_neighbor_prefixes_sent{afi="ipv4_unicast",interface="",ip="10.0.0.2",view=""} 0
+bgp_neighbor_prefixes_sent{afi="ipv6_unicast",interface="",ip="10.0.0.1",view=""} 1
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCiliumNeighborsDisabled : Checks the error of an agent whose BGP control plane is not enabled
+func TestCiliumNeighborsDisabled(t *testing.T) {
+	startCilium(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "BGP Control Plane disabled", http.StatusNotImplemented)
+	})
+	if _, err := ciliumNeighbors(); err == nil || !strings.HasSuffix(err.Error(), "returned 501 Not Implemented") {
+		t.Errorf("got the error %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collection : This represents a collection of a router in progress, given to the collectors rather
+// than them reading the router being collected from globals: its backend, the state kept between its
+// collections and the collectors to run
+type collection struct {
+	// ctx : Bounds the commands of the collection, e.g. to the time allowed to collect a target
+	ctx context.Context
+	// target : The name of the target, under which the errors are kept
+	target     string
+	ssh        SSHConfig
+	northbound NorthboundConfig
+	platform   string
+	state      *targetState
+	// collectors : The collectors of the module being probed, nil to run those enabled by the flags
+	collectors map[string]bool
+	// summaries : The outputs of the summary commands run for the neighbors, by command, for the
+	// summary collector not to run them again
+	summaries map[string]string
+	// failures : The number of collectors which failed, for the probes to tell whether they succeeded
+	failures int
+	// metrics : The copies of the per router metrics set by the collection, by metric, nil for the
+	// collection to set the metrics themselves
+	metrics  map[prometheus.Collector]prometheus.Collector
+	registry *prometheus.Registry
+}
+
+// newCollection : Returns a collection of the target with the given collectors if any, the local
+// router being collected with the backend of the flags and the configuration
+func newCollection(ctx context.Context, t *TargetConfig, collectors map[string]bool) *collection {
+	c := &collection{ctx: ctx, target: t.Name, platform: t.Platform, state: t.state, collectors: collectors}
+	switch {
+	case t == localTargetConfig:
+		c.ssh, c.northbound, c.platform = config.SSH, config.Northbound, *platform
+	case t.Backend == "northbound":
+		c.northbound = t.northbound()
+	default:
+		c.ssh = t.SSHConfig
+	}
+	return c
+}
+
+// ownMetrics : Gives the collection its own copies of the per router metrics, registered in its own
+// registry, for the targets to be collected concurrently from empty metrics
+func (c *collection) ownMetrics() {
+	c.registry = prometheus.NewRegistry()
+	c.metrics = make(map[prometheus.Collector]prometheus.Collector)
+	for _, m := range targetCollectors {
+		copyMetric, ok := metricCopies[m]
+		if !ok {
+			continue
+		}
+		own := copyMetric()
+		c.registry.MustRegister(own)
+		c.metrics[m] = own
+		// The totals are also set through their gauges, e.g. by the per neighbor samples
+		if v, ok := m.(*TotalVec); ok {
+			c.metrics[v.GaugeVec] = own.(*TotalVec).GaugeVec
+		}
+	}
+}
+
+// metric : Returns the metric to set for the collection, its own copy if it has one
+func metric[T prometheus.Collector](c *collection, m T) T {
+	if own, ok := c.metrics[m]; ok {
+		return own.(T)
+	}
+	return m
+}
+
+// metricCopies : The constructors of the per router metrics, for the collections to get their own copies
+var metricCopies = make(map[prometheus.Collector]func() prometheus.Collector)
+
+// metricNames : The names of the metric families of the per router metrics, for collect[] to select those
+// of the collectors
+var metricNames = make(map[prometheus.Collector]string)
+
+func newGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	v := prometheus.NewGaugeVec(opts, labelNames)
+	metricCopies[v] = func() prometheus.Collector { return prometheus.NewGaugeVec(opts, labelNames) }
+	metricNames[v] = prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)
+	return v
+}
+
+func newCounterVec(opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	v := prometheus.NewCounterVec(opts, labelNames)
+	metricCopies[v] = func() prometheus.Collector { return prometheus.NewCounterVec(opts, labelNames) }
+	metricNames[v] = prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)
+	return v
+}
+
+func newGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	g := prometheus.NewGauge(opts)
+	metricCopies[g] = func() prometheus.Collector { return prometheus.NewGauge(opts) }
+	metricNames[g] = prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)
+	return g
+}
+
+// ios : Whether the router runs Cisco IOS rather than FRR
+func (c *collection) ios() bool {
+	return c.platform == "ios"
+}
+
+// collectorEnabled : Whether the collector runs, as chosen by the module being probed or else by the flags
+func (c *collection) collectorEnabled(name string, enabled bool) bool {
+	if c.collectors != nil {
+		return c.collectors[name]
+	}
+	return enabled
+}
+
+// collectorFailed : Counts and logs a failed collector of the collection, keeping it in the error log of the target
+func (c *collection) collectorFailed(collector string, err error) {
+	c.failures++
+	metric(c, bgpCollectorErrors).With(prometheus.Labels{"collector": collector}).Inc()
+	logger.Error("Collector failed", "collector", collector, "target", c.target, "err", err)
+	recordError(c.target, err.Error())
+}
+
+// neighborsOnlyBackend : Whether the neighbors are collected from a backend which provides
+// nothing else (gNMI, ExaBGP, the northbound interface, BIRD or Cilium), rather than with the show commands
+func (c *collection) neighborsOnlyBackend() bool {
+	return config.GNMI.enabled() || exabgpEnabled() || c.northbound.enabled() || calicoEnabled() || ciliumEnabled()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// readFile : Returns the content of a file of testdata
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// testCollection : Returns a collection of the local router with its own copy of the metrics, whose
+// vtysh answers the commands with the outputs, given as is or as the path of a file of testdata, and
+// fails the others as bgpd does for an unknown command
+func testCollection(t *testing.T, outputs map[string]string, metrics ...prometheus.Collector) *collection {
+	t.Helper()
+	dir := t.TempDir()
+	var commands []string
+	for command := range outputs {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	script := "#!/bin/sh\ncase \"$2\" in\n"
+	for i, command := range commands {
+		output := outputs[command]
+		if strings.HasPrefix(output, "testdata/") {
+			output = readFile(t, output)
+		}
+		path := filepath.Join(dir, fmt.Sprint(i))
+		if err := os.WriteFile(path, []byte(output), 0600); err != nil {
+			t.Fatal(err)
+		}
+		script += fmt.Sprintf("'%s') cat '%s' ;;\n", command, path)
+	}
+	script += "*) echo \"% Unknown command: $2\"; exit 1 ;;\nesac\n"
+	vtysh := filepath.Join(dir, "vtysh")
+	if err := os.WriteFile(vtysh, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	setFlags(t, map[string]string{"vtysh.path": vtysh, "vtysh.retries": "0"})
+
+	// The collectors are only registered when the exporter starts, so the collection gets its own
+	// copy of the metrics of the test only
+	collectors := targetCollectors
+	t.Cleanup(func() { targetCollectors = collectors })
+	targetCollectors = metrics
+	c := newCollection(context.Background(), localTargetConfig, nil)
+	c.state = newTargetState()
+	c.ownMetrics()
+	return c
+}
+
+// TestVtyshRetryStopped : Checks that the retries of a failed command stop with the collection
+func TestVtyshRetryStopped(t *testing.T) {
+	c := testCollection(t, nil)
+	setFlags(t, map[string]string{"vtysh.retries": "3", "vtysh.retry-backoff": "1h"})
+	ctx, cancel := context.WithCancel(context.Background())
+	c.ctx = ctx
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.vtysh("show bgp summary")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("the command did not fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the retries went on once the collection was stopped")
+	}
+}
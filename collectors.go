@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	bgpScrapeCollectorDuration = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_scrape_collector_duration_seconds",
+		Help: "The duration of the last run of a given collector in seconds",
+	},
+		[]string{
+			"collector",
+		})
+)
+
+var (
+	bgpScrapeCollectorSuccess = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_scrape_collector_success",
+		Help: "Whether the last run of a given collector succeeded",
+	},
+		[]string{
+			"collector",
+		})
+)
+
+// collectorNeeds : What a collector needs of the backend
+type collectorNeeds int
+
+const (
+	// needsNeighbors : The collector only uses the collected neighbors, or its own source
+	needsNeighbors collectorNeeds = iota
+	// needsShowCommands : The collector runs show commands, which the backends giving the neighbors only cannot
+	needsShowCommands
+	// needsFrr : The collector runs show commands specific to FRR
+	needsFrr
+)
+
+// bgpCollector : This represents a named collector, enabled by its flag (or the configuration), and
+// whose metrics are only registered when it runs
+type bgpCollector struct {
+	name    string
+	enabled func() bool
+	needs   collectorNeeds
+	record  func(c *collection)
+	metrics []prometheus.Collector
+}
+
+// bgpCollectors : The collectors run after the neighbors, in this order
+var bgpCollectors = []bgpCollector{
+	{"peer_groups", flagEnabled(aggregatePeerGroups), needsNeighbors, recordPeerGroupMetrics,
+		[]prometheus.Collector{bgpPeerGroupNeighbors, bgpPeerGroupNeighborsEstablished, bgpPeerGroupAcceptedPrefixes}},
+	{"asns", flagEnabled(aggregateASNs), needsNeighbors, recordASNMetrics,
+		[]prometheus.Collector{bgpASNNeighbors, bgpASNNeighborsEstablished, bgpASNAcceptedPrefixes}},
+	{"calico", calicoEnabled, needsNeighbors, recordCalicoMetrics,
+		[]prometheus.Collector{bgpCalicoPeerInfo}},
+	{"cilium", ciliumEnabled, needsNeighbors, recordCiliumMetrics, nil},
+	{"summary", flagEnabled(collectSummary), needsShowCommands, recordSummaryMetrics,
+		[]prometheus.Collector{bgpRibEntries, bgpRibEntriesPeak, bgpRibPaths, bgpMemoryBytes, bgpNeighborPrefixesReceived, bgpNeighborPrefixesSent, bgpNeighborOutputQueue, bgpNeighborAddressFamilyState}},
+	{"policy", flagEnabled(collectPolicy), needsShowCommands, recordPolicyMetrics,
+		[]prometheus.Collector{bgpNeighborPolicyDeniedPrefixes}},
+	{"security", flagEnabled(collectSecurity), needsShowCommands, recordSecurityMetrics,
+		[]prometheus.Collector{bgpNeighborTTLSecurity, bgpNeighborAuthentication}},
+	{"dampening", flagEnabled(collectDampening), needsFrr, recordDampeningMetrics,
+		[]prometheus.Collector{bgpDampenedPaths, bgpHistoryPaths, bgpNeighborDampenedPaths, bgpNeighborHistoryPaths, bgpNeighborDampeningReuse}},
+	{"rpki", flagEnabled(collectRpki), needsFrr, recordRpkiMetrics,
+		[]prometheus.Collector{bgpRpkiCacheConnected, bgpRpkiRoaPrefixes, bgpRpkiPrefixes}},
+	{"memory", flagEnabled(collectMemory), needsFrr, recordMemoryMetrics,
+		[]prometheus.Collector{bgpdHeapBytes, bgpdMemoryObjects, bgpdMemoryBytes}},
+	{"vpn", flagEnabled(collectVpn), needsFrr, recordVpnMetrics,
+		[]prometheus.Collector{bgpVpnRdPrefixes, bgpVpnRdPaths}},
+	{"flowspec", flagEnabled(collectFlowspec), needsFrr, recordFlowspecMetrics,
+		[]prometheus.Collector{bgpFlowspecRules}},
+	{"evpn", flagEnabled(collectEvpn), needsFrr, recordEvpnMetrics,
+		[]prometheus.Collector{bgpEvpnRibPrefixes, bgpEvpnRibPaths, bgpNeighborEvpnPaths}},
+	{"evpn_vni", flagEnabled(collectEvpnVni), needsFrr, recordEvpnVniMetrics,
+		[]prometheus.Collector{bgpEvpnVnis, bgpEvpnVniMacs, bgpEvpnVniArpNd, bgpEvpnVniRemoteVteps}},
+	{"tcp", flagEnabled(collectTCP), needsFrr, recordTCPMetrics,
+		[]prometheus.Collector{bgpNeighborTCPRtt, bgpNeighborTCPRetransmits, bgpNeighborTCPSendQueue}},
+	{"nexthop", flagEnabled(collectNexthop), needsFrr, recordNexthopMetrics,
+		[]prometheus.Collector{bgpNexthopValid, bgpNexthopPaths, bgpNexthopIgpMetric, bgpNexthopResolvingNexthops, bgpNexthopResolvedInfo}},
+	{"frr_info", flagEnabled(collectFrrInfo), needsFrr, recordFrrInfoMetrics,
+		[]prometheus.Collector{bgpFrrInfo, bgpFrrDaemonUp}},
+	{"watchfrr", flagEnabled(collectWatchfrr), needsFrr, recordWatchfrrMetrics,
+		[]prometheus.Collector{bgpFrrWatchfrrDaemonState, bgpFrrWatchfrrRestarting, bgpFrrWatchfrrRestartBackoff, bgpFrrDaemonRestarts}},
+	{"default_route", flagEnabled(collectDefaultRoute), needsFrr, recordDefaultRouteMetrics,
+		[]prometheus.Collector{bgpNeighborDefaultReceived, bgpNeighborDefaultOriginated}},
+	{"prefixes", monitoredPrefixesEnabled, needsFrr, recordPrefixMetrics,
+		[]prometheus.Collector{bgpPrefixPresent, bgpPrefixBestPath, bgpPrefixPaths, bgpPrefixAdvertised}},
+	{"metallb", flagEnabled(metallbMode), needsFrr, recordMetallbMetrics,
+		[]prometheus.Collector{bgpMetallbPrefixAdvertised}},
+}
+
+func flagEnabled(f *bool) func() bool {
+	return func() bool { return *f }
+}
+
+// collectorNamed : Returns the collector of the given name, nil if there is none
+func collectorNamed(name string) *bgpCollector {
+	for i := range bgpCollectors {
+		if bgpCollectors[i].name == name {
+			return &bgpCollectors[i]
+		}
+	}
+	return nil
+}
+
+// registerCollectorMetrics : Registers the metrics of the collectors enabled by the flags or by a module of /probe
+func registerCollectorMetrics(r prometheus.Registerer) {
+	r.MustRegister(bgpScrapeCollectorDuration)
+	r.MustRegister(bgpScrapeCollectorSuccess)
+	for _, c := range bgpCollectors {
+		if c.enabled() || moduleCollector(c.name) {
+			r.MustRegister(c.metrics...)
+		}
+	}
+}
+
+// runCollectors : Runs the enabled collectors which the backend and the platform allow
+func (c *collection) runCollectors() {
+	for _, bc := range bgpCollectors {
+		if !c.collectorEnabled(bc.name, bc.enabled()) {
+			continue
+		}
+		if bc.needs >= needsShowCommands && c.neighborsOnlyBackend() {
+			continue
+		}
+		if bc.needs >= needsFrr && c.ios() {
+			continue
+		}
+		c.runCollector(bc.name, bc.record)
+	}
+}
+
+// runCollector : Runs the collector, exporting its duration and whether it succeeded, i.e. did not
+// report any failure
+func (c *collection) runCollector(name string, record func(c *collection)) {
+	start := time.Now()
+	failures := c.failures
+	record(c)
+	labels := prometheus.Labels{"collector": name}
+	metric(c, bgpScrapeCollectorDuration).With(labels).Set(time.Since(start).Seconds())
+	metric(c, bgpScrapeCollectorSuccess).With(labels).Set(boolToFloat(c.failures == failures))
+}
+
+// neighborCollectorMetrics : The metrics of the neighbors, which are always collected
+var neighborCollectorMetrics []prometheus.Collector
+
+// collectorFamilyNames : Returns the names of the metric families of the collectors, kept as they were created
+func collectorFamilyNames(collectors []prometheus.Collector) map[string]bool {
+	names := make(map[string]bool)
+	for _, c := range collectors {
+		if name, ok := metricNames[c]; ok {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// metricsHandler : Serves the metrics, restricted with collect[] parameters to those of the given
+// collectors, for different scrape jobs to scrape them at different intervals. The metrics which are not
+// of a collector (about the exporter itself, or the scrape of the collectors) are always served.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if !metricsReady() {
+		http.Error(w, "No successful collection yet", http.StatusServiceUnavailable)
+		return
+	}
+	g := targetsGatherer(prometheus.DefaultGatherer)
+	if selected := r.URL.Query()["collect[]"]; len(selected) > 0 {
+		filtered, err := selectCollectors(g, selected)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		g = filtered
+	}
+	promhttp.HandlerFor(exporterGatherer(g), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// selectCollectors : Returns a gatherer dropping the metric families of the collectors not selected
+func selectCollectors(g prometheus.Gatherer, selected []string) (prometheus.Gatherer, error) {
+	collectors := map[string][]prometheus.Collector{"neighbors": neighborCollectorMetrics}
+	for _, c := range bgpCollectors {
+		collectors[c.name] = c.metrics
+	}
+	keep := make(map[string]bool)
+	for _, name := range selected {
+		metrics, ok := collectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+		for family := range collectorFamilyNames(metrics) {
+			keep[family] = true
+		}
+	}
+	drop := make(map[string]bool)
+	for _, metrics := range collectors {
+		for family := range collectorFamilyNames(metrics) {
+			drop[family] = !keep[family]
+		}
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		var families []*dto.MetricFamily
+		for _, mf := range mfs {
+			if !drop[mf.GetName()] {
+				families = append(families, mf)
+			}
+		}
+		return families, err
+	}), nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestCollectorFamilyNames : Checks that the names of all the metrics of the collectors are known, for
+// collect[] not to serve them whatever the collectors selected
+func TestCollectorFamilyNames(t *testing.T) {
+	neighbors := &collectorTracker{Registerer: prometheus.NewRegistry()}
+	registerNeighborMetrics(neighbors)
+	collectors := map[string][]prometheus.Collector{"neighbors": neighbors.collectors}
+	for _, c := range bgpCollectors {
+		collectors[c.name] = c.metrics
+	}
+	for name, metrics := range collectors {
+		if got := len(collectorFamilyNames(metrics)); got != len(metrics) {
+			t.Errorf("collector %s: got %d names for %d metrics", name, got, len(metrics))
+		}
+	}
+}
+
+func TestSelectCollectors(t *testing.T) {
+	r := prometheus.NewRegistry()
+	r.MustRegister(bgpRpkiRoaPrefixes, bgpDampenedPaths, bgpScrapeCollectorSuccess)
+	t.Cleanup(func() {
+		bgpRpkiRoaPrefixes.Reset()
+		bgpDampenedPaths.Set(0)
+		bgpScrapeCollectorSuccess.Reset()
+	})
+	bgpRpkiRoaPrefixes.WithLabelValues("ipv4").Set(1)
+	bgpDampenedPaths.Set(2)
+	bgpScrapeCollectorSuccess.WithLabelValues("rpki").Set(1)
+
+	tests := []struct {
+		selected []string
+		want     []string
+	}{
+		{[]string{"rpki"}, []string{"bgp_rpki_roa_prefixes", "bgp_scrape_collector_success"}},
+		{[]string{"dampening"}, []string{"bgp_dampened_paths", "bgp_scrape_collector_success"}},
+		{[]string{"rpki", "dampening"}, []string{"bgp_dampened_paths", "bgp_rpki_roa_prefixes", "bgp_scrape_collector_success"}},
+	}
+	for _, tt := range tests {
+		g, err := selectCollectors(r, tt.selected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mfs, err := g.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, mf := range mfs {
+			got = append(got, mf.GetName())
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("collect[]=%v: got %v, want %v", tt.selected, got, tt.want)
+		}
+	}
+	if _, err := selectCollectors(r, []string{"nope"}); err == nil {
+		t.Error("an unknown collector was selected")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var configFile = flag.String("config.file", "", "Path to the YAML configuration file")
+
+// Config : This represents the configuration file
+type Config struct {
+	Neighbors  NeighborsConfig         `yaml:"neighbors"`
+	SSH        SSHConfig               `yaml:"ssh"`
+	GNMI       GNMIConfig              `yaml:"gnmi"`
+	Northbound NorthboundConfig        `yaml:"northbound"`
+	Targets    []TargetConfig          `yaml:"targets"`
+	Discovery  DiscoveryConfig         `yaml:"target_discovery"`
+	Modules    map[string]ModuleConfig `yaml:"modules"`
+	Vault      VaultConfig             `yaml:"vault"`
+	// MonitoredPrefixes : The prefixes whose presence and advertisement are exported, e.g. anycast prefixes
+	MonitoredPrefixes []string `yaml:"monitored_prefixes"`
+}
+
+// NeighborsConfig : This represents the configuration of which neighbors are exported
+type NeighborsConfig struct {
+	Include NeighborFilter `yaml:"include"`
+	Exclude NeighborFilter `yaml:"exclude"`
+	// Max : The maximum number of neighbors exported one by one, the others being summed (0 for no maximum)
+	Max int `yaml:"max"`
+	// Expected : The neighbors which should exist on the router (on each router in multi-router mode)
+	Expected []ExpectedNeighbor `yaml:"expected"`
+	// PrefixRanges : The acceptable numbers of accepted prefixes of the neighbors
+	PrefixRanges []PrefixRange `yaml:"prefix_ranges"`
+}
+
+// NeighborFilter : This represents a list of neighbors, matched by address (or CIDR), remote ASN or description
+type NeighborFilter struct {
+	Addresses    []string `yaml:"addresses"`
+	ASNs         []uint32 `yaml:"asns"`
+	Descriptions []string `yaml:"descriptions"`
+
+	networks     []*net.IPNet
+	descriptions []*regexp.Regexp
+}
+
+// config : The loaded configuration, empty if no configuration file is given
+var config = &Config{}
+
+// loadConfig : Reads and validates the configuration file
+func loadConfig(path string) (*Config, error) {
+	c := &Config{}
+	if path == "" {
+		return c, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(content, c); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+	}
+	if err := c.Neighbors.Include.compile(); err != nil {
+		return nil, fmt.Errorf("invalid neighbors include filter: %s", err)
+	}
+	if err := c.Neighbors.Exclude.compile(); err != nil {
+		return nil, fmt.Errorf("invalid neighbors exclude filter: %s", err)
+	}
+	if c.Neighbors.Max < 0 {
+		return nil, fmt.Errorf("invalid neighbors max %d: must not be negative", c.Neighbors.Max)
+	}
+	if err := validateExpectedNeighbors(c.Neighbors.Expected); err != nil {
+		return nil, fmt.Errorf("invalid expected neighbors: %s", err)
+	}
+	if err := validatePrefixRanges(c.Neighbors.PrefixRanges); err != nil {
+		return nil, fmt.Errorf("invalid prefix ranges: %s", err)
+	}
+	if err := c.SSH.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ssh configuration: %s", err)
+	}
+	if err := c.GNMI.validate(); err != nil {
+		return nil, fmt.Errorf("invalid gnmi configuration: %s", err)
+	}
+	if err := c.Northbound.validate(); err != nil {
+		return nil, fmt.Errorf("invalid northbound configuration: %s", err)
+	}
+	if (len(c.Targets) > 0 || c.Discovery.enabled()) && (c.SSH.enabled() || c.GNMI.enabled() || c.Northbound.enabled()) {
+		return nil, fmt.Errorf("targets cannot be combined with the ssh, gnmi or northbound sections, which configure a single router")
+	}
+	if err := validateTargets(c.Targets); err != nil {
+		return nil, fmt.Errorf("invalid targets: %s", err)
+	}
+	if err := c.Discovery.validate(); err != nil {
+		return nil, fmt.Errorf("invalid target discovery: %s", err)
+	}
+	if err := validateMonitoredPrefixes(c.MonitoredPrefixes); err != nil {
+		return nil, fmt.Errorf("invalid monitored prefixes: %s", err)
+	}
+	if err := validateModules(c.Modules); err != nil {
+		return nil, fmt.Errorf("invalid modules: %s", err)
+	}
+	if err := c.Vault.validate(c.vaultUsed()); err != nil {
+		return nil, fmt.Errorf("invalid vault configuration: %s", err)
+	}
+	return c, nil
+}
+
+// loadAndSetConfig : Loads the configuration file given by the flags as the configuration in use
+func loadAndSetConfig() error {
+	c, err := loadConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	config = c
+	return nil
+}
+
+func (f *NeighborFilter) compile() error {
+	for _, a := range f.Addresses {
+		if !strings.Contains(a, "/") {
+			ip := net.ParseIP(a)
+			if ip == nil {
+				return fmt.Errorf("invalid address %q", a)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			a = fmt.Sprintf("%s/%d", a, bits)
+		}
+		_, network, err := net.ParseCIDR(a)
+		if err != nil {
+			return fmt.Errorf("invalid network %q", a)
+		}
+		f.networks = append(f.networks, network)
+	}
+	for _, d := range f.Descriptions {
+		re, err := regexp.Compile(d)
+		if err != nil {
+			return fmt.Errorf("invalid description regex %q: %s", d, err)
+		}
+		f.descriptions = append(f.descriptions, re)
+	}
+	return nil
+}
+
+func (f *NeighborFilter) empty() bool {
+	return len(f.Addresses) == 0 && len(f.ASNs) == 0 && len(f.Descriptions) == 0
+}
+
+// matches : Returns whether any of the addresses, ASNs or descriptions of the filter match the neighbor
+func (f *NeighborFilter) matches(n *BgpNeighbor) bool {
+	if n.IP != nil {
+		for _, network := range f.networks {
+			if network.Contains(n.IP) {
+				return true
+			}
+		}
+	}
+	if n.RemoteAS != "" {
+		for _, asn := range f.ASNs {
+			if strconv.FormatUint(uint64(asn), 10) == n.RemoteAS {
+				return true
+			}
+		}
+	}
+	for _, re := range f.descriptions {
+		if re.MatchString(n.Description) {
+			return true
+		}
+	}
+	return false
+}
+
+// filtered : Returns whether the neighbor must not be exported: either it is not included
+// (when an include filter is configured) or it is excluded
+func (c *NeighborsConfig) filtered(n *BgpNeighbor) bool {
+	if !c.Include.empty() && !c.Include.matches(n) {
+		return true
+	}
+	return c.Exclude.matches(n)
+}
+
+// filterNeighbors : Returns the neighbors which are not filtered by the configuration
+func filterNeighbors(neighbors []BgpNeighbor) []BgpNeighbor {
+	var kept []BgpNeighbor
+	for i := range neighbors {
+		if !config.Neighbors.filtered(&neighbors[i]) {
+			kept = append(kept, neighbors[i])
+		}
+	}
+	return kept
+}
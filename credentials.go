@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PasswordSource : This represents where a password is read from instead of the configuration. It is read
+// at each SSH session (the connection being made again when it changed) and each gNMI subscription, so
+// that a rotated secret is used from then on.
+type PasswordSource struct {
+	// PasswordFile : A file holding the password, e.g. a mounted secret
+	PasswordFile string `yaml:"password_file"`
+	// PasswordEnv : The environment variable holding the password
+	PasswordEnv string `yaml:"password_env"`
+	// PasswordVault : The Vault secret holding the password, as path#key, the key being "password" if
+	// omitted, e.g. "secret/data/routers/edge1#password" for a KV version 2 engine mounted on secret/
+	PasswordVault string `yaml:"password_vault"`
+}
+
+// given : Whether a password is configured, either as is or by one of the sources
+func (s *PasswordSource) given(password string) bool {
+	return password != "" || s.PasswordFile != "" || s.PasswordEnv != "" || s.PasswordVault != ""
+}
+
+// validate : Checks that the password is configured only once and that its source exists
+func (s *PasswordSource) validate(password string) error {
+	count := 0
+	for _, v := range []string{password, s.PasswordFile, s.PasswordEnv, s.PasswordVault} {
+		if v != "" {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf("only one of password, password_file, password_env and password_vault can be given")
+	}
+	if s.PasswordFile != "" {
+		if _, err := os.Stat(s.PasswordFile); err != nil {
+			return fmt.Errorf("invalid password file: %s", err)
+		}
+	}
+	if s.PasswordEnv != "" {
+		if _, ok := os.LookupEnv(s.PasswordEnv); !ok {
+			return fmt.Errorf("the environment variable %s is not set", s.PasswordEnv)
+		}
+	}
+	if s.PasswordVault != "" && strings.HasPrefix(s.PasswordVault, "#") {
+		return fmt.Errorf("invalid vault secret %q: no path given", s.PasswordVault)
+	}
+	return nil
+}
+
+// read : Returns the password, given as is or read from its source
+func (s *PasswordSource) read(password string) (string, error) {
+	switch {
+	case s.PasswordFile != "":
+		content, err := os.ReadFile(s.PasswordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case s.PasswordEnv != "":
+		return os.Getenv(s.PasswordEnv), nil
+	case s.PasswordVault != "":
+		path, key, found := strings.Cut(s.PasswordVault, "#")
+		if !found {
+			key = "password"
+		}
+		return config.Vault.secret(path, key)
+	}
+	return password, nil
+}
+
+// VaultConfig : This represents the HashiCorp Vault server the passwords of password_vault are read from
+type VaultConfig struct {
+	// Address : The address of the server, e.g. https://vault:8200, $VAULT_ADDR by default
+	Address string `yaml:"address"`
+	// TokenFile : The file holding the token, e.g. written by the Vault agent, read at each request.
+	// $VAULT_TOKEN is used by default.
+	TokenFile string `yaml:"token_file"`
+	Namespace string `yaml:"namespace"`
+	CAFile    string `yaml:"ca_file"`
+	// RefreshInterval : How long the secrets are kept before being read again, 5m by default
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	client *http.Client
+}
+
+// vaultSecrets : The values of the secrets read from Vault by path and key, kept for the refresh interval
+// rather than being read at each SSH session
+var vaultSecrets = struct {
+	sync.Mutex
+	values map[string]*vaultSecret
+}{values: make(map[string]*vaultSecret)}
+
+// vaultSecret : This represents a value read from Vault and when. It is locked while read, for the
+// sessions needing it to wait for a single request without holding up those needing other secrets.
+type vaultSecret struct {
+	sync.Mutex
+	value string
+	read  time.Time
+}
+
+// validate : Checks that the server can be queried if any password is read from it, filling in the defaults
+func (c *VaultConfig) validate(used bool) error {
+	if c.Address == "" {
+		c.Address = os.Getenv("VAULT_ADDR")
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = 5 * time.Minute
+	}
+	c.client = &http.Client{Timeout: *vtyshTimeout}
+	if !used {
+		return nil
+	}
+	if c.Address == "" {
+		return fmt.Errorf("no address given and VAULT_ADDR is not set")
+	}
+	if _, ok := os.LookupEnv("VAULT_TOKEN"); c.TokenFile == "" && !ok {
+		return fmt.Errorf("no token file given and VAULT_TOKEN is not set")
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in %s", c.CAFile)
+		}
+		c.client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	return nil
+}
+
+// token : Returns the token authenticating the requests
+func (c *VaultConfig) token() (string, error) {
+	if c.TokenFile == "" {
+		return os.Getenv("VAULT_TOKEN"), nil
+	}
+	content, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// secret : Returns the value of the key of the secret at the path, read again once older than the
+// refresh interval
+func (c *VaultConfig) secret(path, key string) (string, error) {
+	vaultSecrets.Lock()
+	s, ok := vaultSecrets.values[path+"#"+key]
+	if !ok {
+		s = &vaultSecret{}
+		vaultSecrets.values[path+"#"+key] = s
+	}
+	vaultSecrets.Unlock()
+
+	s.Lock()
+	defer s.Unlock()
+	if !s.read.IsZero() && time.Since(s.read) < c.RefreshInterval {
+		return s.value, nil
+	}
+	value, err := c.readSecret(path, key)
+	if err != nil {
+		return "", err
+	}
+	s.value, s.read = value, time.Now()
+	return value, nil
+}
+
+// readSecret : Reads the value of the key of the secret at the path, of a KV engine of either version
+func (c *VaultConfig) readSecret(path, key string) (string, error) {
+	token, err := c.token()
+	if err != nil {
+		return "", fmt.Errorf("vault: %s", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(c.Address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %s", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: reading %s: %s", path, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("vault: reading %s: %s", path, err)
+	}
+	// The KV version 2 engine nests the versioned data
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: no key %q in %s", key, path)
+	}
+	return value, nil
+}
+
+// vaultUsed : Whether any password of the configuration is read from Vault
+func (c *Config) vaultUsed() bool {
+	sources := []PasswordSource{c.SSH.Secret, c.GNMI.Secret, c.Discovery.Template.Secret}
+	for _, t := range c.Targets {
+		sources = append(sources, t.Secret)
+	}
+	for _, m := range c.Modules {
+		sources = append(sources, m.Secret)
+	}
+	for _, s := range sources {
+		if s.PasswordVault != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestVaultSecretSlowPath : Checks that a secret slow to read does not hold up the others, and is read
+// once for the sessions needing it at the same time
+func TestVaultSecretSlowPath(t *testing.T) {
+	release := make(chan struct{})
+	var slowReads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/v1/secret/data/slow" {
+			slowReads.Add(1)
+			<-release
+		}
+		fmt.Fprintf(w, `{"data": {"data": {"password": %q}}}`, r.URL.Path)
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "token")
+	c := &VaultConfig{Address: server.URL, RefreshInterval: time.Minute, client: server.Client()}
+	t.Cleanup(func() {
+		vaultSecrets.Lock()
+		vaultSecrets.values = make(map[string]*vaultSecret)
+		vaultSecrets.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := c.secret("secret/data/slow", "password"); err != nil || v != "/v1/secret/data/slow" {
+				t.Errorf("got %q, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.secret("secret/data/fast", "password")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the secret waited for the slow one")
+	}
+	close(release)
+	wg.Wait()
+	if n := slowReads.Load(); n != 1 {
+		t.Errorf("the slow secret was read %d times, want once", n)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpDampenedPaths = newGauge(prometheus.GaugeOpts{
+		Name: "bgp_dampened_paths",
+		Help: "The number of paths suppressed by route flap dampening",
+	})
+)
+
+var (
+	bgpHistoryPaths = newGauge(prometheus.GaugeOpts{
+		Name: "bgp_history_paths",
+		Help: "The number of withdrawn paths kept as dampening history",
+	})
+)
+
+var (
+	bgpNeighborDampenedPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_dampened_paths",
+		Help: "The number of paths from a given BGP neighbor suppressed by route flap dampening",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborHistoryPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_history_paths",
+		Help: "The number of withdrawn paths from a given BGP neighbor kept as dampening history",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborDampeningReuse = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_dampening_max_reuse_seconds",
+		Help: "The longest time until a dampened path from a given BGP neighbor is reused",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+// BgpDampening : This represents the route flap dampening state of the BGP table
+type BgpDampening struct {
+	DampenedPaths float64
+	HistoryPaths  float64
+	Neighbors     map[string]*BgpNeighborDampening
+}
+
+// BgpNeighborDampening : This represents the route flap dampening state of the paths from a BGP Neighbor
+type BgpNeighborDampening struct {
+	DampenedPaths float64
+	HistoryPaths  float64
+	MaxReuse      float64
+}
+
+// Matches the status codes, network, from, flaps and duration columns of "show ip bgp dampening flap-statistics"
+var bgpFlapStatisticsRegex = regexp.MustCompile(`^.([dh]).\s*(\S+)?\s+([\d.:a-fA-F]+)\s+(\d+)\s+(\S+)\s+(.*)$`)
+var bgpUptimeRegex = regexp.MustCompile(`^(?:(\d+):(\d+):(\d+)|(\d+)d(\d+)h(?:(\d+)m)?|(\d+)w(\d+)d(?:(\d+)h)?|(\d+)y(\d+)w)$`)
+
+func recordDampeningMetrics(c *collection) {
+	o, err := c.vtysh("show ip bgp dampening flap-statistics")
+	if err != nil {
+		c.collectorFailed("dampening", err)
+		return
+	}
+	d := parseDampening(o)
+
+	metric(c, bgpDampenedPaths).Set(d.DampenedPaths)
+	metric(c, bgpHistoryPaths).Set(d.HistoryPaths)
+
+	metric(c, bgpNeighborDampenedPaths).Reset()
+	metric(c, bgpNeighborHistoryPaths).Reset()
+	metric(c, bgpNeighborDampeningReuse).Reset()
+	for _, n := range c.exportedNeighbors() {
+		if _, ok := d.Neighbors[n.key()]; !ok {
+			d.Neighbors[n.key()] = new(BgpNeighborDampening)
+		}
+	}
+	exported := make(map[string]bool)
+	for _, n := range c.exportedNeighbors() {
+		exported[n.key()] = true
+	}
+	for ip, n := range d.Neighbors {
+		// Filtered neighbors are not in the store, and the paths only show their address
+		if !exported[ip] && (!config.Neighbors.Include.empty() || !config.Neighbors.Exclude.empty() || c.neighborTruncated(ip)) {
+			continue
+		}
+		metric(c, bgpNeighborDampenedPaths).With(neighborLabels(ip)).Set(n.DampenedPaths)
+		metric(c, bgpNeighborHistoryPaths).With(neighborLabels(ip)).Set(n.HistoryPaths)
+		metric(c, bgpNeighborDampeningReuse).With(neighborLabels(ip)).Set(n.MaxReuse)
+	}
+}
+
+func parseDampening(s string) *BgpDampening {
+	d := &BgpDampening{Neighbors: make(map[string]*BgpNeighborDampening)}
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		check := bgpFlapStatisticsRegex.MatchString(line)
+		if !check {
+			continue
+		}
+		m := bgpFlapStatisticsRegex.FindStringSubmatch(line)
+		n, ok := d.Neighbors[m[3]]
+		if !ok {
+			n = new(BgpNeighborDampening)
+			d.Neighbors[m[3]] = n
+		}
+		if m[1] == "h" {
+			d.HistoryPaths++
+			n.HistoryPaths++
+			continue
+		}
+		d.DampenedPaths++
+		n.DampenedPaths++
+		// The reuse column is only filled in for dampened paths and directly follows the duration
+		if fields := strings.Fields(m[6]); len(fields) > 0 {
+			if reuse, ok := parseUptime(fields[0]); ok && reuse > n.MaxReuse {
+				n.MaxReuse = reuse
+			}
+		}
+	}
+	return d
+}
+
+// parseUptime : Converts the durations printed by bgpd (e.g. "01:02:03", "1d02h03m" or "02w3d04h") or IOS
+// (e.g. "1d02h", "2w3d" or "1y02w") to seconds
+func parseUptime(s string) (float64, bool) {
+	m := bgpUptimeRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	v := make([]float64, len(m))
+	for i := 1; i < len(m); i++ {
+		v[i], _ = strconv.ParseFloat(m[i], 64)
+	}
+	switch {
+	case m[1] != "":
+		return v[1]*3600 + v[2]*60 + v[3], true
+	case m[4] != "":
+		return v[4]*86400 + v[5]*3600 + v[6]*60, true
+	case m[7] != "":
+		return v[7]*604800 + v[8]*86400 + v[9]*3600, true
+	default:
+		return v[10]*31536000 + v[11]*604800, true
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseUptime(t *testing.T) {
+	tests := []struct {
+		uptime string
+		want   float64
+		ok     bool
+	}{
+		{"01:02:03", 3723, true},
+		{"1d02h03m", 93780, true},
+		{"1d02h", 93600, true},
+		{"02w3d04h", 1483200, true},
+		{"2w3d", 1468800, true},
+		{"1y02w", 32745600, true},
+		{"never", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseUptime(tt.uptime)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseUptime(%q) = %v, %v, want %v, %v", tt.uptime, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseDampening(t *testing.T) {
+	got := parseDampening(readFile(t, "testdata/frr/show_ip_bgp_dampening_flap-statistics.txt"))
+	want := &BgpDampening{
+		DampenedPaths: 3,
+		HistoryPaths:  2,
+		Neighbors: map[string]*BgpNeighborDampening{
+			"10.0.0.1": {DampenedPaths: 1, HistoryPaths: 1, MaxReuse: 2110},
+			"10.0.0.3": {DampenedPaths: 2, MaxReuse: 4200},
+			"fe80::1":  {HistoryPaths: 1},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordDampeningMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show ip bgp dampening flap-statistics": "testdata/frr/show_ip_bgp_dampening_flap-statistics.txt",
+	}, bgpDampenedPaths, bgpHistoryPaths, bgpNeighborDampeningReuse)
+	recordDampeningMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	want := `
+# HELP bgp_dampened_paths The number of paths suppressed by route flap dampening
+# TYPE bgp_dampened_paths gauge
+bgp_dampened_paths 3
+# HELP bgp_history_paths The number of withdrawn paths kept as dampening history
+# TYPE bgp_history_paths gauge
+bgp_history_paths 2
+# HELP bgp_neighbor_dampening_max_reuse_seconds The longest time until a dampened path from a given BGP neighbor is reused
+# TYPE bgp_neighbor_dampening_max_reuse_seconds gauge
+bgp_neighbor_dampening_max_reuse_seconds{interface="",ip="10.0.0.1",view=""} 2110
+bgp_neighbor_dampening_max_reuse_seconds{interface="",ip="10.0.0.3",view=""} 4200
+bgp_neighbor_dampening_max_reuse_seconds{interface="",ip="fe80::1",view=""} 0
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
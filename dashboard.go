@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GrafanaDashboard : This represents the parts of a Grafana dashboard model set by the exporter
+type GrafanaDashboard struct {
+	Title         string           `json:"title"`
+	UID           string           `json:"uid"`
+	Tags          []string         `json:"tags"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Refresh       string           `json:"refresh"`
+	Time          GrafanaTimeRange `json:"time"`
+	Templating    struct {
+		List []GrafanaVariable `json:"list"`
+	} `json:"templating"`
+	Panels []GrafanaPanel `json:"panels"`
+}
+
+// GrafanaTimeRange : This represents the default time range of a dashboard
+type GrafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GrafanaVariable : This represents a dashboard variable (the data source or a label value query)
+type GrafanaVariable struct {
+	Name       string             `json:"name"`
+	Label      string             `json:"label"`
+	Type       string             `json:"type"`
+	Query      string             `json:"query"`
+	Datasource *GrafanaDatasource `json:"datasource,omitempty"`
+	Refresh    int                `json:"refresh,omitempty"`
+	Multi      bool               `json:"multi,omitempty"`
+	IncludeAll bool               `json:"includeAll,omitempty"`
+}
+
+// GrafanaDatasource : This represents a reference to the data source chosen by the $datasource variable
+type GrafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// GrafanaPanel : This represents a panel of the dashboard
+type GrafanaPanel struct {
+	ID         int               `json:"id"`
+	Title      string            `json:"title"`
+	Type       string            `json:"type"`
+	Datasource GrafanaDatasource `json:"datasource"`
+	GridPos    GrafanaGridPos    `json:"gridPos"`
+	Targets    []GrafanaTarget   `json:"targets"`
+}
+
+// GrafanaGridPos : This represents the position and size of a panel
+type GrafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// GrafanaTarget : This represents a query of a panel
+type GrafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	Instant      bool   `json:"instant,omitempty"`
+	Format       string `json:"format,omitempty"`
+}
+
+// generateDashboard : Returns a Grafana dashboard of the neighbors, using the metric names and
+// labels exported with the current flags (metric prefix, compliant names and state set)
+func generateDashboard() *GrafanaDashboard {
+	m := metricName
+	selector := `{instance=~"$instance"}`
+	state := m("neighbor_state") + selector
+	if *stateSet {
+		// The state set has no state number, the table shows the name of the current state instead
+		state = m("neighbor_state") + `{instance=~"$instance"} == 1`
+	}
+	legend := "{{instance}} {{ip}}{{interface}} {{view}}"
+	datasource := GrafanaDatasource{Type: "prometheus", UID: "$datasource"}
+
+	d := &GrafanaDashboard{
+		Title:         "BGP Exporter",
+		UID:           "bgp-exporter",
+		Tags:          []string{"bgp", "frr"},
+		SchemaVersion: 39,
+		Refresh:       "30s",
+		Time:          GrafanaTimeRange{From: "now-6h", To: "now"},
+	}
+	d.Templating.List = []GrafanaVariable{
+		{Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus"},
+		{
+			Name:       "instance",
+			Label:      "Instance",
+			Type:       "query",
+			Query:      "label_values(" + m("neighbor_state") + ", instance)",
+			Datasource: &datasource,
+			Refresh:    2,
+			Multi:      true,
+			IncludeAll: true,
+		},
+	}
+	panels := []struct {
+		title   string
+		kind    string
+		targets []GrafanaTarget
+	}{
+		{"Established neighbors", "stat", []GrafanaTarget{
+			// The counts computed by the exporter also count the neighbors beyond the maximum exported one by one
+			{Expr: "sum(" + m("neighbors_by_state") + `{instance=~"$instance", state="established"})`, LegendFormat: "established"},
+			{Expr: "sum(" + m("neighbors_total") + selector + ")", LegendFormat: "configured"},
+		}},
+		{"Accepted prefixes", "stat", []GrafanaTarget{
+			{Expr: "sum(" + m("neighbor_accepted_prefixes") + selector + ")", LegendFormat: "accepted"},
+		}},
+		{"Neighbor state", "table", []GrafanaTarget{
+			{Expr: state, Instant: true, Format: "table"},
+		}},
+		{"Accepted prefixes per neighbor", "timeseries", []GrafanaTarget{
+			{Expr: m("neighbor_accepted_prefixes") + selector, LegendFormat: legend},
+		}},
+		{"Flaps per hour", "timeseries", []GrafanaTarget{
+			{Expr: "increase(" + m("neighbor_flaps_total") + selector + "[1h])", LegendFormat: legend},
+		}},
+		{"Received prefixes of the maximum (%)", "timeseries", []GrafanaTarget{
+			{Expr: "100 * " + m("neighbor_prefixes_received") + selector + " / on(instance, ip, interface, view, afi) " + m("neighbor_maximum_prefixes"), LegendFormat: legend + " {{afi}}"},
+		}},
+		{"Collector errors", "timeseries", []GrafanaTarget{
+			{Expr: "increase(" + m("collector_errors_total") + selector + "[5m])", LegendFormat: "{{instance}} {{collector}}"},
+		}},
+	}
+	for i, p := range panels {
+		// Two panels side by side per row
+		panel := GrafanaPanel{
+			ID:         i + 1,
+			Title:      p.title,
+			Type:       p.kind,
+			Datasource: datasource,
+			GridPos:    GrafanaGridPos{H: 8, W: 12, X: 12 * (i % 2), Y: 8 * (i / 2)},
+		}
+		for j, t := range p.targets {
+			t.RefID = string(rune('A' + j))
+			panel.Targets = append(panel.Targets, t)
+		}
+		d.Panels = append(d.Panels, panel)
+	}
+	return d
+}
+
+func dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(generateDashboard()); err != nil {
+		logger.Error("Failed to encode the dashboard", "err", err)
+	}
+}
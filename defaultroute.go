@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborDefaultReceived = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_default_received",
+		Help: "Whether the default route is received from a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborDefaultOriginated = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_default_originated",
+		Help: "Whether the default route is advertised to a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+// defaultRoutes : The default route of each address family
+var defaultRoutes = map[string]string{
+	"ipv4": "0.0.0.0/0",
+	"ipv6": "::/0",
+}
+
+// recordDefaultRouteMetrics : Exports whether the default route is received from and advertised to the
+// established neighbors of the default view, from the paths and the advertisement of its table entry
+func recordDefaultRouteMetrics(c *collection) {
+	metric(c, bgpNeighborDefaultReceived).Reset()
+	metric(c, bgpNeighborDefaultOriginated).Reset()
+	for afi, prefix := range defaultRoutes {
+		o, err := c.vtysh("show bgp " + afi + " unicast " + prefix + " json")
+		if err != nil {
+			c.collectorFailed("default_route", err)
+			continue
+		}
+		var entry BgpPrefixEntry
+		if err := json.Unmarshal([]byte(o), &entry); err != nil {
+			c.collectorFailed("default_route", fmt.Errorf("failed to parse the entry of %s: %s", prefix, err))
+			continue
+		}
+		received := make(map[string]bool)
+		for _, p := range entry.Paths {
+			if p.Peer != nil {
+				received[p.Peer.PeerID] = true
+			}
+		}
+		for _, n := range c.exportedNeighbors() {
+			if n.State != 6 || n.Vrf != "" || n.IP == nil {
+				continue
+			}
+			_, advertised := entry.AdvertisedTo[n.key()]
+			metric(c, bgpNeighborDefaultReceived).With(n.labels("afi", afi+"_unicast")).Set(boolToFloat(received[n.IP.String()]))
+			metric(c, bgpNeighborDefaultOriginated).With(n.labels("afi", afi+"_unicast")).Set(boolToFloat(advertised))
+		}
+	}
+}
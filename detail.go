@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var neighborsDetailInterval = flag.Duration("neighbors.detail-interval", 0, "Collect the neighbors from the summaries of the address families (see --collector.summary.address-families), only getting the detail of a neighbor with \"show ip bgp neighbors <neighbor>\" when it is new, its state changed or its detail is older than this interval (0 to get the detail of all the neighbors each time)")
+
+// detailEnabled : Whether the neighbors are collected from the summaries, with their detail on demand
+func detailEnabled() bool {
+	return *neighborsDetailInterval > 0
+}
+
+// validateNeighborsDetail : Returns an error if the neighbors cannot be collected from the summaries. The
+// summaries only list the neighbors of the default instance.
+func validateNeighborsDetail() error {
+	if detailEnabled() && *allInstances {
+		return fmt.Errorf("--neighbors.detail-interval cannot be used with --collector.all-instances")
+	}
+	return nil
+}
+
+// detailedNeighbors : Returns the neighbors listed in the summaries. The neighbors whose detail is not
+// due are those of the previous collection, with their state, uptime and accepted prefixes updated from
+// the summaries, their other values being those of their last detail.
+func (c *collection) detailedNeighbors() ([]BgpNeighbor, error) {
+	summaries := make(map[string]string)
+	peers := make(map[string]map[string]*BgpSummaryPeer)
+	var keys []string
+	for _, command := range c.summaryCommands() {
+		o, err := c.vtysh(command)
+		if err != nil {
+			return nil, err
+		}
+		summaries[command] = o
+		for afi, s := range parseSummary(o) {
+			for name, p := range s.Peers {
+				key := summaryPeerKey(name)
+				if peers[key] == nil {
+					peers[key] = make(map[string]*BgpSummaryPeer)
+					keys = append(keys, key)
+				}
+				peers[key][afi] = p
+			}
+		}
+	}
+	c.summaries = summaries
+
+	previous := make(map[string]BgpNeighbor)
+	for _, n := range c.state.neighbors.List() {
+		previous[n.key()] = n
+	}
+	var neighbors []BgpNeighbor
+	now := time.Now()
+	for _, key := range keys {
+		afs := peers[key]
+		state, uptime := summaryPeerState(afs)
+		p, ok := previous[key]
+		if ok && p.State == state && now.Sub(c.state.details[key]) < *neighborsDetailInterval {
+			neighbors = append(neighbors, updatedNeighbor(p, afs, state, uptime))
+			continue
+		}
+		detail, err := c.neighborDetail(key)
+		if err != nil {
+			return nil, err
+		}
+		c.state.details[key] = now
+		neighbors = append(neighbors, detail...)
+	}
+	for key := range c.state.details {
+		if peers[key] == nil {
+			delete(c.state.details, key)
+		}
+	}
+	return neighbors, nil
+}
+
+// summaryPeerKey : Returns the key of a neighbor as listed in the summary, which FRR prints as
+// "hostname(swp1)" for an unnumbered neighbor with the hostname capability
+func summaryPeerKey(name string) string {
+	if i := strings.LastIndex(name, "("); i > 0 && strings.HasSuffix(name, ")") {
+		return name[i+1 : len(name)-1]
+	}
+	return name
+}
+
+// summaryPeerState : Returns the state of the session of a neighbor listed in the summaries of its address
+// families, and its uptime once established
+func summaryPeerState(afs map[string]*BgpSummaryPeer) (float64, float64) {
+	var state, uptime float64
+	for _, p := range afs {
+		s := p.State
+		// An address family which was not negotiated is still listed for an established session
+		if s == -1 {
+			s = 6
+		}
+		if s > state {
+			state = s
+		}
+		if p.Uptime > uptime {
+			uptime = p.Uptime
+		}
+	}
+	if state != 6 {
+		uptime = 0
+	}
+	return state, uptime
+}
+
+// updatedNeighbor : Returns a copy of the neighbor of the previous collection, updated from the summaries
+func updatedNeighbor(n BgpNeighbor, afs map[string]*BgpSummaryPeer, state float64, uptime float64) BgpNeighbor {
+	c := n
+	c.State, c.Uptime = state, uptime
+	c.AddressFamilies = make(map[string]*BgpAddressFamily, len(n.AddressFamilies))
+	for name, af := range n.AddressFamilies {
+		a := *af
+		c.AddressFamilies[name] = &a
+	}
+	c.AcceptedPrefixes = 0
+	for afi, p := range afs {
+		if af, ok := c.AddressFamilies[afi]; ok {
+			af.AcceptedPrefixes = p.PrefixesReceived
+		}
+		c.AcceptedPrefixes += p.PrefixesReceived
+	}
+	return c
+}
+
+// neighborDetail : Returns the neighbor (by address or interface) as parsed from its detail
+func (c *collection) neighborDetail(key string) ([]BgpNeighbor, error) {
+	if c.useNeighborsJSON() {
+		var neighbors []BgpNeighbor
+		var parseErr error
+		err := c.vtyshStream("show bgp neighbors "+key+" json", func(r io.Reader) {
+			neighbors, parseErr = parseNeighborsJSON(r)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return neighbors, parseErr
+	}
+	var neighbors []BgpNeighbor
+	err := c.vtyshStream("show ip bgp neighbors "+key, func(r io.Reader) {
+		neighbors = parseBGP(r, c.target)
+	})
+	return neighbors, err
+}
+
+// summaryOutput : Returns the output of the summary command if it was run for the neighbors of this collection
+func (c *collection) summaryOutput(command string) (string, bool) {
+	o, ok := c.summaries[command]
+	delete(c.summaries, command)
+	return o, ok
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DiscoveryConfig : This represents the discovery of the routers of multi-router mode, from file_sd
+// style target files and DNS SRV records. The discovered routers get the backend and credentials of the template.
+type DiscoveryConfig struct {
+	Files           []string      `yaml:"files"`
+	DNSSRV          []string      `yaml:"dns_srv"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+	Template        TargetConfig  `yaml:"template"`
+}
+
+// FileSDGroup : This represents a group of targets of a file_sd file. The name, backend and platform
+// labels, if set, override those of the template.
+type FileSDGroup struct {
+	Targets []string          `yaml:"targets"`
+	Labels  map[string]string `yaml:"labels"`
+}
+
+// discovered : The routers found by the discovery (guarded by targetMetrics), and what was last read from the sources
+var discovered = struct {
+	targets  []TargetConfig
+	files    []TargetConfig
+	dns      []TargetConfig
+	modTimes map[string]time.Time
+	dnsAt    time.Time
+}{modTimes: make(map[string]time.Time)}
+
+func (c *DiscoveryConfig) enabled() bool {
+	return len(c.Files) > 0 || len(c.DNSSRV) > 0
+}
+
+// validate : Checks the discovery configuration, filling in the defaults
+func (c *DiscoveryConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = 5 * time.Minute
+	}
+	for _, pattern := range c.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid file pattern %q: %s", pattern, err)
+		}
+	}
+	// The template is checked with a placeholder target
+	t := c.Template
+	t.Name, t.Address = "template", "localhost:1"
+	return t.validate()
+}
+
+// multiRouter : Whether routers are collected as targets, listed in the configuration or discovered
+func multiRouter() bool {
+	return len(config.Targets) > 0 || config.Discovery.enabled()
+}
+
+// activeTargets : Returns the targets listed in the configuration followed by the discovered ones, those
+// of the shard if the targets are sharded
+func activeTargets() []TargetConfig {
+	targetMetrics.RLock()
+	defer targetMetrics.RUnlock()
+	var targets []TargetConfig
+	for _, t := range append(append([]TargetConfig(nil), config.Targets...), discovered.targets...) {
+		if inShard(t.Name) {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+// refreshTargets : Re-reads the target files which changed, and the DNS SRV records every refresh
+// interval, then updates the discovered targets, keeping the state of those which remain
+func refreshTargets() {
+	c := &config.Discovery
+	if !c.enabled() {
+		return
+	}
+	changed := false
+	if len(c.Files) > 0 && filesChanged(c.Files) {
+		targets, err := readTargetFiles(c)
+		if err != nil {
+			collectorFailed(localTarget, "discovery", err)
+		} else {
+			discovered.files = targets
+			changed = true
+		}
+	}
+	if len(c.DNSSRV) > 0 && time.Since(discovered.dnsAt) >= c.RefreshInterval {
+		discovered.dnsAt = time.Now()
+		targets, err := lookupTargets(c)
+		if err != nil {
+			collectorFailed(localTarget, "discovery", err)
+		} else {
+			discovered.dns = targets
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	states := make(map[string]*targetState)
+	for _, t := range discovered.targets {
+		states[t.Name] = t.state
+	}
+	static := make(map[string]bool)
+	for _, t := range config.Targets {
+		static[t.Name] = true
+	}
+	var targets []TargetConfig
+	seen := make(map[string]bool)
+	for _, t := range append(discovered.files, discovered.dns...) {
+		// The routers listed in the configuration, or found by several sources, are only collected once
+		if static[t.Name] || seen[t.Name] {
+			continue
+		}
+		seen[t.Name] = true
+		if s, ok := states[t.Name]; ok {
+			t.state = s
+		}
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+
+	targetMetrics.Lock()
+	discovered.targets = targets
+	targetMetrics.Unlock()
+	logger.Info("Discovered targets", "targets", len(targets))
+}
+
+// filesChanged : Whether the files matching the patterns changed, appeared or went away since they were read
+func filesChanged(patterns []string) bool {
+	modTimes := make(map[string]time.Time)
+	for _, pattern := range patterns {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			if fi, err := os.Stat(path); err == nil {
+				modTimes[path] = fi.ModTime()
+			}
+		}
+	}
+	changed := len(modTimes) != len(discovered.modTimes)
+	for path, t := range modTimes {
+		if !discovered.modTimes[path].Equal(t) {
+			changed = true
+		}
+	}
+	discovered.modTimes = modTimes
+	return changed
+}
+
+// readTargetFiles : Reads the targets of the file_sd files (JSON or YAML) matching the patterns
+func readTargetFiles(c *DiscoveryConfig) ([]TargetConfig, error) {
+	var targets []TargetConfig
+	for _, pattern := range c.Files {
+		paths, _ := filepath.Glob(pattern)
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			var groups []FileSDGroup
+			// JSON is a subset of YAML
+			if err := yaml.Unmarshal(content, &groups); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %s", path, err)
+			}
+			for _, g := range groups {
+				for _, address := range g.Targets {
+					t, err := discoveredTarget(c.Template, address, g.Labels)
+					if err != nil {
+						return nil, fmt.Errorf("invalid target %q in %s: %s", address, path, err)
+					}
+					targets = append(targets, t)
+				}
+			}
+		}
+	}
+	return targets, nil
+}
+
+// lookupTargets : Looks the targets up in the DNS SRV records, e.g. "_ssh._tcp.routers.example.com"
+func lookupTargets(c *DiscoveryConfig) ([]TargetConfig, error) {
+	var targets []TargetConfig
+	for _, name := range c.DNSSRV {
+		_, records, err := net.LookupSRV("", "", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			address := net.JoinHostPort(strings.TrimSuffix(r.Target, "."), fmt.Sprint(r.Port))
+			t, err := discoveredTarget(c.Template, address, nil)
+			if err != nil {
+				return nil, fmt.Errorf("invalid target %q of %s: %s", address, name, err)
+			}
+			targets = append(targets, t)
+		}
+	}
+	return targets, nil
+}
+
+// discoveredTarget : Returns the target of a discovered address with the backend and credentials of the
+// template, named by its host unless a name label is given, with the default port of the backend if the
+// address has none
+func discoveredTarget(template TargetConfig, address string, labels map[string]string) (TargetConfig, error) {
+	t := template
+	if labels["backend"] != "" {
+		t.Backend = labels["backend"]
+	}
+	if labels["platform"] != "" {
+		t.Platform = labels["platform"]
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+		port := "22"
+		if t.Backend == "northbound" {
+			port = "50051"
+		}
+		address = net.JoinHostPort(address, port)
+	}
+	t.Name = host
+	if labels["name"] != "" {
+		t.Name = labels["name"]
+	}
+	t.Address = address
+	return t, t.validate()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+var reverseLookup = flag.Bool("dns.reverse-lookup", false, "Resolve the neighbor addresses with PTR lookups and add them as the peer_dns label")
+var reverseLookupTimeout = flag.Duration("dns.timeout", 2*time.Second, "The timeout of a PTR lookup")
+var reverseLookupCacheTTL = flag.Duration("dns.cache-ttl", time.Hour, "How long PTR lookup results (including failures) are cached")
+
+// dnsCacheEntry : This represents the cached result of a PTR lookup
+type dnsCacheEntry struct {
+	name    string
+	expires time.Time
+}
+
+var dnsCache = struct {
+	sync.Mutex
+	entries map[string]dnsCacheEntry
+}{entries: make(map[string]dnsCacheEntry)}
+
+// dnsLookupConcurrency : How many PTR lookups of a collection run at the same time
+const dnsLookupConcurrency = 64
+
+// lookupAddr : Returns the names of the address from its PTR records
+var lookupAddr = net.DefaultResolver.LookupAddr
+
+// lookupPeersDNS : Sets the names of the neighbors from the PTR records of their addresses. The addresses
+// which are not cached are looked up in parallel, for a collection not to wait for them one by one.
+func lookupPeersDNS(neighbors []BgpNeighbor) {
+	if !*reverseLookup {
+		return
+	}
+	now := time.Now()
+	var missing []string
+	dnsCache.Lock()
+	for _, n := range neighbors {
+		if n.IP == nil {
+			continue
+		}
+		ip := n.IP.String()
+		entry, ok := dnsCache.entries[ip]
+		if !ok || !now.Before(entry.expires) {
+			// Marked as pending, for the address to be looked up once
+			dnsCache.entries[ip] = dnsCacheEntry{name: entry.name, expires: now.Add(*reverseLookupCacheTTL)}
+			missing = append(missing, ip)
+		}
+	}
+	dnsCache.Unlock()
+
+	slots := make(chan struct{}, dnsLookupConcurrency)
+	var wg sync.WaitGroup
+	for _, ip := range missing {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			name := lookupPTR(ip)
+			dnsCache.Lock()
+			dnsCache.entries[ip] = dnsCacheEntry{name: name, expires: time.Now().Add(*reverseLookupCacheTTL)}
+			dnsCache.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+
+	dnsCache.Lock()
+	defer dnsCache.Unlock()
+	for i := range neighbors {
+		if neighbors[i].IP != nil {
+			neighbors[i].PeerDNS = dnsCache.entries[neighbors[i].IP.String()].name
+		}
+	}
+}
+
+// lookupPTR : Returns the name of the address from its PTR record, or an empty string if it has none
+func lookupPTR(ip string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), *reverseLookupTimeout)
+	defer cancel()
+	names, err := lookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLookupPeersDNS : Checks that the addresses are looked up in parallel, once until they expire
+func TestLookupPeersDNS(t *testing.T) {
+	setFlags(t, map[string]string{"dns.reverse-lookup": "true", "dns.timeout": "1s"})
+	var lookups atomic.Int32
+	previous := lookupAddr
+	t.Cleanup(func() {
+		lookupAddr = previous
+		dnsCache.Lock()
+		dnsCache.entries = make(map[string]dnsCacheEntry)
+		dnsCache.Unlock()
+	})
+	lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		lookups.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		if addr == "10.0.0.99" {
+			return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+		}
+		return []string{"peer-" + addr + ".example.net."}, nil
+	}
+
+	var neighbors []BgpNeighbor
+	for i := 1; i <= 20; i++ {
+		neighbors = append(neighbors, BgpNeighbor{IP: net.ParseIP(fmt.Sprintf("10.0.0.%d", i))})
+	}
+	neighbors = append(neighbors, BgpNeighbor{IP: net.ParseIP("10.0.0.99")}, BgpNeighbor{})
+
+	start := time.Now()
+	lookupPeersDNS(neighbors)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("the lookups took %s, want them in parallel", d)
+	}
+	for _, n := range neighbors[:20] {
+		if want := "peer-" + n.IP.String() + ".example.net"; n.PeerDNS != want {
+			t.Errorf("got %q, want %q", n.PeerDNS, want)
+		}
+	}
+	if n := neighbors[20]; n.PeerDNS != "" {
+		t.Errorf("got %q for an address without PTR record", n.PeerDNS)
+	}
+
+	// The names are cached, failures included
+	lookupPeersDNS(neighbors)
+	if n := lookups.Load(); n != 21 {
+		t.Errorf("got %d lookups, want 21", n)
+	}
+	if n := neighbors[1]; n.PeerDNS != "peer-10.0.0.2.example.net" {
+		t.Errorf("got %q from the cache", n.PeerDNS)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// dumpNeighbors : Collects the neighbors once (from --input.file if given) and prints them as JSON
+func dumpNeighbors(w io.Writer) error {
+	var neighbors []BgpNeighbor
+	var r io.Reader
+	switch *inputFile {
+	case "":
+		collected, err := newCollection(context.Background(), localTargetConfig, nil).vtyshNeighbors()
+		if err != nil {
+			return err
+		}
+		neighbors = filterNeighbors(collected)
+	case "-":
+		r = os.Stdin
+	default:
+		f, err := os.Open(*inputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if r != nil {
+		neighbors = filterNeighbors(parseBGP(r, localTarget))
+	}
+	if neighbors == nil {
+		neighbors = []BgpNeighbor{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(neighbors)
+}
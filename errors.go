@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpCollectorErrors = newCounterVec(prometheus.CounterOpts{
+		Name: "bgp_collector_errors_total",
+		Help: "The number of collections which failed for a given collector",
+	},
+		[]string{
+			"collector",
+		})
+)
+
+// maxCollectionErrors : The number of errors kept per target
+const maxCollectionErrors = 50
+
+// localTarget : The name of the target for the local vtysh
+const localTarget = "local"
+
+// CollectionError : This represents an error encountered while collecting or parsing BGP data
+type CollectionError struct {
+	Time    time.Time `json:"time"`
+	Target  string    `json:"target"`
+	Message string    `json:"message"`
+}
+
+var collectionErrors = struct {
+	sync.Mutex
+	errors map[string][]CollectionError
+}{errors: make(map[string][]CollectionError)}
+
+// recordError : Keeps the error in the bounded per target error log
+func recordError(target string, message string) {
+	collectionErrors.Lock()
+	defer collectionErrors.Unlock()
+
+	errs := append(collectionErrors.errors[target], CollectionError{
+		Time:    time.Now(),
+		Target:  target,
+		Message: message,
+	})
+	if len(errs) > maxCollectionErrors {
+		errs = errs[len(errs)-maxCollectionErrors:]
+	}
+	collectionErrors.errors[target] = errs
+}
+
+// collectorFailed : Counts and logs a failed collection, keeping it in the error log of the target
+func collectorFailed(target string, collector string, err error) {
+	(&collection{target: target}).collectorFailed(collector, err)
+}
+
+// errorsHandler : Serves the most recent collection errors per target as JSON
+func errorsHandler(w http.ResponseWriter, r *http.Request) {
+	collectionErrors.Lock()
+	defer collectionErrors.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(collectionErrors.errors); err != nil {
+		logger.Error("Failed to encode collection errors", "err", err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StateChange : This represents a BGP neighbor changing state between two collections.
+// OldState is empty for a new neighbor and NewState is empty for a neighbor which went away.
+type StateChange struct {
+	Time      time.Time `json:"time"`
+	Target    string    `json:"target,omitempty"`
+	Neighbor  string    `json:"neighbor"`
+	Interface string    `json:"interface,omitempty"`
+	Vrf       string    `json:"vrf,omitempty"`
+	OldState  string    `json:"old_state"`
+	NewState  string    `json:"new_state"`
+}
+
+// stateChanges : Returns the neighbors which changed state, appeared or went away since the previous collection
+func stateChanges(name string, previous []BgpNeighbor, current []BgpNeighbor) []StateChange {
+	now := time.Now()
+	// The target is only named in multi-router mode
+	target := ""
+	if name != localTarget {
+		target = name
+	}
+	change := func(n BgpNeighbor, old string, new string) StateChange {
+		c := StateChange{Time: now, Target: target, Interface: n.Interface, Vrf: n.Vrf, OldState: old, NewState: new}
+		if n.IP != nil {
+			c.Neighbor = n.IP.String()
+		}
+		return c
+	}
+
+	var changes []StateChange
+	states := make(map[string]float64, len(previous))
+	for _, n := range previous {
+		states[storeKey(n)] = n.State
+	}
+	for _, n := range current {
+		old, ok := states[storeKey(n)]
+		delete(states, storeKey(n))
+		if !ok {
+			changes = append(changes, change(n, "", stateName(n.State)))
+		} else if old != n.State {
+			changes = append(changes, change(n, stateName(old), stateName(n.State)))
+		}
+	}
+	for _, n := range previous {
+		if _, ok := states[storeKey(n)]; ok {
+			changes = append(changes, change(n, stateName(n.State), ""))
+		}
+	}
+	return changes
+}
+
+// logStateChanges : Logs the neighbors which changed state, appeared or went away
+func logStateChanges(changes []StateChange) {
+	for _, c := range changes {
+		switch {
+		case c.OldState == "":
+			logger.Info("New neighbor", "target", c.Target, "neighbor", c.Neighbor, "interface", c.Interface, "vrf", c.Vrf, "state", c.NewState)
+		case c.NewState == "":
+			logger.Info("Neighbor went away", "target", c.Target, "neighbor", c.Neighbor, "interface", c.Interface, "vrf", c.Vrf)
+		default:
+			logger.Info("Neighbor state changed", "target", c.Target, "neighbor", c.Neighbor, "interface", c.Interface, "vrf", c.Vrf, "old_state", c.OldState, "new_state", c.NewState)
+		}
+	}
+}
+
+// eventBroker : This distributes the state changes to the connected event streams
+type eventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[chan StateChange]bool
+	closed      bool
+}
+
+var events = &eventBroker{subscribers: make(map[chan StateChange]bool)}
+
+// subscribe : Returns a channel receiving the state changes, closed when the broker is closed
+func (b *eventBroker) subscribe() chan StateChange {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ch := make(chan StateChange, 64)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers[ch] = true
+	return ch
+}
+
+func (b *eventBroker) unsubscribe(ch chan StateChange) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.subscribers[ch] {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish : Sends the state changes to all subscribers. Changes are dropped for
+// subscribers which are too slow to keep up, rather than delaying the collection.
+func (b *eventBroker) publish(changes []StateChange) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for ch := range b.subscribers {
+		for _, c := range changes {
+			select {
+			case ch <- c:
+			default:
+			}
+		}
+	}
+}
+
+// close : Ends all the event streams
+func (b *eventBroker) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// eventsHandler : Streams the state changes as Server-Sent Events
+func eventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := events.subscribe()
+	defer events.unsubscribe(ch)
+	keepalive := time.NewTicker(30 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case c, ok := <-ch:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(c)
+			if err != nil {
+				logger.Error("Failed to encode state change", "err", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: state_change\ndata: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpEvpnRibPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_evpn_rib_prefixes",
+		Help: "The number of EVPN prefixes in the RIB, by route type (ead, macip, imet, es, prefix)",
+	},
+		[]string{
+			"route_type",
+		})
+)
+
+var (
+	bgpEvpnRibPaths = newGauge(prometheus.GaugeOpts{
+		Name: "bgp_evpn_rib_paths",
+		Help: "The number of EVPN paths in the RIB",
+	})
+)
+
+var (
+	bgpNeighborEvpnPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_evpn_paths",
+		Help: "The number of EVPN paths received from a given BGP neighbor, by route type (ead, macip, imet, es, prefix)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"route_type",
+		})
+)
+
+var (
+	bgpEvpnVnis = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_evpn_vnis",
+		Help: "The number of EVPN VNIs, by type (l2, l3)",
+	},
+		[]string{
+			"type",
+		})
+)
+
+var (
+	bgpEvpnVniMacs = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_evpn_vni_macs",
+		Help: "The number of MACs of an EVPN VNI, local and remote (the router MACs for an L3 VNI)",
+	},
+		[]string{
+			"vni",
+			"type",
+			"vxlan_interface",
+			"view",
+		})
+)
+
+var (
+	bgpEvpnVniArpNd = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_evpn_vni_arp_nd",
+		Help: "The number of ARP/ND neighbors of an EVPN VNI, local and remote (the next hops for an L3 VNI)",
+	},
+		[]string{
+			"vni",
+			"type",
+			"vxlan_interface",
+			"view",
+		})
+)
+
+var (
+	bgpEvpnVniRemoteVteps = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_evpn_vni_remote_vteps",
+		Help: "The number of remote VTEPs of an L2 EVPN VNI",
+	},
+		[]string{
+			"vni",
+			"type",
+			"vxlan_interface",
+			"view",
+		})
+)
+
+// evpnRouteTypes : The names of the EVPN route types, from the "[N]:" prefix of the routes
+var evpnRouteTypes = map[string]string{
+	"1": "ead",
+	"2": "macip",
+	"3": "imet",
+	"4": "es",
+	"5": "prefix",
+}
+
+// EvpnPath : This represents a path of an EVPN route in "show bgp l2vpn evpn route json"
+type EvpnPath struct {
+	PeerID string `json:"peerId"`
+}
+
+func recordEvpnMetrics(c *collection) {
+	o, err := c.vtysh("show bgp l2vpn evpn route json")
+	if err != nil {
+		c.collectorFailed("evpn", err)
+		return
+	}
+	prefixes, paths, neighbors, err := parseEvpnRoutes([]byte(o))
+	if err != nil {
+		c.collectorFailed("evpn", err)
+		return
+	}
+
+	metric(c, bgpEvpnRibPrefixes).Reset()
+	metric(c, bgpNeighborEvpnPaths).Reset()
+	for _, t := range evpnRouteTypes {
+		metric(c, bgpEvpnRibPrefixes).With(prometheus.Labels{"route_type": t}).Set(prefixes[t])
+	}
+	metric(c, bgpEvpnRibPaths).Set(paths)
+	for peer, types := range neighbors {
+		n := BgpNeighbor{IP: net.ParseIP(peer)}
+		if config.Neighbors.filtered(&n) || c.neighborTruncated(peer) {
+			continue
+		}
+		for t, count := range types {
+			metric(c, bgpNeighborEvpnPaths).With(neighborLabels(peer, "route_type", t)).Set(count)
+		}
+	}
+}
+
+// parseEvpnRoutes : Counts the EVPN prefixes per route type, the paths, and the paths received per
+// neighbor and route type. The routes are grouped by route distinguisher:
+// {"<rd>": {"rd": "<rd>", "[2]:[0]:[48]:[...]": {"prefix": "...", "paths": [[{"peerId": "..."}]]}}}
+func parseEvpnRoutes(data []byte) (map[string]float64, float64, map[string]map[string]float64, error) {
+	var table map[string]json.RawMessage
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to parse the EVPN routes: %s", err)
+	}
+
+	prefixes := make(map[string]float64)
+	var paths float64
+	neighbors := make(map[string]map[string]float64)
+	for _, rd := range table {
+		var routes map[string]json.RawMessage
+		// The other members of the table are scalars such as the local AS
+		if json.Unmarshal(rd, &routes) != nil {
+			continue
+		}
+		for prefix, route := range routes {
+			t, ok := evpnRouteType(prefix)
+			if !ok {
+				continue
+			}
+			var r struct {
+				Paths []json.RawMessage `json:"paths"`
+			}
+			if json.Unmarshal(route, &r) != nil {
+				continue
+			}
+			prefixes[t]++
+			for _, p := range evpnPaths(r.Paths) {
+				paths++
+				// Local routes have no peer
+				if net.ParseIP(p.PeerID) == nil {
+					continue
+				}
+				if neighbors[p.PeerID] == nil {
+					neighbors[p.PeerID] = make(map[string]float64)
+				}
+				neighbors[p.PeerID][t]++
+			}
+		}
+	}
+	return prefixes, paths, neighbors, nil
+}
+
+// evpnRouteType : Returns the name of the route type of an EVPN prefix such as "[2]:[0]:[48]:[...]"
+func evpnRouteType(prefix string) (string, bool) {
+	if !strings.HasPrefix(prefix, "[") || len(prefix) < 3 || prefix[2] != ']' {
+		return "", false
+	}
+	t, ok := evpnRouteTypes[prefix[1:2]]
+	return t, ok
+}
+
+// evpnPaths : Returns the paths of a route, which some FRR versions wrap in a list of their own
+func evpnPaths(raw []json.RawMessage) []EvpnPath {
+	var paths []EvpnPath
+	for _, r := range raw {
+		var p EvpnPath
+		if json.Unmarshal(r, &p) == nil {
+			paths = append(paths, p)
+			continue
+		}
+		var ps []EvpnPath
+		if json.Unmarshal(r, &ps) == nil {
+			paths = append(paths, ps...)
+		}
+	}
+	return paths
+}
+
+// EvpnVni : This represents a VNI in "show evpn vni json". The counts of an L3 VNI are its router MACs and
+// next hops, and it has no remote VTEPs ("n/a").
+type EvpnVni struct {
+	Vni            json.Number `json:"vni"`
+	Type           string      `json:"type"`
+	VxlanInterface string      `json:"vxlanIf"`
+	Vrf            string      `json:"tenantVrf"`
+	Macs           json.Number `json:"numMacs"`
+	ArpNd          json.Number `json:"numArpNd"`
+	RemoteVteps    interface{} `json:"numRemoteVteps"`
+}
+
+func recordEvpnVniMetrics(c *collection) {
+	o, err := c.vtysh("show evpn vni json")
+	if err != nil {
+		c.collectorFailed("evpn_vni", err)
+		return
+	}
+	vnis, err := parseEvpnVnis([]byte(o))
+	if err != nil {
+		c.collectorFailed("evpn_vni", err)
+		return
+	}
+
+	metric(c, bgpEvpnVnis).Reset()
+	metric(c, bgpEvpnVniMacs).Reset()
+	metric(c, bgpEvpnVniArpNd).Reset()
+	metric(c, bgpEvpnVniRemoteVteps).Reset()
+	for _, t := range []string{"l2", "l3"} {
+		metric(c, bgpEvpnVnis).With(prometheus.Labels{"type": t}).Set(0)
+	}
+	for _, v := range vnis {
+		t := strings.ToLower(v.Type)
+		labels := prometheus.Labels{"vni": v.Vni.String(), "type": t, "vxlan_interface": v.VxlanInterface, "view": v.Vrf}
+		metric(c, bgpEvpnVnis).With(prometheus.Labels{"type": t}).Inc()
+		if macs, err := v.Macs.Float64(); err == nil {
+			metric(c, bgpEvpnVniMacs).With(labels).Set(macs)
+		}
+		if arpNd, err := v.ArpNd.Float64(); err == nil {
+			metric(c, bgpEvpnVniArpNd).With(labels).Set(arpNd)
+		}
+		if vteps, ok := v.RemoteVteps.(float64); ok {
+			metric(c, bgpEvpnVniRemoteVteps).With(labels).Set(vteps)
+		}
+	}
+}
+
+// parseEvpnVnis : Parses the VNIs of "show evpn vni json", keyed by VNI: {"100": {"vni": 100, ...}}.
+// Zebra prints nothing when EVPN is not enabled.
+func parseEvpnVnis(data []byte) ([]EvpnVni, error) {
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil
+	}
+	var table map[string]EvpnVni
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to parse the EVPN VNIs: %s", err)
+	}
+	vnis := make([]EvpnVni, 0, len(table))
+	for _, v := range table {
+		vnis = append(vnis, v)
+	}
+	return vnis, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordEvpnMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show bgp l2vpn evpn route json": "testdata/frr/show_bgp_l2vpn_evpn_route_json.txt",
+	}, bgpEvpnRibPrefixes, bgpEvpnRibPaths, bgpNeighborEvpnPaths)
+	recordEvpnMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	// The local routes are counted in the RIB but have no neighbor
+	want := `
+# HELP bgp_evpn_rib_paths The number of EVPN paths in the RIB
+# TYPE bgp_evpn_rib_paths gauge
+bgp_evpn_rib_paths 7
+# HELP bgp_evpn_rib_prefixes The number of EVPN prefixes in the RIB, by route type (ead, macip, imet, es, prefix)
+# TYPE bgp_evpn_rib_prefixes gauge
+bgp_evpn_rib_prefixes{route_type="ead"} 0
+bgp_evpn_rib_prefixes{route_type="es"} 0
+bgp_evpn_rib_prefixes{route_type="imet"} 2
+bgp_evpn_rib_prefixes{route_type="macip"} 2
+bgp_evpn_rib_prefixes{route_type="prefix"} 1
+# HELP bgp_neighbor_evpn_paths The number of EVPN paths received from a given BGP neighbor, by route type (ead, macip, imet, es, prefix)
+# TYPE bgp_neighbor_evpn_paths gauge
+bgp_neighbor_evpn_paths{interface="",ip="192.0.2.1",route_type="imet",view=""} 1
+bgp_neighbor_evpn_paths{interface="",ip="192.0.2.1",route_type="macip",view=""} 1
+bgp_neighbor_evpn_paths{interface="",ip="192.0.2.1",route_type="prefix",view=""} 1
+bgp_neighbor_evpn_paths{interface="",ip="192.0.2.5",route_type="imet",view=""} 1
+bgp_neighbor_evpn_paths{interface="",ip="192.0.2.5",route_type="macip",view=""} 1
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestParseEvpnRoutesUnwrappedPaths : Checks the paths of the FRR versions which do not wrap them in a list
+func TestParseEvpnRoutesUnwrappedPaths(t *testing.T) {
+	data := `{"localAS":65001,"10.0.0.2:2":{"rd":"10.0.0.2:2","[1]:[0]:[03:00:00:00:00:00:00:00:00:01]:[4294967295]":{"paths":[{"peerId":"192.0.2.1"},{"peerId":"192.0.2.5"}]},"[4]:[03:00:00:00:00:00:00:00:00:01]:[32]:[10.0.0.2]":{"paths":[{"peerId":"192.0.2.1"}]}}}`
+	prefixes, paths, neighbors, err := parseEvpnRoutes([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]float64{"ead": 1, "es": 1}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("got the prefixes %v, want %v", prefixes, want)
+	}
+	if paths != 3 {
+		t.Errorf("got %v paths, want 3", paths)
+	}
+	want := map[string]map[string]float64{"192.0.2.1": {"ead": 1, "es": 1}, "192.0.2.5": {"ead": 1}}
+	if !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("got the neighbors %v, want %v", neighbors, want)
+	}
+}
+
+// TestRecordEvpnMetricsInvalid : Checks that the collector fails on an output which is not JSON
+func TestRecordEvpnMetricsInvalid(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show bgp l2vpn evpn route json": "% BGP instance not found\n",
+	}, bgpEvpnRibPrefixes)
+	recordEvpnMetrics(c)
+	if c.failures != 1 {
+		t.Errorf("got %d failures, want 1", c.failures)
+	}
+}
+
+func TestRecordEvpnVniMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show evpn vni json": "testdata/frr/show_evpn_vni_json.txt",
+	}, bgpEvpnVnis, bgpEvpnVniMacs, bgpEvpnVniArpNd, bgpEvpnVniRemoteVteps)
+	recordEvpnVniMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	// An L3 VNI has no remote VTEPs
+	want := `
+# HELP bgp_evpn_vni_arp_nd The number of ARP/ND neighbors of an EVPN VNI, local and remote (the next hops for an L3 VNI)
+# TYPE bgp_evpn_vni_arp_nd gauge
+bgp_evpn_vni_arp_nd{type="l2",view="default",vni="200",vxlan_interface="vxlan200"} 0
+bgp_evpn_vni_arp_nd{type="l2",view="vrf-red",vni="100",vxlan_interface="vxlan100"} 3
+bgp_evpn_vni_arp_nd{type="l3",view="vrf-red",vni="5000",vxlan_interface="vxlan5000"} 2
+# HELP bgp_evpn_vni_macs The number of MACs of an EVPN VNI, local and remote (the router MACs for an L3 VNI)
+# TYPE bgp_evpn_vni_macs gauge
+bgp_evpn_vni_macs{type="l2",view="default",vni="200",vxlan_interface="vxlan200"} 0
+bgp_evpn_vni_macs{type="l2",view="vrf-red",vni="100",vxlan_interface="vxlan100"} 5
+bgp_evpn_vni_macs{type="l3",view="vrf-red",vni="5000",vxlan_interface="vxlan5000"} 2
+# HELP bgp_evpn_vni_remote_vteps The number of remote VTEPs of an L2 EVPN VNI
+# TYPE bgp_evpn_vni_remote_vteps gauge
+bgp_evpn_vni_remote_vteps{type="l2",view="default",vni="200",vxlan_interface="vxlan200"} 0
+bgp_evpn_vni_remote_vteps{type="l2",view="vrf-red",vni="100",vxlan_interface="vxlan100"} 2
+# HELP bgp_evpn_vnis The number of EVPN VNIs, by type (l2, l3)
+# TYPE bgp_evpn_vnis gauge
+bgp_evpn_vnis{type="l2"} 2
+bgp_evpn_vnis{type="l3"} 1
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRecordEvpnVniMetricsDisabled : Checks that a zebra without EVPN has no VNIs, rather than failing
+func TestRecordEvpnVniMetricsDisabled(t *testing.T) {
+	c := testCollection(t, map[string]string{"show evpn vni json": ""}, bgpEvpnVnis, bgpEvpnVniMacs)
+	recordEvpnVniMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	want := `
+# HELP bgp_evpn_vnis The number of EVPN VNIs, by type (l2, l3)
+# TYPE bgp_evpn_vnis gauge
+bgp_evpn_vnis{type="l2"} 0
+bgp_evpn_vnis{type="l3"} 0
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	exabgpPipe = flag.String("exabgp.pipe", "", "Read the JSON API messages of ExaBGP from this named pipe, e.g. written by the process `run /bin/sh -c \"cat > /run/bgp_exporter/exabgp\"; encoder json;`")
+	exabgpHTTP = flag.Bool("exabgp.http", false, "Accept the JSON API messages of ExaBGP POSTed to /api/v1/exabgp, one message per line")
+)
+
+var (
+	bgpNeighborUpdateMessages = newCounterVec(prometheus.CounterOpts{
+		Name: "bgp_neighbor_update_messages_total",
+		Help: "The number of UPDATE messages received from or sent to a given ExaBGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"direction",
+		})
+)
+
+var (
+	bgpNeighborUpdatePrefixes = newCounterVec(prometheus.CounterOpts{
+		Name: "bgp_neighbor_update_prefixes_total",
+		Help: "The number of prefixes announced or withdrawn in the UPDATE messages received from or sent to a given ExaBGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"direction",
+			"action",
+		})
+)
+
+// exabgpEnabled : Whether the neighbors are built from the messages of ExaBGP
+func exabgpEnabled() bool {
+	return *exabgpPipe != "" || *exabgpHTTP
+}
+
+// ExabgpMessage : This represents a message of the ExaBGP JSON API (encoder json)
+type ExabgpMessage struct {
+	Type     string `json:"type"`
+	Neighbor struct {
+		Address struct {
+			Local string `json:"local"`
+			Peer  string `json:"peer"`
+		} `json:"address"`
+		ASN struct {
+			Local json.Number `json:"local"`
+			Peer  json.Number `json:"peer"`
+		} `json:"asn"`
+		State     string `json:"state"`
+		Reason    string `json:"reason"`
+		Direction string `json:"direction"`
+		Message   struct {
+			Update struct {
+				// Announced NLRIs are grouped by address family and next hop, withdrawn ones by address family
+				Announce map[string]map[string][]json.RawMessage `json:"announce"`
+				Withdraw map[string][]json.RawMessage            `json:"withdraw"`
+			} `json:"update"`
+		} `json:"message"`
+	} `json:"neighbor"`
+}
+
+// exabgpNeighbor : This represents a neighbor built from the messages of ExaBGP
+type exabgpNeighbor struct {
+	BgpNeighbor
+	// received : The prefixes currently announced by the neighbor, counted as its accepted prefixes
+	received map[string]bool
+}
+
+var exabgpState = struct {
+	sync.Mutex
+	neighbors map[string]*exabgpNeighbor
+}{neighbors: make(map[string]*exabgpNeighbor)}
+
+// exabgpNeighbors : Returns the neighbors known from the messages of ExaBGP. ExaBGP only
+// reports changes, so there are none until a session changes state.
+func exabgpNeighbors() []BgpNeighbor {
+	exabgpState.Lock()
+	defer exabgpState.Unlock()
+
+	neighbors := make([]BgpNeighbor, 0, len(exabgpState.neighbors))
+	for _, n := range exabgpState.neighbors {
+		c := n.BgpNeighbor
+		c.AcceptedPrefixes = float64(len(n.received))
+		neighbors = append(neighbors, c)
+	}
+	return neighbors
+}
+
+// applyExabgpMessage : Updates the neighbor of the message
+func applyExabgpMessage(m *ExabgpMessage) {
+	peer := m.Neighbor.Address.Peer
+	if peer == "" {
+		return
+	}
+
+	exabgpState.Lock()
+	defer exabgpState.Unlock()
+
+	n, ok := exabgpState.neighbors[peer]
+	if !ok {
+		n = &exabgpNeighbor{
+			BgpNeighbor: BgpNeighbor{IP: net.ParseIP(peer), AddressFamilies: make(map[string]*BgpAddressFamily)},
+			received:    make(map[string]bool),
+		}
+		exabgpState.neighbors[peer] = n
+	}
+	if asn := m.Neighbor.ASN.Peer.String(); asn != "" {
+		n.RemoteAS = asn
+		if asn == m.Neighbor.ASN.Local.String() {
+			n.Type = "ibgp"
+		} else {
+			n.Type = "ebgp"
+		}
+	}
+
+	switch m.Type {
+	case "state":
+		switch m.Neighbor.State {
+		case "connected":
+			// The TCP session is up and the OPEN messages are being exchanged
+			n.State = 4
+		case "up":
+			n.State = 6
+			n.ConnectionsEstablished++
+		case "down":
+			if n.State == 6 {
+				n.ConnectionsDropped++
+			}
+			n.State = 1
+			n.received = make(map[string]bool)
+		}
+	case "update":
+		direction := m.Neighbor.Direction
+		if direction == "" {
+			direction = "receive"
+		}
+		labels := neighborLabels(peer, "direction", direction)
+		bgpNeighborUpdateMessages.With(labels).Inc()
+		update := &m.Neighbor.Message.Update
+		for _, nexthops := range update.Announce {
+			for _, nlris := range nexthops {
+				bgpNeighborUpdatePrefixes.With(neighborLabels(peer, "direction", direction, "action", "announce")).Add(float64(len(nlris)))
+				if direction == "receive" {
+					for _, nlri := range nlris {
+						n.received[exabgpPrefix(nlri)] = true
+					}
+				}
+			}
+		}
+		for _, nlris := range update.Withdraw {
+			bgpNeighborUpdatePrefixes.With(neighborLabels(peer, "direction", direction, "action", "withdraw")).Add(float64(len(nlris)))
+			if direction == "receive" {
+				for _, nlri := range nlris {
+					delete(n.received, exabgpPrefix(nlri))
+				}
+			}
+		}
+	}
+}
+
+// exabgpPrefix : Returns the prefix of an NLRI, printed either as a string or as {"nlri": "..."}
+func exabgpPrefix(nlri json.RawMessage) string {
+	var s string
+	if json.Unmarshal(nlri, &s) == nil {
+		return s
+	}
+	// The path identifier of ADD-PATH is printed as an address (e.g. "0.0.0.1") or a number
+	var o struct {
+		NLRI   string          `json:"nlri"`
+		PathID json.RawMessage `json:"path-information"`
+	}
+	if json.Unmarshal(nlri, &o) == nil && o.NLRI != "" {
+		if o.PathID != nil {
+			return o.NLRI + "#" + strings.Trim(string(o.PathID), `"`)
+		}
+		return o.NLRI
+	}
+	return string(nlri)
+}
+
+// readExabgpMessages : Applies the messages read from ExaBGP, one JSON object per line
+func readExabgpMessages(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// ExaBGP also prints plain text messages, e.g. "done" acknowledgments
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var m ExabgpMessage
+		if err := json.Unmarshal(line, &m); err != nil {
+			recordError(localTarget, fmt.Sprintf("Failed to parse the ExaBGP message: %s", err))
+			continue
+		}
+		applyExabgpMessage(&m)
+	}
+	return scanner.Err()
+}
+
+// readExabgpPipe : Reads the messages from the named pipe, opening it again whenever ExaBGP restarts
+func readExabgpPipe(path string) {
+	for {
+		// Opening a named pipe blocks until ExaBGP opens it for writing
+		f, err := os.Open(path)
+		if err == nil {
+			err = readExabgpMessages(f)
+			f.Close()
+		}
+		if err != nil {
+			collectorFailed(localTarget, "exabgp", fmt.Errorf("failed to read the ExaBGP messages from %s: %s", path, err))
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
+
+// exabgpHandler : Accepts the messages of ExaBGP POSTed by a relay process
+func exabgpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := readExabgpMessages(r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
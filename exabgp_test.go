@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestExabgpMessages : Checks the neighbors and the update counters built from the messages POSTed by ExaBGP
+func TestExabgpMessages(t *testing.T) {
+	r := prometheus.NewRegistry()
+	r.MustRegister(bgpNeighborUpdateMessages, bgpNeighborUpdatePrefixes)
+	t.Cleanup(func() {
+		bgpNeighborUpdateMessages.Reset()
+		bgpNeighborUpdatePrefixes.Reset()
+		exabgpState.Lock()
+		exabgpState.neighbors = make(map[string]*exabgpNeighbor)
+		exabgpState.Unlock()
+	})
+
+	w := httptest.NewRecorder()
+	exabgpHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1/exabgp", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got the status %d for a GET", w.Code)
+	}
+	w = httptest.NewRecorder()
+	exabgpHandler(w, httptest.NewRequest(http.MethodPost, "/api/v1/exabgp", strings.NewReader(readFile(t, "testdata/exabgp/messages.json"))))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got the status %d: %s", w.Code, w.Body)
+	}
+
+	neighbors := exabgpNeighbors()
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].key() < neighbors[j].key() })
+	type neighbor struct {
+		ip, as, kind         string
+		state, accepted      float64
+		established, dropped float64
+	}
+	var got []neighbor
+	for _, n := range neighbors {
+		got = append(got, neighbor{n.IP.String(), n.RemoteAS, n.Type, n.State, n.AcceptedPrefixes, n.ConnectionsEstablished, n.ConnectionsDropped})
+	}
+	want := []neighbor{
+		// The ADD-PATH paths of a prefix are counted apart
+		{"10.0.0.1", "65001", "ebgp", 6, 2, 1, 0},
+		// The prefixes of a session which went down are forgotten
+		{"10.0.0.3", "65000", "ibgp", 1, 0, 1, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+
+	counters := `
+# HELP bgp_neighbor_update_messages_total The number of UPDATE messages received from or sent to a given ExaBGP neighbor
+# TYPE bgp_neighbor_update_messages_total counter
+bgp_neighbor_update_messages_total{direction="receive",interface="",ip="10.0.0.1",view=""} 3
+bgp_neighbor_update_messages_total{direction="receive",interface="",ip="10.0.0.3",view=""} 1
+bgp_neighbor_update_messages_total{direction="send",interface="",ip="10.0.0.1",view=""} 1
+# HELP bgp_neighbor_update_prefixes_total The number of prefixes announced or withdrawn in the UPDATE messages received from or sent to a given ExaBGP neighbor
+# TYPE bgp_neighbor_update_prefixes_total counter
+bgp_neighbor_update_prefixes_total{action="announce",direction="receive",interface="",ip="10.0.0.1",view=""} 4
+bgp_neighbor_update_prefixes_total{action="announce",direction="receive",interface="",ip="10.0.0.3",view=""} 1
+bgp_neighbor_update_prefixes_total{action="announce",direction="send",interface="",ip="10.0.0.1",view=""} 1
+bgp_neighbor_update_prefixes_total{action="withdraw",direction="receive",interface="",ip="10.0.0.1",view=""} 2
+`
+	if err := testutil.GatherAndCompare(r, strings.NewReader(counters)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExabgpPrefix(t *testing.T) {
+	tests := map[string]string{
+		`"192.0.2.0/24"`:             "192.0.2.0/24",
+		`{ "nlri": "192.0.2.0/24" }`: "192.0.2.0/24",
+		`{ "nlri": "192.0.2.0/24", "path-information": "0.0.0.1" }`: "192.0.2.0/24#0.0.0.1",
+		`{ "nlri": "192.0.2.0/24", "path-information": 2 }`:         "192.0.2.0/24#2",
+	}
+	for nlri, want := range tests {
+		if got := exabgpPrefix(json.RawMessage(nlri)); got != want {
+			t.Errorf("exabgpPrefix(%s) = %q, want %q", nlri, got, want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborExpectedMissing = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_expected_missing",
+		Help: "Whether a BGP neighbor expected by the configuration is missing from the router (1) or present in any state (0)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"remote_as",
+		})
+)
+
+// ExpectedNeighbor : This represents a neighbor which should be configured on the router, by address (or
+// interface for unnumbered neighbors) and/or remote ASN, in the default view unless another is given
+type ExpectedNeighbor struct {
+	Address string `yaml:"address"`
+	ASN     uint32 `yaml:"asn"`
+	View    string `yaml:"view"`
+}
+
+func validateExpectedNeighbors(expected []ExpectedNeighbor) error {
+	for i, e := range expected {
+		if e.Address == "" && e.ASN == 0 {
+			return fmt.Errorf("expected neighbor %d: no address or asn given", i+1)
+		}
+	}
+	return nil
+}
+
+// labels : Returns the labels of the expected neighbor, as those of the neighbor it matches
+func (e *ExpectedNeighbor) labels() prometheus.Labels {
+	asn := ""
+	if e.ASN != 0 {
+		asn = strconv.FormatUint(uint64(e.ASN), 10)
+	}
+	labels := neighborLabels(e.Address, "remote_as", asn)
+	labels["view"] = e.View
+	return labels
+}
+
+// matches : Whether the neighbor has the address, ASN and view of the expected neighbor
+func (e *ExpectedNeighbor) matches(n *BgpNeighbor) bool {
+	if n.Vrf != e.View {
+		return false
+	}
+	if e.Address != "" {
+		if ip := net.ParseIP(e.Address); ip != nil {
+			if !ip.Equal(n.IP) {
+				return false
+			}
+		} else if e.Address != n.Interface {
+			return false
+		}
+	}
+	return e.ASN == 0 || strconv.FormatUint(uint64(e.ASN), 10) == n.RemoteAS
+}
+
+// recordExpectedNeighbors : Exports whether each expected neighbor is missing from the neighbors collected
+// from the router, before they are filtered, for a deleted neighbor to be alerted on rather than its series
+// just going away
+func (c *collection) recordExpectedNeighbors(neighbors []BgpNeighbor) {
+	for i := range config.Neighbors.Expected {
+		e := &config.Neighbors.Expected[i]
+		missing := true
+		for j := range neighbors {
+			if e.matches(&neighbors[j]) {
+				missing = false
+				break
+			}
+		}
+		metric(c, bgpNeighborExpectedMissing).With(e.labels()).Set(boolToFloat(missing))
+	}
+}
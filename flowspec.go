@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpFlowspecRules = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_flowspec_rules",
+		Help: "The number of flowspec rules for an address family, by whether they are installed in the policy based routing",
+	},
+		[]string{
+			"afi",
+			"state",
+		})
+)
+
+// BgpFlowspecRules : This represents the flowspec rules of an address family
+type BgpFlowspecRules struct {
+	Installed    float64
+	NotInstalled float64
+}
+
+// recordFlowspecMetrics : Counts the installed flowspec rules. The rules received per neighbor are
+// part of the summary, as bgpd does not show which neighbor an installed rule was received from.
+func recordFlowspecMetrics(c *collection) {
+	for _, afi := range []string{"ipv4", "ipv6"} {
+		o, err := c.vtysh("show bgp " + afi + " flowspec detail")
+		if err != nil {
+			c.collectorFailed("flowspec", err)
+			continue
+		}
+		rules := parseFlowspecRules(o)
+		metric(c, bgpFlowspecRules).With(prometheus.Labels{"afi": afi + "_flowspec", "state": "installed"}).Set(rules.Installed)
+		metric(c, bgpFlowspecRules).With(prometheus.Labels{"afi": afi + "_flowspec", "state": "not_installed"}).Set(rules.NotInstalled)
+	}
+}
+
+// parseFlowspecRules : Counts the entries of "show bgp ipv4 flowspec detail" by their PBR state
+func parseFlowspecRules(s string) BgpFlowspecRules {
+	var rules BgpFlowspecRules
+	for _, line := range strings.Split(s, "\n") {
+		switch t := strings.TrimSpace(line); {
+		case strings.HasPrefix(t, "not installed in PBR"):
+			rules.NotInstalled++
+		case strings.HasPrefix(t, "installed in PBR"):
+			rules.Installed++
+		}
+	}
+	return rules
+}
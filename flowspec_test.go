@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordFlowspecMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show ip bgp summary":            "% No BGP neighbors found in VRF default\n",
+		"show bgp ipv4 flowspec summary": "testdata/frr/show_bgp_ipv4_flowspec_summary.txt",
+		"show bgp ipv6 flowspec summary": "% No BGP neighbors found in VRF default\n",
+		"show bgp ipv4 flowspec detail":  "testdata/frr/show_bgp_ipv4_flowspec_detail.txt",
+		"show bgp ipv6 flowspec detail":  "",
+	}, bgpFlowspecRules, bgpNeighborPrefixesReceived)
+	setFlags(t, map[string]string{"collector.flowspec": "true"})
+	recordFlowspecMetrics(c)
+	recordSummaryMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	// The rules received per neighbor are the prefixes of the flowspec summary
+	want := `
+# HELP bgp_flowspec_rules The number of flowspec rules for an address family, by whether they are installed in the policy based routing
+# TYPE bgp_flowspec_rules gauge
+bgp_flowspec_rules{afi="ipv4_flowspec",state="installed"} 2
+bgp_flowspec_rules{afi="ipv4_flowspec",state="not_installed"} 1
+bgp_flowspec_rules{afi="ipv6_flowspec",state="installed"} 0
+bgp_flowspec_rules{afi="ipv6_flowspec",state="not_installed"} 0
+# HELP bgp_neighbor_prefixes_received The number of prefixes received from a given BGP neighbor for an address family (PfxRcd)
+# TYPE bgp_neighbor_prefixes_received gauge
+bgp_neighbor_prefixes_received{afi="ipv4_flowspec",interface="",ip="192.0.2.1",view=""} 3
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want), "bgp_flowspec_rules", "bgp_neighbor_prefixes_received"); err != nil {
+		t.Error(err)
+	}
+}
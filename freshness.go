@@ -0,0 +1,63 @@
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// freshnessCollector : Exports when the neighbors were last collected and how old they are at scrape time, for
+// dashboards to tell stale values (e.g. from a hanging vtysh) from the current state. The routers which were
+// never collected have no series.
+type freshnessCollector struct {
+	lastCollect *prometheus.Desc
+	dataAge     *prometheus.Desc
+}
+
+// newFreshnessCollector : Returns the collector of the freshness of the neighbors, labeled with the router
+// in multi-router mode
+func newFreshnessCollector() *freshnessCollector {
+	var labels []string
+	if multiRouter() {
+		labels = []string{"router"}
+	}
+	return &freshnessCollector{
+		lastCollect: prometheus.NewDesc(
+			"bgp_last_collect_timestamp_seconds",
+			"The time of the last successful collection of the neighbors, as a Unix timestamp",
+			labels, nil),
+		dataAge: prometheus.NewDesc(
+			"bgp_data_age_seconds",
+			"The number of seconds since the last successful collection of the neighbors, i.e. how old the served values are",
+			labels, nil),
+	}
+}
+
+// freshnessMetric : Whether the metric family is computed at scrape time for all the routers, rather than
+// kept from the collection of a target
+func freshnessMetric(name string) bool {
+	return name == "bgp_last_collect_timestamp_seconds" || name == "bgp_data_age_seconds"
+}
+
+func (c *freshnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lastCollect
+	ch <- c.dataAge
+}
+
+func (c *freshnessCollector) Collect(ch chan<- prometheus.Metric) {
+	if !multiRouter() {
+		c.collect(ch, localTargetConfig.state.neighbors.Collected())
+		return
+	}
+	for _, t := range activeTargets() {
+		c.collect(ch, t.state.neighbors.Collected(), t.Name)
+	}
+}
+
+func (c *freshnessCollector) collect(ch chan<- prometheus.Metric, collected time.Time, labels ...string) {
+	if collected.IsZero() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.lastCollect, prometheus.GaugeValue, float64(collected.UnixNano())/1e9, labels...)
+	ch <- prometheus.MustNewConstMetric(c.dataAge, prometheus.GaugeValue, time.Since(collected).Seconds(), labels...)
+}
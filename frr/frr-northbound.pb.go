@@ -0,0 +1,439 @@
+// The part of grpc/frr-northbound.proto of FRR used by the northbound backend, to read the operational
+// state of bgpd started with "-M grpc". The messages keep their field numbers in FRR.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: frr/frr-northbound.proto
+
+package frr
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Encoding int32
+
+const (
+	Encoding_JSON Encoding = 0
+	Encoding_XML  Encoding = 1
+)
+
+// Enum value maps for Encoding.
+var (
+	Encoding_name = map[int32]string{
+		0: "JSON",
+		1: "XML",
+	}
+	Encoding_value = map[string]int32{
+		"JSON": 0,
+		"XML":  1,
+	}
+)
+
+func (x Encoding) Enum() *Encoding {
+	p := new(Encoding)
+	*p = x
+	return p
+}
+
+func (x Encoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Encoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_frr_frr_northbound_proto_enumTypes[0].Descriptor()
+}
+
+func (Encoding) Type() protoreflect.EnumType {
+	return &file_frr_frr_northbound_proto_enumTypes[0]
+}
+
+func (x Encoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Encoding.Descriptor instead.
+func (Encoding) EnumDescriptor() ([]byte, []int) {
+	return file_frr_frr_northbound_proto_rawDescGZIP(), []int{0}
+}
+
+type GetRequest_DataType int32
+
+const (
+	GetRequest_ALL    GetRequest_DataType = 0
+	GetRequest_CONFIG GetRequest_DataType = 1
+	GetRequest_STATE  GetRequest_DataType = 2
+)
+
+// Enum value maps for GetRequest_DataType.
+var (
+	GetRequest_DataType_name = map[int32]string{
+		0: "ALL",
+		1: "CONFIG",
+		2: "STATE",
+	}
+	GetRequest_DataType_value = map[string]int32{
+		"ALL":    0,
+		"CONFIG": 1,
+		"STATE":  2,
+	}
+)
+
+func (x GetRequest_DataType) Enum() *GetRequest_DataType {
+	p := new(GetRequest_DataType)
+	*p = x
+	return p
+}
+
+func (x GetRequest_DataType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GetRequest_DataType) Descriptor() protoreflect.EnumDescriptor {
+	return file_frr_frr_northbound_proto_enumTypes[1].Descriptor()
+}
+
+func (GetRequest_DataType) Type() protoreflect.EnumType {
+	return &file_frr_frr_northbound_proto_enumTypes[1]
+}
+
+func (x GetRequest_DataType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GetRequest_DataType.Descriptor instead.
+func (GetRequest_DataType) EnumDescriptor() ([]byte, []int) {
+	return file_frr_frr_northbound_proto_rawDescGZIP(), []int{1, 0}
+}
+
+type DataTree struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Encoding Encoding `protobuf:"varint,1,opt,name=encoding,proto3,enum=frr.Encoding" json:"encoding,omitempty"`
+	Data     string   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *DataTree) Reset() {
+	*x = DataTree{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_frr_frr_northbound_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DataTree) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DataTree) ProtoMessage() {}
+
+func (x *DataTree) ProtoReflect() protoreflect.Message {
+	mi := &file_frr_frr_northbound_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DataTree.ProtoReflect.Descriptor instead.
+func (*DataTree) Descriptor() ([]byte, []int) {
+	return file_frr_frr_northbound_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DataTree) GetEncoding() Encoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return Encoding_JSON
+}
+
+func (x *DataTree) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type         GetRequest_DataType `protobuf:"varint,1,opt,name=type,proto3,enum=frr.GetRequest_DataType" json:"type,omitempty"`
+	Encoding     Encoding            `protobuf:"varint,2,opt,name=encoding,proto3,enum=frr.Encoding" json:"encoding,omitempty"`
+	WithDefaults bool                `protobuf:"varint,3,opt,name=with_defaults,json=withDefaults,proto3" json:"with_defaults,omitempty"`
+	Path         []string            `protobuf:"bytes,4,rep,name=path,proto3" json:"path,omitempty"`
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_frr_frr_northbound_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_frr_frr_northbound_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_frr_frr_northbound_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetRequest) GetType() GetRequest_DataType {
+	if x != nil {
+		return x.Type
+	}
+	return GetRequest_ALL
+}
+
+func (x *GetRequest) GetEncoding() Encoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return Encoding_JSON
+}
+
+func (x *GetRequest) GetWithDefaults() bool {
+	if x != nil {
+		return x.WithDefaults
+	}
+	return false
+}
+
+func (x *GetRequest) GetPath() []string {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+type GetResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The time of the data, in nanoseconds since the epoch
+	Timestamp int64     `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Data      *DataTree `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_frr_frr_northbound_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponse) ProtoMessage() {}
+
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_frr_frr_northbound_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return file_frr_frr_northbound_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GetResponse) GetData() *DataTree {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_frr_frr_northbound_proto protoreflect.FileDescriptor
+
+var file_frr_frr_northbound_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x66, 0x72, 0x72, 0x2f, 0x66, 0x72, 0x72, 0x2d, 0x6e, 0x6f, 0x72, 0x74, 0x68, 0x62,
+	0x6f, 0x75, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03, 0x66, 0x72, 0x72, 0x22,
+	0x49, 0x0a, 0x08, 0x44, 0x61, 0x74, 0x61, 0x54, 0x72, 0x65, 0x65, 0x12, 0x29, 0x0a, 0x08, 0x65,
+	0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e,
+	0x66, 0x72, 0x72, 0x2e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x08, 0x65, 0x6e,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0xca, 0x01, 0x0a, 0x0a, 0x47,
+	0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x66, 0x72, 0x72, 0x2e, 0x47, 0x65,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x54, 0x79, 0x70,
+	0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64,
+	0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x66, 0x72, 0x72, 0x2e,
+	0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x52, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69,
+	0x6e, 0x67, 0x12, 0x23, 0x0a, 0x0d, 0x77, 0x69, 0x74, 0x68, 0x5f, 0x64, 0x65, 0x66, 0x61, 0x75,
+	0x6c, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x77, 0x69, 0x74, 0x68, 0x44,
+	0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x2a, 0x0a, 0x08, 0x44,
+	0x61, 0x74, 0x61, 0x54, 0x79, 0x70, 0x65, 0x12, 0x07, 0x0a, 0x03, 0x41, 0x4c, 0x4c, 0x10, 0x00,
+	0x12, 0x0a, 0x0a, 0x06, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x47, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05,
+	0x53, 0x54, 0x41, 0x54, 0x45, 0x10, 0x02, 0x22, 0x4e, 0x0a, 0x0b, 0x47, 0x65, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x12, 0x21, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x66, 0x72, 0x72, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x54, 0x72, 0x65,
+	0x65, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x2a, 0x1d, 0x0a, 0x08, 0x45, 0x6e, 0x63, 0x6f, 0x64,
+	0x69, 0x6e, 0x67, 0x12, 0x08, 0x0a, 0x04, 0x4a, 0x53, 0x4f, 0x4e, 0x10, 0x00, 0x12, 0x07, 0x0a,
+	0x03, 0x58, 0x4d, 0x4c, 0x10, 0x01, 0x32, 0x3a, 0x0a, 0x0a, 0x4e, 0x6f, 0x72, 0x74, 0x68, 0x62,
+	0x6f, 0x75, 0x6e, 0x64, 0x12, 0x2c, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0f, 0x2e, 0x66, 0x72,
+	0x72, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x66,
+	0x72, 0x72, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x30, 0x01, 0x42, 0x28, 0x5a, 0x26, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x66, 0x69, 0x76, 0x65, 0x61, 0x69, 0x2f, 0x62, 0x67, 0x70, 0x2d, 0x65, 0x78, 0x70, 0x6f,
+	0x72, 0x74, 0x65, 0x72, 0x2f, 0x66, 0x72, 0x72, 0x3b, 0x66, 0x72, 0x72, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_frr_frr_northbound_proto_rawDescOnce sync.Once
+	file_frr_frr_northbound_proto_rawDescData = file_frr_frr_northbound_proto_rawDesc
+)
+
+func file_frr_frr_northbound_proto_rawDescGZIP() []byte {
+	file_frr_frr_northbound_proto_rawDescOnce.Do(func() {
+		file_frr_frr_northbound_proto_rawDescData = protoimpl.X.CompressGZIP(file_frr_frr_northbound_proto_rawDescData)
+	})
+	return file_frr_frr_northbound_proto_rawDescData
+}
+
+var file_frr_frr_northbound_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_frr_frr_northbound_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_frr_frr_northbound_proto_goTypes = []any{
+	(Encoding)(0),            // 0: frr.Encoding
+	(GetRequest_DataType)(0), // 1: frr.GetRequest.DataType
+	(*DataTree)(nil),         // 2: frr.DataTree
+	(*GetRequest)(nil),       // 3: frr.GetRequest
+	(*GetResponse)(nil),      // 4: frr.GetResponse
+}
+var file_frr_frr_northbound_proto_depIdxs = []int32{
+	0, // 0: frr.DataTree.encoding:type_name -> frr.Encoding
+	1, // 1: frr.GetRequest.type:type_name -> frr.GetRequest.DataType
+	0, // 2: frr.GetRequest.encoding:type_name -> frr.Encoding
+	2, // 3: frr.GetResponse.data:type_name -> frr.DataTree
+	3, // 4: frr.Northbound.Get:input_type -> frr.GetRequest
+	4, // 5: frr.Northbound.Get:output_type -> frr.GetResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_frr_frr_northbound_proto_init() }
+func file_frr_frr_northbound_proto_init() {
+	if File_frr_frr_northbound_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_frr_frr_northbound_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*DataTree); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_frr_frr_northbound_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_frr_frr_northbound_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*GetResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_frr_frr_northbound_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_frr_frr_northbound_proto_goTypes,
+		DependencyIndexes: file_frr_frr_northbound_proto_depIdxs,
+		EnumInfos:         file_frr_frr_northbound_proto_enumTypes,
+		MessageInfos:      file_frr_frr_northbound_proto_msgTypes,
+	}.Build()
+	File_frr_frr_northbound_proto = out.File
+	file_frr_frr_northbound_proto_rawDesc = nil
+	file_frr_frr_northbound_proto_goTypes = nil
+	file_frr_frr_northbound_proto_depIdxs = nil
+}
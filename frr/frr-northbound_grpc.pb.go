@@ -0,0 +1,142 @@
+// The part of grpc/frr-northbound.proto of FRR used by the northbound backend, to read the operational
+// state of bgpd started with "-M grpc". The messages keep their field numbers in FRR.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: frr/frr-northbound.proto
+
+package frr
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Northbound_Get_FullMethodName = "/frr.Northbound/Get"
+)
+
+// NorthboundClient is the client API for Northbound service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type NorthboundClient interface {
+	// Get returns the data trees of the paths, in one or more messages
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Northbound_GetClient, error)
+}
+
+type northboundClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewNorthboundClient(cc grpc.ClientConnInterface) NorthboundClient {
+	return &northboundClient{cc}
+}
+
+func (c *northboundClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Northbound_GetClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Northbound_ServiceDesc.Streams[0], Northbound_Get_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &northboundGetClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Northbound_GetClient interface {
+	Recv() (*GetResponse, error)
+	grpc.ClientStream
+}
+
+type northboundGetClient struct {
+	grpc.ClientStream
+}
+
+func (x *northboundGetClient) Recv() (*GetResponse, error) {
+	m := new(GetResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NorthboundServer is the server API for Northbound service.
+// All implementations must embed UnimplementedNorthboundServer
+// for forward compatibility
+type NorthboundServer interface {
+	// Get returns the data trees of the paths, in one or more messages
+	Get(*GetRequest, Northbound_GetServer) error
+	mustEmbedUnimplementedNorthboundServer()
+}
+
+// UnimplementedNorthboundServer must be embedded to have forward compatible implementations.
+type UnimplementedNorthboundServer struct {
+}
+
+func (UnimplementedNorthboundServer) Get(*GetRequest, Northbound_GetServer) error {
+	return status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedNorthboundServer) mustEmbedUnimplementedNorthboundServer() {}
+
+// UnsafeNorthboundServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to NorthboundServer will
+// result in compilation errors.
+type UnsafeNorthboundServer interface {
+	mustEmbedUnimplementedNorthboundServer()
+}
+
+func RegisterNorthboundServer(s grpc.ServiceRegistrar, srv NorthboundServer) {
+	s.RegisterService(&Northbound_ServiceDesc, srv)
+}
+
+func _Northbound_Get_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NorthboundServer).Get(m, &northboundGetServer{ServerStream: stream})
+}
+
+type Northbound_GetServer interface {
+	Send(*GetResponse) error
+	grpc.ServerStream
+}
+
+type northboundGetServer struct {
+	grpc.ServerStream
+}
+
+func (x *northboundGetServer) Send(m *GetResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Northbound_ServiceDesc is the grpc.ServiceDesc for Northbound service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Northbound_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "frr.Northbound",
+	HandlerType: (*NorthboundServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Get",
+			Handler:       _Northbound_Get_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "frr/frr-northbound.proto",
+}
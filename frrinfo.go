@@ -0,0 +1,71 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpFrrInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_frr_info",
+		Help: "A metric with a constant '1' value labeled by the routing suite (frrouting or quagga) and its version",
+	},
+		[]string{
+			"product",
+			"version",
+		})
+)
+
+var (
+	bgpFrrDaemonUp = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_frr_daemon_up",
+		Help: "Whether a given daemon of the routing suite is running, as reachable by vtysh",
+	},
+		[]string{
+			"daemon",
+		})
+)
+
+// frrDaemons : The daemons whose status is always exported, down if vtysh does not reach them
+var frrDaemons = []string{"bgpd", "zebra", "watchfrr"}
+
+// frrVersionRegex : The first line of "show version", e.g. "FRRouting 8.4.2 (router1) on Linux(5.10.0)."
+// or "Quagga 1.2.4 (router1)."
+var frrVersionRegex = regexp.MustCompile(`^(FRRouting|Quagga) (\S+) `)
+
+// recordFrrInfoMetrics : Exports the version of FRR (or Quagga) and which of its daemons run, from
+// "show version" and the daemons listed by "show daemons"
+func recordFrrInfoMetrics(c *collection) {
+	metric(c, bgpFrrInfo).Reset()
+	o, err := c.vtysh("show version")
+	if err != nil {
+		c.collectorFailed("frr_info", err)
+	} else if product, version := parseFrrVersion(o); product != "" {
+		metric(c, bgpFrrInfo).With(prometheus.Labels{"product": product, "version": version}).Set(1)
+	}
+
+	o, err = c.vtysh("show daemons")
+	if err != nil {
+		c.collectorFailed("frr_info", err)
+		return
+	}
+	metric(c, bgpFrrDaemonUp).Reset()
+	for _, d := range frrDaemons {
+		metric(c, bgpFrrDaemonUp).With(prometheus.Labels{"daemon": d}).Set(0)
+	}
+	for _, d := range strings.Fields(o) {
+		metric(c, bgpFrrDaemonUp).With(prometheus.Labels{"daemon": d}).Set(1)
+	}
+}
+
+// parseFrrVersion : Returns the lower case product and the version of "show version"
+func parseFrrVersion(s string) (product string, version string) {
+	for _, line := range strings.Split(s, "\n") {
+		if m := frrVersionRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			return strings.ToLower(m[1]), m[2]
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,10 @@
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// exporterGatherer : Returns the gatherer of the metrics as exported by every sink (HTTP, push, textfile,
+// Graphite, StatsD, remote_write and offline parsing): with the configured prefix, the compliant names
+// if enabled, and with the node label in MetalLB mode
+func exporterGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	return metallbGatherer(prefixedGatherer(compliantGatherer(g)))
+}
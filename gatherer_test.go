@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestExporterGatherer : Checks the metrics as exported, with the compliant names, the prefix and the
+// node label, the metrics not about BGP being only labeled
+func TestExporterGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	retransmits := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_tcp_retransmitted_segments",
+		Help: "The number of TCP segments retransmitted to a given BGP neighbor",
+	}, []string{"ip"})
+	threshold := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_maximum_prefixes_threshold",
+		Help: "The configured maximum prefix warning threshold",
+	}, []string{"ip"})
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "frr_up", Help: "Whether FRR is up"})
+	registry.MustRegister(retransmits, threshold, up)
+	retransmits.WithLabelValues("10.0.0.1").Set(3)
+	threshold.WithLabelValues("10.0.0.1").Set(75)
+	up.Set(1)
+
+	tests := []struct {
+		name  string
+		flags map[string]string
+		want  string
+	}{
+		{
+			name:  "defaults",
+			flags: map[string]string{},
+			want: `
+# HELP bgp_neighbor_maximum_prefixes_threshold The configured maximum prefix warning threshold
+# TYPE bgp_neighbor_maximum_prefixes_threshold gauge
+bgp_neighbor_maximum_prefixes_threshold{ip="10.0.0.1"} 75
+# HELP bgp_neighbor_tcp_retransmitted_segments The number of TCP segments retransmitted to a given BGP neighbor
+# TYPE bgp_neighbor_tcp_retransmitted_segments gauge
+bgp_neighbor_tcp_retransmitted_segments{ip="10.0.0.1"} 3
+# HELP frr_up Whether FRR is up
+# TYPE frr_up gauge
+frr_up 1
+`,
+		},
+		{
+			name: "all",
+			flags: map[string]string{
+				"metric.prefix":          "frr_bgp_",
+				"metric.compliant-names": "true",
+				"metallb":                "true",
+				"metallb.node":           "node-1",
+			},
+			want: `
+# HELP frr_bgp_neighbor_maximum_prefixes_threshold_ratio The configured maximum prefix warning threshold (ratio of the maximum) for a given BGP neighbor and address family
+# TYPE frr_bgp_neighbor_maximum_prefixes_threshold_ratio gauge
+frr_bgp_neighbor_maximum_prefixes_threshold_ratio{ip="10.0.0.1",node="node-1"} 0.75
+# HELP frr_bgp_neighbor_tcp_retransmitted_segments_total The number of TCP segments retransmitted to a given BGP neighbor
+# TYPE frr_bgp_neighbor_tcp_retransmitted_segments_total counter
+frr_bgp_neighbor_tcp_retransmitted_segments_total{ip="10.0.0.1",node="node-1"} 3
+# HELP frr_up Whether FRR is up
+# TYPE frr_up gauge
+frr_up{node="node-1"} 1
+`,
+		},
+		{
+			// The node label is only added once the node is known
+			name:  "metallb without node",
+			flags: map[string]string{"metallb": "true", "metallb.node": ""},
+			want: `
+# HELP bgp_neighbor_maximum_prefixes_threshold The configured maximum prefix warning threshold
+# TYPE bgp_neighbor_maximum_prefixes_threshold gauge
+bgp_neighbor_maximum_prefixes_threshold{ip="10.0.0.1"} 75
+# HELP bgp_neighbor_tcp_retransmitted_segments The number of TCP segments retransmitted to a given BGP neighbor
+# TYPE bgp_neighbor_tcp_retransmitted_segments gauge
+bgp_neighbor_tcp_retransmitted_segments{ip="10.0.0.1"} 3
+# HELP frr_up Whether FRR is up
+# TYPE frr_up gauge
+frr_up 1
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := map[string]string{"metric.prefix": "bgp_", "metric.compliant-names": "false", "metallb": "false"}
+			for name, value := range tt.flags {
+				flags[name] = value
+			}
+			setFlags(t, flags)
+			// The metrics are gathered twice, as every scrape does, from the metrics of the registry
+			for i := 0; i < 2; i++ {
+				if err := testutil.GatherAndCompare(exporterGatherer(registry), strings.NewReader(tt.want)); err != nil {
+					t.Errorf("gather %d: %s", i, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GNMIConfig : This represents the router streaming its neighbors over gNMI. The subscription
+// is run by gnmic (https://gnmic.openconfig.net), whose events are read from its output.
+type GNMIConfig struct {
+	Address    string   `yaml:"address"`
+	Username   string   `yaml:"username"`
+	Password   string   `yaml:"password"`
+	Insecure   bool     `yaml:"insecure"`
+	SkipVerify bool     `yaml:"skip_verify"`
+	TLSCA      string   `yaml:"tls_ca"`
+	Paths      []string `yaml:"paths"`
+	Binary     string   `yaml:"binary"`
+
+	Secret PasswordSource `yaml:",inline"`
+}
+
+// gnmiDefaultPaths : The OpenConfig BGP neighbor paths subscribed to by default
+var gnmiDefaultPaths = []string{
+	"/network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/state",
+	"/network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/timers/state",
+	"/network-instances/network-instance/protocols/protocol/bgp/neighbors/neighbor/afi-safis/afi-safi/state/prefixes",
+}
+
+func (c *GNMIConfig) enabled() bool {
+	return c.Address != ""
+}
+
+// validate : Checks that the gNMI configuration is complete, filling in the defaults
+func (c *GNMIConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("invalid address %q: %s", c.Address, err)
+	}
+	if err := c.Secret.validate(c.Password); err != nil {
+		return err
+	}
+	if len(c.Paths) == 0 {
+		c.Paths = gnmiDefaultPaths
+	}
+	if c.Binary == "" {
+		c.Binary = "gnmic"
+	}
+	return nil
+}
+
+// args : Returns the gnmic command line subscribing to the paths
+func (c *GNMIConfig) args() []string {
+	args := append(c.connectionArgs(), "subscribe", "--mode", "stream", "--stream-mode", "on-change", "--format", "event")
+	for _, p := range c.Paths {
+		args = append(args, "--path", p)
+	}
+	return args
+}
+
+// connectionArgs : Returns the global flags of gnmic connecting to the router, the password being given
+// in the environment
+func (c *GNMIConfig) connectionArgs() []string {
+	args := []string{"--address", c.Address}
+	if c.Username != "" {
+		args = append(args, "--username", c.Username)
+	}
+	if c.Insecure {
+		args = append(args, "--insecure")
+	}
+	if c.SkipVerify {
+		args = append(args, "--skip-verify")
+	}
+	if c.TLSCA != "" {
+		args = append(args, "--tls-ca", c.TLSCA)
+	}
+	return args
+}
+
+// gnmiEvent : This represents an event printed by gnmic with "--format event"
+type gnmiEvent struct {
+	Name      string                 `json:"name"`
+	Timestamp int64                  `json:"timestamp"`
+	Tags      map[string]string      `json:"tags"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+// gnmiNeighborStates : The OpenConfig session states, numbered as the states of "show ip bgp neighbors"
+var gnmiNeighborStates = map[string]float64{
+	"IDLE":        1,
+	"CONNECT":     2,
+	"ACTIVE":      3,
+	"OPENSENT":    4,
+	"OPENCONFIRM": 5,
+	"ESTABLISHED": 6,
+}
+
+// gnmiNeighbor : This represents a neighbor built from the streamed updates
+type gnmiNeighbor struct {
+	BgpNeighbor
+	// received : The prefixes received per address family, summed up as the accepted prefixes
+	received map[string]float64
+}
+
+// gnmiState : The neighbors streamed since the subscription started
+var gnmiState = struct {
+	sync.Mutex
+	neighbors map[string]*gnmiNeighbor
+	synced    bool
+	err       error
+}{neighbors: make(map[string]*gnmiNeighbor)}
+
+// errGNMIWaiting : The subscription has been started but has not received any update yet
+var errGNMIWaiting = errors.New("waiting for the first gNMI update")
+
+// gnmiNeighbors : Returns the streamed neighbors, or an error while the subscription is down
+func gnmiNeighbors() ([]BgpNeighbor, error) {
+	gnmiState.Lock()
+	defer gnmiState.Unlock()
+
+	if !gnmiState.synced {
+		if gnmiState.err != nil {
+			return nil, fmt.Errorf("gNMI subscription to %s is down: %s", config.GNMI.Address, gnmiState.err)
+		}
+		return nil, errGNMIWaiting
+	}
+	neighbors := make([]BgpNeighbor, 0, len(gnmiState.neighbors))
+	for _, n := range gnmiState.neighbors {
+		c := n.BgpNeighbor
+		c.AddressFamilies = make(map[string]*BgpAddressFamily, len(n.AddressFamilies))
+		for name, af := range n.AddressFamilies {
+			a := *af
+			c.AddressFamilies[name] = &a
+		}
+		for _, received := range n.received {
+			c.AcceptedPrefixes += received
+		}
+		neighbors = append(neighbors, c)
+	}
+	return neighbors, nil
+}
+
+// subscribeGNMI : Runs the gNMI subscription until the context is cancelled, restarting it when it fails
+func subscribeGNMI(ctx context.Context) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := runGNMISubscription(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		gnmiState.Lock()
+		gnmiState.neighbors = make(map[string]*gnmiNeighbor)
+		gnmiState.synced = false
+		gnmiState.err = err
+		gnmiState.Unlock()
+		collectorFailed(localTarget, "gnmi", fmt.Errorf("gNMI subscription to %s failed: %s", config.GNMI.Address, err))
+
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+func runGNMISubscription(ctx context.Context) error {
+	c := &config.GNMI
+	// The password is read again by each subscription, for a rotated one to be used once resubscribed
+	password, err := c.Secret.read(c.Password)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, c.Binary, c.args()...)
+	// The password is given in the environment rather than the command line, where any user could see it
+	cmd.Env = append(os.Environ(), "GNMIC_PASSWORD="+password)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	logger.Info("Subscribed to gNMI", "address", c.Address)
+
+	dec := json.NewDecoder(stdout)
+	for {
+		var events []gnmiEvent
+		if err := dec.Decode(&events); err != nil {
+			if waitErr := cmd.Wait(); waitErr != nil {
+				return fmt.Errorf("%s %s", waitErr, strings.TrimSpace(stderr.String()))
+			}
+			return fmt.Errorf("failed to read the events: %s", err)
+		}
+		gnmiState.Lock()
+		for _, e := range events {
+			applyGNMIEvent(gnmiState.neighbors, e)
+		}
+		gnmiState.synced = true
+		gnmiState.err = nil
+		gnmiState.Unlock()
+	}
+}
+
+// applyGNMIEvent : Updates the neighbor of the event with its values
+func applyGNMIEvent(neighbors map[string]*gnmiNeighbor, e gnmiEvent) {
+	address := e.Tags["neighbor_neighbor-address"]
+	if address == "" {
+		return
+	}
+	vrf := e.Tags["network-instance_name"]
+	if vrf == "default" {
+		vrf = ""
+	}
+	key := vrf + "|" + address
+	n, ok := neighbors[key]
+	if !ok {
+		n = &gnmiNeighbor{
+			BgpNeighbor: BgpNeighbor{IP: net.ParseIP(address), Vrf: vrf, AddressFamilies: make(map[string]*BgpAddressFamily)},
+			received:    make(map[string]float64),
+		}
+		neighbors[key] = n
+	}
+
+	for path, value := range e.Values {
+		s := fmt.Sprint(value)
+		f, _ := strconv.ParseFloat(s, 64)
+		switch path[strings.LastIndex(path, "/")+1:] {
+		case "session-state":
+			n.State = gnmiNeighborStates[s]
+		case "peer-as":
+			n.RemoteAS = s
+		case "description":
+			n.Description = s
+		case "peer-group":
+			n.PeerGroup = s
+		case "peer-type":
+			switch s {
+			case "INTERNAL":
+				n.Type = "ibgp"
+			case "EXTERNAL":
+				n.Type = "ebgp"
+			}
+		case "enabled":
+			// Only part of the neighbor state, not of the prefixes of an address family
+			if strings.HasSuffix(path, "/neighbor/state/enabled") {
+				n.AdminShutdown = s == "false"
+			}
+		case "established-transitions":
+			n.ConnectionsEstablished = f
+		case "negotiated-hold-time":
+			n.HoldTime = f
+		case "hold-time":
+			n.ConfiguredHoldTime = f
+		case "keepalive-interval":
+			n.ConfiguredKeepalive = f
+		case "received":
+			// The prefixes of an address family, e.g. .../afi-safi/state/prefixes/received
+			n.received[gnmiAfiName(e.Tags["afi-safi_afi-safi-name"])] = f
+		}
+	}
+}
+
+// gnmiAfiName : Converts an OpenConfig AFI-SAFI identity (e.g. "openconfig-bgp-types:IPV4_UNICAST")
+// to the name of the address family in "show ip bgp neighbors" (e.g. "IPv4 Unicast")
+func gnmiAfiName(identity string) string {
+	identity = identity[strings.LastIndex(identity, ":")+1:]
+	parts := strings.SplitN(identity, "_", 2)
+	if len(parts) != 2 {
+		return identity
+	}
+	afi := strings.Replace(strings.ToLower(parts[0]), "ipv", "IPv", 1)
+	safi := strings.ToUpper(parts[1][:1]) + strings.ToLower(parts[1][1:])
+	return afi + " " + safi
+}
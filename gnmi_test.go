@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGNMIAfiName(t *testing.T) {
+	tests := map[string]string{
+		"openconfig-bgp-types:IPV4_UNICAST": "IPv4 Unicast",
+		"openconfig-bgp-types:IPV6_UNICAST": "IPv6 Unicast",
+		"SRTE":                              "SRTE",
+	}
+	for identity, want := range tests {
+		if got := gnmiAfiName(identity); got != want {
+			t.Errorf("gnmiAfiName(%q) = %q, want %q", identity, got, want)
+		}
+	}
+}
+
+// TestGNMISubscription : Checks the neighbors built from the events printed by gnmic, and how gnmic is run
+func TestGNMISubscription(t *testing.T) {
+	dir := t.TempDir()
+	gnmic := filepath.Join(dir, "gnmic")
+	script := `#!/bin/sh
+echo "$@" > '` + dir + `/args'
+echo "$GNMIC_PASSWORD" > '` + dir + `/password'
+cat testdata/gnmi/subscribe_events.json
+`
+	if err := os.WriteFile(gnmic, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	setFlags(t, nil)
+	config.GNMI = GNMIConfig{Address: "router1:57400", Username: "admin", Password: "secret", SkipVerify: true, Binary: gnmic}
+	if err := config.GNMI.validate(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		gnmiState.Lock()
+		gnmiState.neighbors = make(map[string]*gnmiNeighbor)
+		gnmiState.synced = false
+		gnmiState.Unlock()
+	})
+
+	if _, err := gnmiNeighbors(); err != errGNMIWaiting {
+		t.Fatalf("got %v before the first update, want %v", err, errGNMIWaiting)
+	}
+	// The subscription ends with the output of gnmic
+	if err := runGNMISubscription(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to read the events") {
+		t.Fatalf("got the error %v once gnmic exited", err)
+	}
+
+	want := "--address router1:57400 --username admin --skip-verify subscribe --mode stream --stream-mode on-change --format event" +
+		" --path " + strings.Join(gnmiDefaultPaths, " --path ")
+	if got := strings.TrimSpace(readFile(t, filepath.Join(dir, "args"))); got != want {
+		t.Errorf("gnmic was run with\n%s\nwant\n%s", got, want)
+	}
+	if got := strings.TrimSpace(readFile(t, filepath.Join(dir, "password"))); got != "secret" {
+		t.Errorf("gnmic got the password %q", got)
+	}
+
+	neighbors, err := gnmiNeighbors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].key() < neighbors[j].key() })
+	golden(t, "testdata/gnmi/subscribe_events.golden", neighbors)
+}
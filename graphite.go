@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/graphite"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	graphiteAddress  = flag.String("graphite.address", "", "Push the metrics to this Graphite server in the plaintext protocol, e.g. graphite:2003")
+	graphitePrefix   = flag.String("graphite.prefix", "", "The prefix of the paths of the metrics pushed to Graphite")
+	graphiteInterval = flag.Duration("graphite.interval", time.Minute, "How often the metrics are pushed to Graphite")
+	statsdAddress    = flag.String("statsd.address", "", "Send the metrics to this StatsD server as gauges, e.g. statsd:8125")
+	statsdPrefix     = flag.String("statsd.prefix", "", "The prefix of the names of the metrics sent to StatsD")
+	statsdInterval   = flag.Duration("statsd.interval", time.Minute, "How often the metrics are sent to StatsD")
+)
+
+// pushGraphite : Pushes the metrics to Graphite every interval until the context is cancelled, the paths
+// being the metric names followed by the label names and values, as the Graphite bridge of client_golang
+// builds them
+func pushGraphite(ctx context.Context) {
+	bridge, err := graphite.NewBridge(&graphite.Config{
+		URL:      *graphiteAddress,
+		Prefix:   *graphitePrefix,
+		Gatherer: exporterGatherer(targetsGatherer(prometheus.DefaultGatherer)),
+	})
+	if err != nil {
+		logger.Error("Failed to set up the Graphite push", "address", *graphiteAddress, "err", err)
+		return
+	}
+	pushEvery(ctx, *graphiteInterval, func() {
+		if err := bridge.Push(); err != nil {
+			logger.Error("Failed to push the metrics to Graphite", "address", *graphiteAddress, "err", err)
+			recordError(localTarget, err.Error())
+		}
+	})
+}
+
+// pushStatsd : Sends the metrics to StatsD every interval until the context is cancelled
+func pushStatsd(ctx context.Context) {
+	pushEvery(ctx, *statsdInterval, func() {
+		if err := sendStatsd(); err != nil {
+			logger.Error("Failed to send the metrics to StatsD", "address", *statsdAddress, "err", err)
+			recordError(localTarget, err.Error())
+		}
+	})
+}
+
+func pushEvery(ctx context.Context, interval time.Duration, push func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			push()
+		}
+	}
+}
+
+// statsdPacketSize : The size up to which the metrics are sent in a single datagram
+const statsdPacketSize = 1432
+
+// sendStatsd : Sends the counters and gauges as StatsD gauges, since StatsD counters are increments
+// rather than totals, in as few datagrams as fit
+func sendStatsd() error {
+	mfs, err := exporterGatherer(targetsGatherer(prometheus.DefaultGatherer)).Gather()
+	if err != nil {
+		return err
+	}
+	conn, err := net.Dial("udp", *statsdAddress)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet bytes.Buffer
+	for _, line := range statsdLines(mfs) {
+		if packet.Len() > 0 && packet.Len()+len(line) > statsdPacketSize {
+			if _, err := conn.Write(packet.Bytes()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		_, err = conn.Write(packet.Bytes())
+	}
+	return err
+}
+
+// statsdLines : Returns the "name:value|g" lines of the counters and gauges, named as the Graphite paths
+func statsdLines(mfs []*dto.MetricFamily) []string {
+	var lines []string
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			var value float64
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				value = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				value = m.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				value = m.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			// StatsD gauges cannot be set to negative values, which would be read as decrements
+			if value < 0 {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s:%s|g\n", metricPath(*statsdPrefix, mf.GetName(), m.GetLabel()), strconv.FormatFloat(value, 'g', -1, 64)))
+		}
+	}
+	return lines
+}
+
+var pathInvalidRegex = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// metricPath : Returns the dotted path of a series, e.g. "bgp_neighbor_state.interface..ip.10_0_0_1.view.",
+// with the labels sorted by name and the characters of their values other than letters, digits, "_" and "-"
+// replaced by "_"
+func metricPath(prefix string, name string, labels []*dto.LabelPair) string {
+	var b bytes.Buffer
+	if prefix != "" {
+		b.WriteString(prefix + ".")
+	}
+	b.WriteString(name)
+	sorted := append([]*dto.LabelPair(nil), labels...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+	for _, l := range sorted {
+		b.WriteString("." + l.GetName() + "." + pathInvalidRegex.ReplaceAllString(l.GetValue(), "_"))
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative api/v1/bgp_exporter.proto
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	bgpexporterv1 "github.com/fiveai/bgp-exporter/api/v1"
+	"google.golang.org/grpc"
+)
+
+var grpcListenAddress = flag.String("grpc.listen-address", "", "Serve the gRPC API of api/v1/bgp_exporter.proto (the neighbors and their state changes) in cleartext HTTP/2 on this address, e.g. :9115")
+
+// grpcAPI : Implements the BgpExporter service of api/v1/bgp_exporter.proto
+type grpcAPI struct {
+	bgpexporterv1.UnimplementedBgpExporterServer
+}
+
+// newGRPCServer : Returns the server of the gRPC API, which speaks HTTP/2 without TLS as the northbound
+// interface of FRR does
+func newGRPCServer() *grpc.Server {
+	s := grpc.NewServer()
+	bgpexporterv1.RegisterBgpExporterServer(s, &grpcAPI{})
+	return s
+}
+
+// stopGRPCServer : Stops the server once its calls have completed, or when the context is done
+func stopGRPCServer(ctx context.Context, s *grpc.Server) {
+	// The streams of state changes never complete, so they have to be ended for the server to stop
+	events.close()
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Error("Failed to shut down the gRPC server gracefully", "err", ctx.Err())
+		s.Stop()
+	}
+}
+
+// ListNeighbors : Returns the neighbors of the last collection of the router, or of all routers
+func (grpcAPI) ListNeighbors(_ context.Context, req *bgpexporterv1.ListNeighborsRequest) (*bgpexporterv1.ListNeighborsResponse, error) {
+	return &bgpexporterv1.ListNeighborsResponse{Neighbors: grpcNeighbors(req.GetRouter())}, nil
+}
+
+// WatchStateChanges : Streams the state changes of the neighbors of the router, or of all routers
+func (grpcAPI) WatchStateChanges(req *bgpexporterv1.WatchStateChangesRequest, stream bgpexporterv1.BgpExporter_WatchStateChangesServer) error {
+	ch := events.subscribe()
+	defer events.unsubscribe(ch)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case c, ok := <-ch:
+			if !ok {
+				// The exporter is shutting down
+				return nil
+			}
+			if req.GetRouter() != "" && c.Target != req.GetRouter() {
+				continue
+			}
+			if err := stream.Send(stateChangeMessage(c)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// grpcNeighbors : Returns the Neighbor messages of the router, or of all routers
+func grpcNeighbors(router string) []*bgpexporterv1.Neighbor {
+	var neighbors []*bgpexporterv1.Neighbor
+	if multiRouter() {
+		for _, t := range activeTargets() {
+			if router == "" || t.Name == router {
+				for _, n := range t.state.neighbors.List() {
+					neighbors = append(neighbors, neighborMessage(t.Name, n))
+				}
+			}
+		}
+	} else if router == "" {
+		for _, n := range localTargetConfig.state.neighbors.List() {
+			neighbors = append(neighbors, neighborMessage("", n))
+		}
+	}
+	return neighbors
+}
+
+// neighborMessage : Returns the neighbor as a Neighbor message
+func neighborMessage(router string, n BgpNeighbor) *bgpexporterv1.Neighbor {
+	m := &bgpexporterv1.Neighbor{
+		Router:                 router,
+		Interface:              n.Interface,
+		View:                   n.Vrf,
+		RemoteAs:               n.RemoteAS,
+		Description:            n.Description,
+		Type:                   n.Type,
+		PeerGroup:              n.PeerGroup,
+		Hostname:               n.Hostname,
+		State:                  stateName(n.State),
+		StateNumber:            uint32(n.State),
+		UptimeSeconds:          n.Uptime,
+		AcceptedPrefixes:       uint64(n.AcceptedPrefixes),
+		ConnectionsEstablished: uint64(n.ConnectionsEstablished),
+		ConnectionsDropped:     uint64(n.ConnectionsDropped),
+		LastResetReason:        n.LastResetReason,
+		AdminShutdown:          n.AdminShutdown,
+		ShutdownMessage:        n.ShutdownMessage,
+	}
+	if n.IP != nil {
+		m.Ip = n.IP.String()
+	}
+	for _, name := range strings.Split(n.addressFamilyNames(), ",") {
+		if name == "" {
+			continue
+		}
+		af := n.AddressFamilies[name]
+		m.AddressFamilies = append(m.AddressFamilies, &bgpexporterv1.AddressFamily{
+			Name:             name,
+			AcceptedPrefixes: uint64(af.AcceptedPrefixes),
+			MaximumPrefixes:  uint64(af.MaximumPrefixes),
+		})
+	}
+	return m
+}
+
+// stateChangeMessage : Returns the change as a StateChange message
+func stateChangeMessage(c StateChange) *bgpexporterv1.StateChange {
+	return &bgpexporterv1.StateChange{
+		TimeUnixNano: c.Time.UnixNano(),
+		Router:       c.Target,
+		Neighbor:     c.Neighbor,
+		Interface:    c.Interface,
+		View:         c.Vrf,
+		OldState:     c.OldState,
+		NewState:     c.NewState,
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"sync/atomic"
+)
+
+var waitForCollection = flag.Bool("web.wait-for-collection", false, "Answer /metrics with 503 until the first successful collection, unless the neighbors were restored from --state.file, rather than serving gauges at 0 while the exporter starts")
+
+// ready : Whether at least one collection of the neighbors succeeded, or the exporter serves with
+// --probe.only, without a collection of its own
+var ready atomic.Bool
+
+// warmStarted : Whether the metrics of the neighbors restored from the state file are served until they are collected
+var warmStarted atomic.Bool
+
+// metricsReady : Whether /metrics is served, i.e. unless it waits for a first collection
+func metricsReady() bool {
+	return !*waitForCollection || ready.Load() || warmStarted.Load()
+}
+
+// healthzHandler : Reports that the process is alive
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK\n"))
+}
+
+// readyzHandler : Reports whether the exporter has data to serve, i.e. a collection succeeded or it
+// only collects the probes
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	if !ready.Load() {
+		http.Error(w, "No successful collection yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK\n"))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var historySize = flag.Int("history.size", 1000, "The number of neighbor state changes kept for /api/v1/history")
+
+var (
+	bgpNeighborFlaps = newCounterVec(prometheus.CounterOpts{
+		Name: "bgp_neighbor_flaps_total",
+		Help: "The number of times the BGP neighbor left the established state",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+// stateHistory : This keeps the most recent state changes in a ring buffer
+var stateHistory = struct {
+	sync.Mutex
+	changes []StateChange
+	next    int
+}{}
+
+// recordHistory : Keeps the state changes in the history and counts the flaps
+func (c *collection) recordHistory(changes []StateChange) {
+	stateHistory.Lock()
+	defer stateHistory.Unlock()
+
+	for _, change := range changes {
+		if change.OldState == stateName(6) && change.NewState != "" {
+			metric(c, bgpNeighborFlaps).With(prometheus.Labels{"ip": change.Neighbor, "interface": change.Interface, "view": change.Vrf}).Inc()
+		}
+		if *historySize <= 0 {
+			continue
+		}
+		if len(stateHistory.changes) < *historySize {
+			stateHistory.changes = append(stateHistory.changes, change)
+			continue
+		}
+		stateHistory.changes[stateHistory.next] = change
+		stateHistory.next = (stateHistory.next + 1) % *historySize
+	}
+}
+
+// historyHandler : Serves the state changes kept in the history as JSON, oldest first
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	stateHistory.Lock()
+	changes := make([]StateChange, 0, len(stateHistory.changes))
+	changes = append(changes, stateHistory.changes[stateHistory.next:]...)
+	changes = append(changes, stateHistory.changes[:stateHistory.next]...)
+	stateHistory.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(changes); err != nil {
+		logger.Error("Failed to encode state history", "err", err)
+	}
+}
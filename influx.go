@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	influxURL       = flag.String("influx.url", "", "Write the neighbors to this InfluxDB (v2 API) as line protocol after each collection, e.g. http://influxdb:8086")
+	influxOrg       = flag.String("influx.org", "", "The organization of the InfluxDB bucket")
+	influxBucket    = flag.String("influx.bucket", "bgp", "The InfluxDB bucket the neighbors are written to")
+	influxTokenFile = flag.String("influx.token-file", "", "File holding the InfluxDB API token")
+	influxTimeout   = flag.Duration("influx.timeout", 10*time.Second, "Timeout for an InfluxDB write request")
+	influxFile      = flag.String("output.influx-file", "", "Append the neighbors as InfluxDB line protocol to this file after each collection")
+)
+
+// influxToken : The InfluxDB API token, if any
+var influxToken string
+
+// loadInfluxToken : Reads the InfluxDB API token
+func loadInfluxToken() error {
+	if *influxTokenFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(*influxTokenFile)
+	if err != nil {
+		return err
+	}
+	influxToken = strings.TrimSpace(string(content))
+	return nil
+}
+
+// influxEnabled : Whether the neighbors are written as line protocol after each collection
+func influxEnabled() bool {
+	return *influxURL != "" || *influxFile != ""
+}
+
+// writeInflux : Writes the neighbors of the collection as line protocol to InfluxDB and/or the file.
+// A collection which could not be written is not retried.
+func writeInflux() {
+	lines := influxLines(time.Now())
+	if *influxURL != "" {
+		if err := sendInflux(lines); err != nil {
+			logger.Error("Failed to write the neighbors to InfluxDB", "url", *influxURL, "err", err)
+			recordError(localTarget, err.Error())
+		}
+	}
+	if *influxFile != "" {
+		if err := appendInfluxFile(*influxFile, lines); err != nil {
+			logger.Error("Failed to write the neighbors to the line protocol file", "path", *influxFile, "err", err)
+			recordError(localTarget, err.Error())
+		}
+	}
+}
+
+// sendInflux : Sends the lines to the write endpoint of the InfluxDB v2 API
+func sendInflux(lines []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *influxTimeout)
+	defer cancel()
+
+	query := url.Values{"bucket": {*influxBucket}, "precision": {"ns"}}
+	if *influxOrg != "" {
+		query.Set("org", *influxOrg)
+	}
+	u := strings.TrimSuffix(*influxURL, "/") + "/api/v2/write?" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(lines))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "bgp_exporter")
+	if influxToken != "" {
+		req.Header.Set("Authorization", "Token "+influxToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("InfluxDB write failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+}
+
+func appendInfluxFile(path string, lines []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// influxLines : Returns a bgp_neighbor point per neighbor of the routers, tagged as the per neighbor metrics
+// are labeled, and with the remote ASN
+func influxLines(now time.Time) []byte {
+	var b bytes.Buffer
+	if multiRouter() {
+		for _, t := range activeTargets() {
+			appendInfluxNeighbors(&b, t.Name, t.state.neighbors.List(), now)
+		}
+	} else {
+		appendInfluxNeighbors(&b, "", localTargetConfig.state.neighbors.List(), now)
+	}
+	return b.Bytes()
+}
+
+func appendInfluxNeighbors(b *bytes.Buffer, router string, neighbors []BgpNeighbor, now time.Time) {
+	for _, n := range neighbors {
+		b.WriteString("bgp_neighbor")
+		tags := n.labels("router", router, "remote_as", n.RemoteAS)
+		for _, k := range []string{"interface", "ip", "remote_as", "router", "view"} {
+			// Empty tag values are not allowed
+			if v := tags[k]; v != "" {
+				b.WriteString("," + k + "=" + influxEscape(v))
+			}
+		}
+		fmt.Fprintf(b, " state=%di,state_name=%s,accepted_prefixes=%s,uptime_seconds=%s,connections_established=%di,connections_dropped=%di,admin_shutdown=%t",
+			int(n.State), influxString(stateName(n.State)), influxFloat(n.AcceptedPrefixes), influxFloat(n.Uptime),
+			int64(n.ConnectionsEstablished), int64(n.ConnectionsDropped), n.AdminShutdown)
+		if n.Description != "" {
+			fmt.Fprintf(b, ",description=%s", influxString(n.Description))
+		}
+		fmt.Fprintf(b, " %d\n", now.UnixNano())
+	}
+}
+
+// influxEscape : Escapes the commas, equal signs and spaces of a tag value
+func influxEscape(s string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(s)
+}
+
+// influxString : Returns a string field value, quoted with its backslashes and double quotes escaped
+func influxString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func influxFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborsTruncated = newGauge(prometheus.GaugeOpts{
+		Name: "bgp_neighbors_truncated",
+		Help: "The number of BGP neighbors beyond the configured maximum, whose per neighbor gauges are summed into the series with ip=\"other\" (their counters are not exported)",
+	})
+)
+
+// limitNeighbors : Returns the neighbors whose per neighbor metrics are exported, i.e. the first ones
+// up to the configured maximum in the order of the views and addresses, followed by a single neighbor
+// summing the others if any, for the number of series to stay bounded (e.g. for route servers with
+// dynamic neighbors). The truncated neighbors are still kept in the store, for their state changes.
+func (c *collection) limitNeighbors(neighbors []BgpNeighbor) []BgpNeighbor {
+	truncated := make(map[string]bool)
+	if max := config.Neighbors.Max; max > 0 && len(neighbors) > max {
+		other := BgpNeighbor{Overflow: true}
+		for _, n := range neighbors[max:] {
+			truncated[storeKey(n)] = true
+			other.AcceptedPrefixes += n.AcceptedPrefixes
+		}
+		neighbors = append(neighbors[:max:max], other)
+	}
+	c.state.truncated = truncated
+	metric(c, bgpNeighborsTruncated).Set(float64(len(truncated)))
+	return neighbors
+}
+
+// neighborTruncated : Whether the neighbor named as in the tables (by address, or by interface for
+// unnumbered neighbors) of the default view is beyond the maximum
+func (c *collection) neighborTruncated(name string) bool {
+	return c.state.truncated["|"+name]
+}
+
+// exportedNeighbors : Returns the neighbors whose per neighbor metrics are exported, i.e. all those of
+// the store but the truncated ones
+func (c *collection) exportedNeighbors() []BgpNeighbor {
+	neighbors := c.state.neighbors.List()
+	if len(c.state.truncated) == 0 {
+		return neighbors
+	}
+	var exported []BgpNeighbor
+	for _, n := range neighbors {
+		if !c.state.truncated[storeKey(n)] {
+			exported = append(exported, n)
+		}
+	}
+	return exported
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+var logLevel = flag.String("log.level", "info", "Only log messages with the given severity or above. One of: [debug, info, warn, error]")
+var logFormat = flag.String("log.format", "logfmt", "Output format of log messages. One of: [logfmt, json]")
+
+var logger = slog.Default()
+
+// setupLogging : Configures the logger from the log flags
+func setupLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q", *logLevel)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch *logFormat {
+	case "logfmt":
+		logger = slog.New(slog.NewTextHandler(os.Stderr, opts))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		return fmt.Errorf("invalid log format %q", *logFormat)
+	}
+	slog.SetDefault(logger)
+	return nil
+}
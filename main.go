@@ -2,191 +2,1228 @@ package main
 
 import (
 	"bytes"
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
 	"net"
 	"net/http"
+	"os"
 	"os/exec"
-	"regexp"
+	"os/signal"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 var (
-	bgpNeighborState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	bgpNeighborState = newGaugeVec(prometheus.GaugeOpts{
 		Name: "bgp_neighbor_state",
-		Help: "The state of the connection to a given BGP neighbor (1=idle,2=connect,3=active,4=opensent,5=openconfirm,6=established)",
+		Help: "The state of the connection to a given BGP neighbor (1=idle,2=connect,3=active,4=opensent,5=openconfirm,6=established,7=clearing,8=deleted)",
 	},
 		[]string{
 			"ip",
+			"interface",
+			"view",
 		})
 )
 
 var (
-	bgpNeighborAcceptedPrefixes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	bgpNeighborStateSet = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_state",
+		Help: "The state of the connection to a given BGP neighbor, 1 for the current state and 0 for the others",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"state",
+		})
+)
+
+var (
+	bgpNeighborAcceptedPrefixes = newGaugeVec(prometheus.GaugeOpts{
 		Name: "bgp_neighbor_accepted_prefixes",
 		Help: "The number of accepted prefixes for a given BGP neighbor",
 	},
 		[]string{
 			"ip",
+			"interface",
+			"view",
 		})
 )
 
 var (
-	bgpNeighborConnectionsEstablished = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	bgpNeighborConnectionsEstablished = NewTotalVec(prometheus.CounterOpts{
 		Name: "bgp_neighbor_connections_established",
 		Help: "The number of connections that have been established for a given BGP neighbor",
 	}, []string{
 		"ip",
+		"interface",
+		"view",
 	})
 )
 
 var (
-	bgpNeighborConnectionsDropped = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	bgpNeighborConnectionsDropped = NewTotalVec(prometheus.CounterOpts{
 		Name: "bgp_neighbor_connections_dropped",
 		Help: "The number of connections that have been dropped for a given BGP neighbor",
 	},
 		[]string{
 			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborMaximumPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_maximum_prefixes",
+		Help: "The configured maximum number of prefixes accepted from a given BGP neighbor for an address family",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborMaximumPrefixesThreshold = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_maximum_prefixes_threshold",
+		Help: "The configured maximum prefix warning threshold (percent) for a given BGP neighbor and address family",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborAdminShutdown = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_admin_shutdown",
+		Help: "Whether a given BGP neighbor has been administratively shut down (1=shutdown,0=enabled), with the RFC 8203 shutdown message if any",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"message",
+		})
+)
+
+var (
+	bgpNeighborGracefulRestartCapability = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_graceful_restart_capability",
+		Help: "Whether the graceful restart capability has been advertised to or received from a given BGP neighbor (1=yes,0=no)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"direction",
+		})
+)
+
+var (
+	bgpNeighborGracefulRestartTimer = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_graceful_restart_timer_seconds",
+		Help: "The restart time announced by a given BGP neighbor in its graceful restart capability",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborGracefulRestartRestarting = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_graceful_restart_restarting",
+		Help: "Whether a given BGP neighbor is currently restarting and its routes are being retained (1=restarting,0=not restarting)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborGracefulRestartPreserved = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_graceful_restart_forwarding_preserved",
+		Help: "Whether a given BGP neighbor preserves forwarding state (NSF) for an address family during a graceful restart (1=preserved,0=not preserved)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborBfdStatus = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_bfd_status",
+		Help: "The status of the BFD session to a given BGP neighbor (0=admindown,1=down,2=init,3=up,-1=unknown)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"type",
+		})
+)
+
+var (
+	bgpNeighborBfdDetectMultiplier = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_bfd_detect_multiplier",
+		Help: "The BFD detect multiplier configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborBfdMinRxInterval = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_bfd_min_rx_interval_seconds",
+		Help: "The BFD minimum receive interval configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborBfdMinTxInterval = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_bfd_min_tx_interval_seconds",
+		Help: "The BFD minimum transmit interval configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborHoldTime = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_hold_time_seconds",
+		Help: "The hold time negotiated with a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborKeepaliveInterval = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_keepalive_interval_seconds",
+		Help: "The keepalive interval negotiated with a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborConfiguredHoldTime = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_configured_hold_time_seconds",
+		Help: "The hold time configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborConfiguredKeepaliveInterval = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_configured_keepalive_interval_seconds",
+		Help: "The keepalive interval configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborLastRead = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_last_read_seconds",
+		Help: "The number of seconds since a message was last read from a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborLastWrite = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_last_write_seconds",
+		Help: "The number of seconds since a message was last written to a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborHoldTimerRemaining = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_hold_timer_remaining_seconds",
+		Help: "The number of seconds before the hold timer of a given established BGP neighbor expires, unless a message is read",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborAdvertisementInterval = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_advertisement_interval_seconds",
+		Help: "The minimum time between the advertisement runs to a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborUpdateGroupInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_update_group_info",
+		Help: "A metric with a constant '1' value labeled by the update group and subgroup of a given BGP neighbor for an address family",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+			"update_group",
+			"subgroup",
+		})
+)
+
+var (
+	bgpNeighborPolicyInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_policy_info",
+		Help: "A metric with a constant '1' value labeled by the route maps and prefix lists applied inbound and outbound to a given BGP neighbor for an address family, empty if none",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+			"inbound_route_map",
+			"inbound_prefix_list",
+			"outbound_route_map",
+			"outbound_prefix_list",
+		})
+)
+
+var (
+	bgpNeighborConnectionInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_connection_info",
+		Help: "A metric with a constant '1' value labeled by the local and foreign address and port of the TCP connection to a given BGP neighbor, its configured update source and eBGP multihop TTL",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"local_host",
+			"local_port",
+			"foreign_host",
+			"foreign_port",
+			"update_source",
+			"multihop_ttl",
+		})
+)
+
+var (
+	bgpNeighborInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_info",
+		Help: "Information about a given BGP neighbor: its remote ASN, description, session type (ibgp,ebgp,confed_ibgp,confed_ebgp), address families (comma-separated), whether it is a route-reflector client, its peer group, the hostname it advertised and the name of its address if reverse lookups are enabled, always 1",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"remote_as",
+			"description",
+			"type",
+			"address_families",
+			"route_reflector_client",
+			"peer_group",
+			"peer_hostname",
+			"peer_dns",
 		})
 )
 
 // BgpNeighbor : This represents a BGP Neighbor
 type BgpNeighbor struct {
-	IP                     net.IP
-	State                  float64
-	AcceptedPrefixes       float64
-	ConnectionsEstablished float64
-	ConnectionsDropped     float64
+	IP                       net.IP
+	Interface                string
+	Vrf                      string
+	RemoteAS                 string
+	Description              string
+	Type                     string
+	RouteReflectorClient     bool
+	PeerGroup                string
+	Hostname                 string
+	PeerDNS                  string
+	State                    float64
+	AcceptedPrefixes         float64
+	Uptime                   float64
+	ConnectionsEstablished   float64
+	ConnectionsDropped       float64
+	LastResetReason          string
+	AdminShutdown            bool
+	ShutdownMessage          string
+	GRAdvertised             bool
+	GRReceived               bool
+	GRRestartTimer           float64
+	GRRestarting             bool
+	HoldTime                 float64
+	KeepaliveInterval        float64
+	ConfiguredHoldTime       float64
+	ConfiguredKeepalive      float64
+	LastRead                 float64
+	LastWrite                float64
+	HasLastRead              bool
+	AdvertisementInterval    float64
+	TTLSecurity              bool
+	MultihopTTL              string
+	UpdateSource             string
+	LocalHost                string
+	LocalPort                string
+	ForeignHost              string
+	ForeignPort              string
+	Authentication           string
+	HasAdvertisementInterval bool
+	BfdType                  string
+	BfdStatus                float64
+	BfdDetectMultiplier      float64
+	BfdMinRxInterval         float64
+	BfdMinTxInterval         float64
+	Overflow                 bool
+	AddressFamilies          map[string]*BgpAddressFamily
+}
+
+// BgpAddressFamily : This represents the per address family settings of a BGP Neighbor
+type BgpAddressFamily struct {
+	MaximumPrefixes          float64
+	MaximumPrefixesThreshold float64
+	GracefulRestart          bool
+	GRForwardingPreserved    bool
+	UpdateGroup              string
+	UpdateSubgroup           string
+	AcceptedPrefixes         float64
+	SoftReconfigInbound      bool
+	InboundRouteMap          string
+	InboundPrefixList        string
+	OutboundRouteMap         string
+	OutboundPrefixList       string
+	PolicyDenied             float64
+	HasPolicyDenied          bool
+}
+
+// labels : Returns the labels identifying the neighbor, followed by the given extra label names and values
+func (n *BgpNeighbor) labels(extra ...string) prometheus.Labels {
+	labels := prometheus.Labels{"ip": "", "interface": n.Interface, "view": n.Vrf}
+	if n.IP != nil {
+		labels["ip"] = n.IP.String()
+	} else if n.Overflow {
+		labels["ip"] = "other"
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels[extra[i]] = extra[i+1]
+	}
+	return labels
+}
+
+// samples : Returns the values of all per neighbor metrics for the neighbor
+func (n *BgpNeighbor) samples() []neighborSample {
+	var samples []neighborSample
+	id := n.id()
+	if n.Overflow {
+		// Only the gauges which can be summed. The sum of the connection counters would go down whenever
+		// the truncated neighbors change, which rate() and increase() would take as counter resets.
+		samples = append(samples, neighborSample{bgpNeighborAcceptedPrefixes, id.labels(), n.AcceptedPrefixes})
+		return samples
+	}
+	if *stateSet {
+		for state := 1; state < len(bgpStateNames); state++ {
+			samples = append(samples, neighborSample{bgpNeighborStateSet, id.labels("state", bgpStateNames[state]), boolToFloat(int(n.State) == state)})
+		}
+	} else {
+		samples = append(samples, neighborSample{bgpNeighborState, id.labels(), n.State})
+	}
+	samples = append(samples, neighborSample{bgpNeighborInfo, id.labels("remote_as", n.RemoteAS, "description", n.Description, "type", n.Type, "address_families", n.addressFamilyNames(), "route_reflector_client", strconv.FormatBool(n.RouteReflectorClient), "peer_group", n.PeerGroup, "peer_hostname", n.Hostname, "peer_dns", n.PeerDNS), 1})
+	samples = append(samples, neighborSample{bgpNeighborAcceptedPrefixes, id.labels(), n.AcceptedPrefixes})
+	samples = append(samples, neighborSample{bgpNeighborConnectionsEstablished.GaugeVec, id.labels(), n.ConnectionsEstablished})
+	samples = append(samples, neighborSample{bgpNeighborConnectionsDropped.GaugeVec, id.labels(), n.ConnectionsDropped})
+	samples = append(samples, neighborSample{bgpNeighborAdminShutdown, id.labels("message", n.ShutdownMessage), boolToFloat(n.AdminShutdown)})
+	samples = append(samples, neighborSample{bgpNeighborHoldTime, id.labels(), n.HoldTime})
+	samples = append(samples, neighborSample{bgpNeighborKeepaliveInterval, id.labels(), n.KeepaliveInterval})
+	samples = append(samples, neighborSample{bgpNeighborConfiguredHoldTime, id.labels(), n.ConfiguredHoldTime})
+	samples = append(samples, neighborSample{bgpNeighborConfiguredKeepaliveInterval, id.labels(), n.ConfiguredKeepalive})
+	if n.LocalHost != "" || n.UpdateSource != "" || n.MultihopTTL != "" {
+		samples = append(samples, neighborSample{bgpNeighborConnectionInfo, id.labels("local_host", n.LocalHost, "local_port", n.LocalPort, "foreign_host", n.ForeignHost, "foreign_port", n.ForeignPort, "update_source", n.UpdateSource, "multihop_ttl", n.MultihopTTL), 1})
+	}
+	if n.HasAdvertisementInterval {
+		samples = append(samples, neighborSample{bgpNeighborAdvertisementInterval, id.labels(), n.AdvertisementInterval})
+	}
+	if n.State == 6 && n.HasLastRead {
+		samples = append(samples, neighborSample{bgpNeighborLastRead, id.labels(), n.LastRead})
+		samples = append(samples, neighborSample{bgpNeighborLastWrite, id.labels(), n.LastWrite})
+		if n.HoldTime > 0 {
+			samples = append(samples, neighborSample{bgpNeighborHoldTimerRemaining, id.labels(), math.Max(n.HoldTime-n.LastRead, 0)})
+		}
+	}
+	samples = append(samples, neighborSample{bgpNeighborGracefulRestartCapability, id.labels("direction", "advertised"), boolToFloat(n.GRAdvertised)})
+	samples = append(samples, neighborSample{bgpNeighborGracefulRestartCapability, id.labels("direction", "received"), boolToFloat(n.GRReceived)})
+	samples = append(samples, neighborSample{bgpNeighborGracefulRestartTimer, id.labels(), n.GRRestartTimer})
+	samples = append(samples, neighborSample{bgpNeighborGracefulRestartRestarting, id.labels(), boolToFloat(n.GRRestarting)})
+	if n.BfdType != "" {
+		samples = append(samples, neighborSample{bgpNeighborBfdStatus, id.labels("type", n.BfdType), n.BfdStatus})
+		samples = append(samples, neighborSample{bgpNeighborBfdDetectMultiplier, id.labels(), n.BfdDetectMultiplier})
+		samples = append(samples, neighborSample{bgpNeighborBfdMinRxInterval, id.labels(), n.BfdMinRxInterval})
+		samples = append(samples, neighborSample{bgpNeighborBfdMinTxInterval, id.labels(), n.BfdMinTxInterval})
+	}
+	for afi, af := range n.AddressFamilies {
+		if af.GracefulRestart {
+			samples = append(samples, neighborSample{bgpNeighborGracefulRestartPreserved, id.labels("afi", afi), boolToFloat(af.GRForwardingPreserved)})
+		}
+		if af.UpdateGroup != "" {
+			samples = append(samples, neighborSample{bgpNeighborUpdateGroupInfo, id.labels("afi", afi, "update_group", af.UpdateGroup, "subgroup", af.UpdateSubgroup), 1})
+		}
+		samples = append(samples, neighborSample{bgpNeighborPolicyInfo, id.labels("afi", afi, "inbound_route_map", af.InboundRouteMap, "inbound_prefix_list", af.InboundPrefixList,
+			"outbound_route_map", af.OutboundRouteMap, "outbound_prefix_list", af.OutboundPrefixList), 1})
+		if af.MaximumPrefixes > 0 {
+			samples = append(samples, neighborSample{bgpNeighborMaximumPrefixes, id.labels("afi", afi), af.MaximumPrefixes})
+			samples = append(samples, neighborSample{bgpNeighborMaximumPrefixesThreshold, id.labels("afi", afi), af.MaximumPrefixesThreshold})
+		}
+	}
+	samples = append(samples, n.prefixRangeSamples(id)...)
+	return samples
 }
 
-var bgpNeighbors []BgpNeighbor
+// key : Returns the key identifying the neighbor, which is its interface for unnumbered neighbors
+func (n *BgpNeighbor) key() string {
+	if n.Interface != "" {
+		return n.Interface
+	}
+	return n.IP.String()
+}
 
-var bgpNeighborRegex = regexp.MustCompile(`^BGP neighbor is ([\d.]+), .*$`)
-var bgpStateRegex = regexp.MustCompile(`^\s+BGP state = (\w+), .*$`)
-var bgpAcceptedPrefixesRegex = regexp.MustCompile(`^\s+(\d+) accepted prefixes\w*$`)
-var bgpConnectionsEstablishedDroppedRegex = regexp.MustCompile(`^\s+Connections established (\d+); dropped (\d+)\w*$`)
+// neighborLabels : Returns the labels identifying a neighbor as printed in tables, where
+// unnumbered neighbors are shown by their interface name instead of an address
+func neighborLabels(name string, extra ...string) prometheus.Labels {
+	n := BgpNeighbor{IP: net.ParseIP(name)}
+	if n.IP == nil {
+		n.Interface = name
+	}
+	return n.labels(extra...)
+}
 
-func recordMetrics() {
+// addressFamilyNames : Returns the names of the address families of the neighbor, sorted and comma-separated
+func (n *BgpNeighbor) addressFamilyNames() string {
+	names := make([]string, 0, len(n.AddressFamilies))
+	for afi := range n.AddressFamilies {
+		names = append(names, afi)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// addressFamily : Returns the named address family of the neighbor, adding it if it was not seen yet
+func (n *BgpNeighbor) addressFamily(name string) *BgpAddressFamily {
+	afi := afiLabel(name)
+	af, ok := n.AddressFamilies[afi]
+	if !ok {
+		af = new(BgpAddressFamily)
+		n.AddressFamilies[afi] = af
+	}
+	return af
+}
+
+var shutdownTimeout = flag.Duration("web.shutdown-timeout", 10*time.Second, "How long in-flight requests are waited for when shutting down")
+var vtyshTimeout = flag.Duration("vtysh.timeout", 8*time.Second, "The timeout of a vtysh command")
+var vtyshRetries = flag.Int("vtysh.retries", 2, "The number of times a failed vtysh command is retried")
+var vtyshRetryBackoff = flag.Duration("vtysh.retry-backoff", 500*time.Millisecond, "The delay before the first retry of a failed vtysh command, doubled for every further retry")
+var aggregatePeerGroups = flag.Bool("aggregate.peer-groups", false, "Export metrics aggregated per peer group")
+var aggregateASNs = flag.Bool("aggregate.asns", false, "Export metrics aggregated per remote ASN")
+var allInstances = flag.Bool("collector.all-instances", false, "Collect the neighbors of all the BGP instances (views and VRFs), named by the view label, rather than of the default one only")
+var collectSummary = flag.Bool("collector.summary", true, "Export the prefixes received and sent per neighbor and address family, and the RIB entries and memory, from \"show bgp summary\"")
+var summaryAddressFamilies = flag.String("collector.summary.address-families", "", "The comma-separated address families whose summary is collected with \"show bgp <afi> <safi> summary\" rather than only IPv4 unicast, among ipv4_unicast, ipv6_unicast, ipv4_labeled_unicast, ipv6_labeled_unicast, ipv4_multicast and ipv6_multicast")
+var collectDampening = flag.Bool("collector.dampening", false, "Export the dampened and history paths, in total and per neighbor (requires route flap dampening)")
+var collectRpki = flag.Bool("collector.rpki", false, "Export the state of the RPKI cache servers and the ROA prefixes (requires bgpd to be started with the rpki module)")
+var collectRpkiPrefixes = flag.Bool("collector.rpki.prefixes", false, "With --collector.rpki, also export the prefixes of the BGP table by origin validation state, which lists the whole IPv4 and IPv6 unicast tables at each collection")
+var collectMemory = flag.Bool("collector.memory", false, "Export bgpd memory usage from \"show memory bgpd\" and the bgpd process metrics")
+var collectVpn = flag.Bool("collector.vpn", false, "Export the VPNv4/VPNv6 (L3VPN) address families: the summary per neighbor and the routes per route distinguisher")
+var collectFlowspec = flag.Bool("collector.flowspec", false, "Export the flowspec rules received per neighbor and installed")
+var collectEvpn = flag.Bool("collector.evpn", false, "Export the EVPN routes per route type, in total and per neighbor")
+var collectEvpnVni = flag.Bool("collector.evpn.vni", false, "Export the EVPN VNIs of zebra with their MACs, ARP/ND neighbors and remote VTEPs, from \"show evpn vni json\"")
+var collectPolicy = flag.Bool("collector.policy", false, "Export the prefixes denied by the inbound policy of the neighbors (with soft-reconfiguration inbound on FRR)")
+var collectSecurity = flag.Bool("collector.security", false, "Export whether TTL security (GTSM) and TCP authentication (MD5, TCP-AO) apply to each neighbor, from the configuration on FRR")
+var collectTCP = flag.Bool("collector.tcp", false, "Export the round trip time, retransmissions and send queue of the TCP connections of the neighbors, from \"ss\"")
+var collectNexthop = flag.Bool("collector.nexthop", false, "Export the validity, paths and resolving route of the nexthops of the BGP nexthop cache")
+var collectFrrInfo = flag.Bool("collector.frr-info", false, "Export the version of FRR and which of its daemons (bgpd, zebra, watchfrr...) run")
+var collectWatchfrr = flag.Bool("collector.watchfrr", false, "Export the state of the daemons supervised by watchfrr and count their restarts, from \"show watchfrr\"")
+var collectDefaultRoute = flag.Bool("collector.default-route", false, "Export whether the default route is received from and advertised to each neighbor")
+var stateSet = flag.Bool("metrics.state-set", false, "Export bgp_neighbor_state as a state set, with a state label and a series per state, rather than as the state number")
+var bgpdPidFile = flag.String("bgpd.pid-file", "/var/run/frr/bgpd.pid", "The pid file of bgpd, used for the bgpd process metrics")
+
+// afterCollection : Writes or pushes the collected metrics, depending on the output flags
+func afterCollection() {
+	if *textfilePath != "" {
+		writeTextfile()
+	}
+	if *pushURL != "" {
+		pushMetrics()
+	}
+	if *remoteWriteURL != "" {
+		remoteWrite()
+	}
+	if influxEnabled() {
+		writeInflux()
+	}
+}
+
+// recordMetrics : Starts collecting the metrics every 10 seconds, or at once when polled through
+// /-/poll, until the context is cancelled.
+// The returned channel is closed once the collection in progress, if any, has completed.
+// collected, if set, is called after each collection.
+func recordMetrics(ctx context.Context, collected func()) <-chan struct{} {
+	done := make(chan struct{})
+	collectionLoopTime.Store(time.Now().UnixNano())
 	go func() {
+		defer close(done)
+		var polls []chan struct{}
 		for {
-			o, _ := getBgpNeighbors()
-			parseBGP(o)
-
-			for _, n := range bgpNeighbors {
-				bgpNeighborState.With(prometheus.Labels{"ip": n.IP.String()}).Set(n.State)
-				bgpNeighborAcceptedPrefixes.With(prometheus.Labels{"ip": n.IP.String()}).Set(n.AcceptedPrefixes)
-				bgpNeighborConnectionsEstablished.With(prometheus.Labels{"ip": n.IP.String()}).Set(n.ConnectionsEstablished)
-				bgpNeighborConnectionsDropped.With(prometheus.Labels{"ip": n.IP.String()}).Set(n.ConnectionsDropped)
+			switch {
+			case multiRouter():
+				collectTargets(ctx)
+			case probesEnabled() && *probeOnly:
+				// Only the routers given to /probe are collected
+			case probesEnabled():
+				// The probes share the metrics, so the local router is collected aside as they are
+				collectLocal(ctx)
+			default:
+				newCollection(ctx, localTargetConfig, nil).collect()
+			}
+			collectionLoopTime.Store(time.Now().UnixNano())
+			if collected != nil {
+				collected()
+			}
+			for _, p := range polls {
+				close(p)
+			}
+			polls = nil
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Second):
+			case p := <-pollRequests:
+				polls = append(polls, p)
 			}
-			time.Sleep(10 * time.Second)
 		}
 	}()
+	return done
 }
 
-func getBgpNeighbors() (stdout string, stderr string) {
-	cmd := exec.Command("vtysh", "-c", "show ip bgp neighbors")
-	var sout, serr bytes.Buffer
-	cmd.Stdout = &sout
-	cmd.Stderr = &serr
-	err := cmd.Run()
+// collect : Runs all the enabled collectors once and updates the metrics
+func (c *collection) collect() {
+	c.runCollector("neighbors", recordNeighbors)
+	c.runCollectors()
+}
+
+// recordNeighbors : Collects the neighbors, keeping them in the store, and updates the per neighbor metrics.
+// When bgpd is unavailable (e.g. while it restarts) the metrics of the last successful collection keep being served.
+func recordNeighbors(c *collection) {
+	start := time.Now()
+	neighbors, err := c.collectNeighbors()
+	if err == errGNMIWaiting {
+		logger.Info("Waiting for the first gNMI update", "address", config.GNMI.Address)
+		return
+	} else if err != nil {
+		c.collectorFailed("neighbors", err)
+		if *auditFile != "" {
+			auditCollection(c.target, nil, nil, err)
+		}
+		return
+	}
+	if len(config.Neighbors.Expected) > 0 {
+		c.recordExpectedNeighbors(neighbors)
+	}
+	neighbors = filterNeighbors(neighbors)
+	lookupPeersDNS(neighbors)
+	previous := c.state.neighbors.Replace(neighbors)
+	c.state.neighbors.SetCollected(time.Now())
+	changes := stateChanges(c.target, previous, neighbors)
+	logStateChanges(changes)
+	c.recordHistory(changes)
+	events.publish(changes)
+	if *auditFile != "" {
+		auditCollection(c.target, neighbors, changes, nil)
+	}
+	if *snmpTrapReceiver != "" {
+		sendStateTraps(changes)
+	}
+	c.recordNeighborMetrics(c.limitNeighbors(c.state.neighbors.List()))
+	c.recordNeighborCountMetrics(neighbors)
+	notifyCollected(len(neighbors))
+	logger.Debug("Collected neighbors", "neighbors", len(neighbors), "duration", time.Since(start))
+}
+
+// collectNeighbors : Returns the neighbors, either streamed over gNMI, built from the messages of
+// ExaBGP, read from the northbound interface of FRR or parsed from "show ip bgp neighbors"
+func (c *collection) collectNeighbors() ([]BgpNeighbor, error) {
+	if config.GNMI.enabled() {
+		return gnmiNeighbors()
+	}
+	if exabgpEnabled() {
+		return exabgpNeighbors(), nil
+	}
+	if c.northbound.enabled() {
+		return northboundNeighbors(c.ctx, &c.northbound)
+	}
+	if calicoEnabled() {
+		return birdNeighbors()
+	}
+	if ciliumEnabled() {
+		return ciliumNeighbors()
+	}
+	return c.vtyshNeighbors()
+}
+
+// vtyshNeighbors : Returns the neighbors of the JSON output of FRR when supported, else parsed from the text
+// output of "show ip bgp neighbors", or from the summaries with --neighbors.detail-interval
+func (c *collection) vtyshNeighbors() ([]BgpNeighbor, error) {
+	if detailEnabled() {
+		return c.detailedNeighbors()
+	}
+	if c.useNeighborsJSON() {
+		var neighbors []BgpNeighbor
+		var parseErr error
+		err := c.vtyshStream(neighborsJSONCommand(), func(r io.Reader) {
+			neighbors, parseErr = parseNeighborsJSON(r)
+		})
+		if err != nil {
+			return nil, err
+		}
+		return neighbors, parseErr
+	}
+	var neighbors []BgpNeighbor
+	err := c.vtyshStream(c.neighborsCommand(), func(r io.Reader) {
+		neighbors = parseBGP(r, c.target)
+	})
 	if err != nil {
-		log.Fatalf("Failed to execute vtysh command: %s\n", err)
+		return nil, err
 	}
-	stdout, stderr = string(sout.Bytes()), string(serr.Bytes())
+	return neighbors, nil
+}
+
+// neighborsCommand : Returns the command listing the neighbors in text
+func (c *collection) neighborsCommand() string {
+	if *allInstances && !c.ios() {
+		return "show ip bgp view all neighbors"
+	}
+	return "show ip bgp neighbors"
+}
+
+// vtysh : Runs a show command through vtysh, retrying with an exponential backoff when it fails or times out
+func (c *collection) vtysh(command string) (stdout string, err error) {
+	err = c.vtyshStream(command, func(r io.Reader) {
+		b, _ := io.ReadAll(r)
+		stdout = string(b)
+	})
 	return
 }
 
-func parseBGP(s string) {
-	var bgpNeigh *BgpNeighbor
-	neigh := ""
-	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
-		check := bgpNeighborRegex.MatchString(line)
-		if check {
-			neigh = bgpNeighborRegex.FindStringSubmatch(line)[1]
-			bgpNeigh = new(BgpNeighbor)
-		}
-		if neigh != "" {
-			bgpNeigh.IP = net.ParseIP(neigh)
-
-			checkState := bgpStateRegex.MatchString(line)
-			if checkState {
-				/* References from: https://github.com/troglobit/quagga/blob/master/bgpd/BGP4-MIB.txt
-				Convert the state from string to int
-				idle(1),
-				connect(2),
-				active(3),
-				opensent(4),
-				openconfirm(5),
-				established(6)
-				*/
-				var state float64
-
-				switch bgpStateRegex.FindStringSubmatch(line)[1] {
-				case "Idle":
-					state = 1
-				case "Connect":
-					state = 2
-				case "Active":
-					state = 3
-				case "Opensent":
-					state = 4
-				case "Openconfirm":
-					state = 5
-				case "Established":
-					state = 6
-				}
-				bgpNeigh.State = state
-			}
-			checkPrefixes := bgpAcceptedPrefixesRegex.MatchString(line)
-			if checkPrefixes {
-				pref, _ := strconv.ParseFloat(bgpAcceptedPrefixesRegex.FindStringSubmatch(line)[1], 64)
-				bgpNeigh.AcceptedPrefixes = pref
-			}
-			checkConnections := bgpConnectionsEstablishedDroppedRegex.MatchString(line)
-			if checkConnections {
-				est, _ := strconv.ParseFloat(bgpConnectionsEstablishedDroppedRegex.FindStringSubmatch(line)[1], 64)
-				drp, _ := strconv.ParseFloat(bgpConnectionsEstablishedDroppedRegex.FindStringSubmatch(line)[2], 64)
-				bgpNeigh.ConnectionsEstablished = est
-				bgpNeigh.ConnectionsDropped = drp
-
-				var found bool = false
-				for i := range bgpNeighbors {
-					if bgpNeighbors[i].IP.String() == neigh {
-						found = true
-						bgpNeighbors[i] = *bgpNeigh
-					}
-				}
-				if !found {
-					bgpNeighbors = append(bgpNeighbors, *bgpNeigh)
-				}
+// vtyshStream : Runs a show command through vtysh as vtysh does, with its output parsed as it arrives rather
+// than once buffered, for the large outputs such as the neighbors of a route reflector. The output of
+// each attempt is parsed, so the parsing must start over when called again.
+func (c *collection) vtyshStream(command string, parse func(io.Reader)) (err error) {
+	backoff := *vtyshRetryBackoff
+	for attempt := 0; ; attempt++ {
+		pr, pw := io.Pipe()
+		parsed := make(chan struct{})
+		go func() {
+			defer close(parsed)
+			parse(pr)
+			// The rest of the output, for the command not to block
+			_, _ = io.Copy(io.Discard, pr)
+		}()
+		var stderr string
+		stderr, err = c.runVtyshTo(command, pw)
+		pw.Close()
+		<-parsed
+		if err == nil {
+			if stderr != "" {
+				recordError(c.target, strings.TrimSpace(stderr))
 			}
+			return
 		}
+		err = fmt.Errorf("failed to execute vtysh command %q: %s %s", command, err, strings.TrimSpace(stderr))
+		// No retry once the time allowed to collect the target is over
+		if attempt >= *vtyshRetries || c.ctx.Err() != nil {
+			return
+		}
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// runVtysh : Runs a show command through vtysh once, returning its whole output
+func (c *collection) runVtysh(command string) (stdout string, stderr string, err error) {
+	var out bytes.Buffer
+	stderr, err = c.runVtyshTo(command, &out)
+	return out.String(), stderr, err
+}
+
+// runVtyshTo : Runs a show command through vtysh once, writing its output as it arrives, and killing it if it
+// does not complete within the timeout
+func (c *collection) runVtyshTo(command string, stdout io.Writer) (stderr string, err error) {
+	ctx, cancel := context.WithTimeout(c.ctx, *vtyshTimeout)
+	defer cancel()
+	if c.ssh.enabled() {
+		return sshVtysh(ctx, &c.ssh, c.ios(), command, stdout)
+	}
+	if *vtySocket != "" {
+		err = runVty(ctx, *vtySocket, command, stdout)
+		if ctx.Err() == context.DeadlineExceeded || os.IsTimeout(err) {
+			err = fmt.Errorf("timed out after %s", *vtyshTimeout)
+		}
+		return
+	}
+	args := vtyshArgs(command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var serr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &serr
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", *vtyshTimeout)
+	}
+	return serr.String(), err
+}
+
+// sshVtysh : Runs a show command through vtysh on the remote host, or as it is on Cisco IOS which has no shell
+func sshVtysh(ctx context.Context, c *SSHConfig, ios bool, command string, stdout io.Writer) (stderr string, err error) {
+	// The local vtysh binary, its arguments and wrappers do not apply to the remote host
+	line := shellQuote([]string{"vtysh", "-c", command})
+	if ios {
+		line = command
+	}
+	stderr, err = runSSHTo(ctx, c, line, stdout)
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", *vtyshTimeout)
+	}
+	return
+}
+
+// bgpStateNames : The names of the BGP states, indexed by their value in bgp_neighbor_state
+var bgpStateNames = []string{"unknown", "idle", "connect", "active", "opensent", "openconfirm", "established", "clearing", "deleted"}
+
+// stateName : Returns the name of a BGP state value
+func stateName(state float64) string {
+	if state < 0 || int(state) >= len(bgpStateNames) {
+		return bgpStateNames[0]
+	}
+	return bgpStateNames[int(state)]
+}
+
+// boolToFloat converts a flag into a gauge value
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// afiLabel converts an address family name as printed by vtysh (e.g. "IPv4 Unicast")
+// into a label value (e.g. "ipv4_unicast")
+func afiLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), "_"))
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: %s [command] [flags]
+
+Commands:
+  check-config    Validate the configuration and that bgpd can be queried, then exit
+  dump            Collect the neighbors once and print them as parsed, as JSON
+  generate-rules  Print Prometheus recording and alerting rules for the exported metrics
+  metric-names    Print the legacy names of the metrics renamed with --metric.compliant-names and their new names
+  status          Print the neighbors of the exporter running at --address as a table
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+}
+
+// registerNeighborMetrics : Registers the per neighbor metrics set from the "show ip bgp neighbors" output
+func registerNeighborMetrics(r prometheus.Registerer) {
+	if *stateSet {
+		r.MustRegister(bgpNeighborStateSet)
+	} else {
+		r.MustRegister(bgpNeighborState)
 	}
+	r.MustRegister(bgpNeighborAcceptedPrefixes)
+	r.MustRegister(bgpNeighborConnectionsEstablished)
+	r.MustRegister(bgpNeighborConnectionsDropped)
+	r.MustRegister(bgpNeighborMaximumPrefixes)
+	r.MustRegister(bgpNeighborMaximumPrefixesThreshold)
+	r.MustRegister(bgpNeighborAdminShutdown)
+	r.MustRegister(bgpNeighborGracefulRestartCapability)
+	r.MustRegister(bgpNeighborGracefulRestartTimer)
+	r.MustRegister(bgpNeighborGracefulRestartRestarting)
+	r.MustRegister(bgpNeighborGracefulRestartPreserved)
+	r.MustRegister(bgpNeighborHoldTime)
+	r.MustRegister(bgpNeighborKeepaliveInterval)
+	r.MustRegister(bgpNeighborConfiguredHoldTime)
+	r.MustRegister(bgpNeighborConfiguredKeepaliveInterval)
+	r.MustRegister(bgpNeighborLastRead)
+	r.MustRegister(bgpNeighborLastWrite)
+	r.MustRegister(bgpNeighborHoldTimerRemaining)
+	r.MustRegister(bgpNeighborAdvertisementInterval)
+	r.MustRegister(bgpNeighborUpdateGroupInfo)
+	r.MustRegister(bgpNeighborPolicyInfo)
+	r.MustRegister(bgpNeighborConnectionInfo)
+	r.MustRegister(bgpNeighborInfo)
+	r.MustRegister(bgpNeighborBfdStatus)
+	r.MustRegister(bgpNeighborBfdDetectMultiplier)
+	r.MustRegister(bgpNeighborBfdMinRxInterval)
+	r.MustRegister(bgpNeighborBfdMinTxInterval)
 }
 
+// listenAddress : The address the metrics and the API are served on
+const listenAddress = ":9114"
+
 func main() {
-	prometheus.MustRegister(bgpNeighborState)
-	prometheus.MustRegister(bgpNeighborAcceptedPrefixes)
-	prometheus.MustRegister(bgpNeighborConnectionsEstablished)
-	prometheus.MustRegister(bgpNeighborConnectionsDropped)
-
-	recordMetrics()
-
-	http.Handle("/metrics", promhttp.Handler())
-
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte(`<html>
-             <head><title>BGP Exporter</title></head>
-             <body>
-             <h1>BGP Exporter</h1>
-             <p><a href='/metrics'>Metrics</a></p>
-             </body>
-             </html>`))
-	})
+	// The command, if any, comes before the flags, e.g. "bgp_exporter check-config --config.file=..."
+	command := ""
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flag.Usage = usage
+	_ = flag.CommandLine.Parse(args)
+	if err := setupLogging(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *showVersion {
+		fmt.Println(versionString())
+		return
+	}
+
+	switch command {
+	case "":
+	case "check-config":
+		if err := checkConfig(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	case "dump":
+		if err := loadAndSetConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		if err := dumpNeighbors(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	case "generate-rules":
+		if err := generateRules(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	case "metric-names":
+		if err := printMetricNames(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	case "status":
+		if err := printStatus(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n", command)
+		usage()
+		os.Exit(2)
+	}
+
+	if err := checkMetricPrefix(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := loadPollToken(); err != nil {
+		logger.Error("Failed to load the poll token", "err", err)
+		os.Exit(1)
+	}
+	if err := loadInfluxToken(); err != nil {
+		logger.Error("Failed to load the InfluxDB token", "err", err)
+		os.Exit(1)
+	}
+	if err := loadAndSetConfig(); err != nil {
+		logger.Error("Failed to load the configuration", "err", err)
+		os.Exit(1)
+	}
+	if err := checkPlatform(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := validateNeighborsJSON(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := validateSummaryAddressFamilies(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := parseShard(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := validateNeighborsDetail(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := validatePrivsep(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if shard.count > 0 && multiRouter() {
+		logger.Info("Collecting a shard of the targets", "shard", shard.index, "shards", shard.count)
+	}
+	if err := setupMetalLB(); err != nil {
+		logger.Error("Failed to find the FRR of the MetalLB speaker", "err", err)
+		os.Exit(1)
+	}
+
+	if *inputFile != "" {
+		if err := parseInputFile(*inputFile, os.Stdout); err != nil {
+			logger.Error("Failed to parse the input file", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	registerBuildInfo(prometheus.DefaultRegisterer)
+	// The per router metrics are tracked to be reset before collecting each target
+	tracker := &collectorTracker{Registerer: prometheus.DefaultRegisterer}
+	prometheus.DefaultRegisterer = tracker
+	// The metrics of the neighbors collector are tracked as well, for collect[] to select them
+	neighbors := &collectorTracker{Registerer: prometheus.DefaultRegisterer}
+	registerNeighborMetrics(neighbors)
+	neighbors.MustRegister(bgpNeighborsTotal)
+	neighbors.MustRegister(bgpNeighborsByState)
+	neighbors.MustRegister(bgpNeighborFlaps)
+	if exabgpEnabled() {
+		neighbors.MustRegister(bgpNeighborUpdateMessages)
+		neighbors.MustRegister(bgpNeighborUpdatePrefixes)
+	}
+	if config.Neighbors.Max > 0 {
+		neighbors.MustRegister(bgpNeighborsTruncated)
+	}
+	if len(config.Neighbors.Expected) > 0 {
+		neighbors.MustRegister(bgpNeighborExpectedMissing)
+	}
+	if len(config.Neighbors.PrefixRanges) > 0 {
+		neighbors.MustRegister(bgpNeighborAcceptedPrefixesOutOfRange)
+	}
+	neighborCollectorMetrics = neighbors.collectors
+	prometheus.MustRegister(bgpCollectorErrors)
+	registerCollectorMetrics(prometheus.DefaultRegisterer)
+	if *collectMemory {
+		prometheus.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{
+			PidFn:     pidFileFn(*bgpdPidFile),
+			Namespace: "bgpd",
+		}))
+	}
+	prometheus.DefaultRegisterer = tracker.Registerer
+	targetCollectors = tracker.collectors
+	prometheus.MustRegister(newFreshnessCollector())
+	if *stateFile != "" {
+		// A state which cannot be restored is only missed, as after a first start
+		if err := restoreState(); err != nil {
+			logger.Error("Failed to restore the state", "path", *stateFile, "err", err)
+		}
+	}
+
+	logger.Info("Starting bgp_exporter", "version", version, "commit", commit)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
-	http.ListenAndServe(":9114", nil)
+	// The ports are bound before the privileges are dropped, for those below 1024
+	var listener, grpcListener net.Listener
+	if *textfilePath == "" {
+		l, err := net.Listen("tcp", listenAddress)
+		if err != nil {
+			logger.Error("HTTP server failed", "err", err)
+			os.Exit(1)
+		}
+		listener = l
+		if *grpcListenAddress != "" {
+			if grpcListener, err = net.Listen("tcp", *grpcListenAddress); err != nil {
+				logger.Error("gRPC server failed", "err", err)
+				os.Exit(1)
+			}
+		}
+	}
+	if err := dropPrivileges(); err != nil {
+		logger.Error("Failed to drop the privileges", "user", *privsepUser, "err", err)
+		os.Exit(1)
+	}
+
+	if config.GNMI.enabled() {
+		go subscribeGNMI(ctx)
+	}
+	if *exabgpPipe != "" {
+		go readExabgpPipe(*exabgpPipe)
+	}
+	if *graphiteAddress != "" {
+		go pushGraphite(ctx)
+	}
+	if *statsdAddress != "" {
+		go pushStatsd(ctx)
+	}
+	if *remoteWriteURL != "" {
+		go sendRemoteWrite(ctx)
+	}
+	go petWatchdog(ctx)
+
+	if *textfilePath != "" {
+		// The metrics are only written to the file, without listening on a port
+		logger.Info("Writing metrics to textfile", "path", *textfilePath)
+		collecting := recordMetrics(ctx, afterCollection)
+		<-ctx.Done()
+		logger.Info("Shutting down")
+		sdNotify("STOPPING=1")
+		<-collecting
+		closeSSH()
+		closeNorthbound()
+		if *stateFile != "" {
+			persistState()
+		}
+		return
+	}
+
+	collecting := recordMetrics(ctx, afterCollection)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		http.HandlerFunc(metricsHandler),
+	))
+	mux.HandleFunc("/api/v1/errors", errorsHandler)
+	mux.HandleFunc("/api/v1/events", eventsHandler)
+	mux.HandleFunc("/api/v1/history", historyHandler)
+	mux.HandleFunc("/api/v1/neighbors", neighborsHandler)
+	mux.HandleFunc("/dashboard.json", dashboardHandler)
+	mux.HandleFunc("/sd", sdHandler)
+	mux.HandleFunc("/probe", probeHandler)
+	mux.HandleFunc("/-/poll", pollHandler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler)
+	if *exabgpHTTP {
+		mux.HandleFunc("/api/v1/exabgp", exabgpHandler)
+	}
+	if *enablePprof {
+		registerPprofHandlers(mux)
+	}
+
+	mux.HandleFunc("/", statusHandler)
+
+	server := &http.Server{Addr: listenAddress, Handler: mux}
+	// Event streams never become idle, so they have to be ended for the shutdown to complete
+	server.RegisterOnShutdown(events.close)
+	if probesEnabled() && *probeOnly {
+		// Without a collection of its own, the exporter is ready once it serves the probes
+		notifyReady()
+	}
+	go func() {
+		logger.Info("Listening", "address", server.Addr)
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			logger.Error("HTTP server failed", "err", err)
+			os.Exit(1)
+		}
+	}()
+	var grpcServer *grpc.Server
+	if grpcListener != nil {
+		grpcServer = newGRPCServer()
+		go func() {
+			logger.Info("Serving the gRPC API", "address", *grpcListenAddress)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				logger.Error("gRPC server failed", "err", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	logger.Info("Shutting down")
+	sdNotify("STOPPING=1")
+	stop()
+
+	// Metrics keep being served until the collection in progress has completed
+	<-collecting
+	closeSSH()
+	closeNorthbound()
+	if *stateFile != "" {
+		persistState()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if grpcServer != nil {
+		stopGRPCServer(shutdownCtx, grpcServer)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down the HTTP server gracefully", "err", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpdHeapBytes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_bgpd_heap_bytes",
+		Help: "The heap usage of bgpd as reported by the system allocator, by kind (e.g. total_heap_allocated, used_ordinary_blocks)",
+	},
+		[]string{
+			"kind",
+		})
+)
+
+var (
+	bgpdMemoryObjects = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_bgpd_memory_objects",
+		Help: "The number of objects of a given memory type currently allocated by bgpd (e.g. BGP route, Attribute)",
+	},
+		[]string{
+			"type",
+		})
+)
+
+var (
+	bgpdMemoryBytes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_bgpd_memory_bytes",
+		Help: "The memory currently allocated by bgpd for a given memory type, where the objects are of a fixed size",
+	},
+		[]string{
+			"type",
+		})
+)
+
+// BgpdMemoryType : This represents the allocations of a bgpd memory type
+type BgpdMemoryType struct {
+	Objects float64
+	Bytes   float64
+	Sized   bool
+}
+
+var bgpdHeapRegex = regexp.MustCompile(`^\s+([\w ]+):\s+(\d+) (bytes|KiB|MiB|GiB)\s*$`)
+var bgpdQmemSectionRegex = regexp.MustCompile(`^--- qmem (\S+) ---`)
+var bgpdQmemRegex = regexp.MustCompile(`^(\S.*?)\s+:\s+(\d+)\s+(?:(\d+)(?:\s+(\d+))?|\(variably sized\))`)
+
+func recordMemoryMetrics(c *collection) {
+	o, err := c.vtysh("show memory bgpd")
+	if err != nil {
+		c.collectorFailed("memory", err)
+		return
+	}
+	heap, types := parseMemoryStatistics(o)
+	for kind, bytes := range heap {
+		metric(c, bgpdHeapBytes).With(prometheus.Labels{"kind": kind}).Set(bytes)
+	}
+	for name, t := range types {
+		metric(c, bgpdMemoryObjects).With(prometheus.Labels{"type": name}).Set(t.Objects)
+		if t.Sized {
+			metric(c, bgpdMemoryBytes).With(prometheus.Labels{"type": name}).Set(t.Bytes)
+		}
+	}
+}
+
+// parseMemoryStatistics : Parses the allocator statistics and the bgpd memory types of "show memory bgpd"
+func parseMemoryStatistics(s string) (map[string]float64, map[string]*BgpdMemoryType) {
+	heap := make(map[string]float64)
+	types := make(map[string]*BgpdMemoryType)
+	section := ""
+	for _, line := range strings.Split(s, "\n") {
+		if m := bgpdQmemSectionRegex.FindStringSubmatch(line); m != nil {
+			section = m[1]
+			continue
+		}
+		if section == "" {
+			if m := bgpdHeapRegex.FindStringSubmatch(line); m != nil {
+				heap[afiLabel(m[1])] = parseMemory(m[2], m[3])
+			}
+			continue
+		}
+		// Only the memory types of bgpd itself are exported, not those of libfrr
+		if section != "bgpd" {
+			continue
+		}
+		if m := bgpdQmemRegex.FindStringSubmatch(line); m != nil {
+			t := new(BgpdMemoryType)
+			t.Objects, _ = strconv.ParseFloat(m[2], 64)
+			if m[3] != "" {
+				size, _ := strconv.ParseFloat(m[3], 64)
+				t.Bytes = t.Objects * size
+				t.Sized = true
+			}
+			// Newer versions print the total allocated size in the next column
+			if m[4] != "" {
+				t.Bytes, _ = strconv.ParseFloat(m[4], 64)
+			}
+			types[m[1]] = t
+		}
+	}
+	return heap, types
+}
+
+// pidFileFn : Returns a function reading the pid of a process from its pid file, for the process collector
+func pidFileFn(path string) func() (int, error) {
+	return func() (int, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return 0, fmt.Errorf("can't read pid file %q: %s", path, err)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+		if err != nil {
+			return 0, fmt.Errorf("can't parse pid file %q: %s", path, err)
+		}
+		return pid, nil
+	}
+}
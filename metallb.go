@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	metallbMode   = flag.Bool("metallb", false, "MetalLB (FRR mode) speaker integration: find the FRR container of the speaker pod, add the node label and export the advertisement of the LoadBalancer prefixes per neighbor")
+	metallbNode   = flag.String("metallb.node", os.Getenv("NODE_NAME"), "The node label added to all the metrics in MetalLB mode, usually set from the downward API (defaults to $NODE_NAME)")
+	metallbSocket = flag.String("metallb.socket-dir", "/var/run/frr", "The directory of the FRR sockets shared in the speaker pod, looked up in MetalLB mode")
+)
+
+var (
+	bgpMetallbPrefixAdvertised = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_metallb_prefix_advertised",
+		Help: "Whether a locally originated (LoadBalancer) prefix is advertised to a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"prefix",
+		})
+)
+
+// setupMetalLB : Finds how to reach the FRR of the speaker pod unless a backend is configured: the vty
+// socket of bgpd when the exporter runs as a container of the pod, or else the "frr" container
+func setupMetalLB() error {
+	if !*metallbMode || *vtySocket != "" || *dockerContainer != "" || config.SSH.enabled() {
+		return nil
+	}
+	socket := strings.TrimSuffix(*metallbSocket, "/") + "/bgpd.vty"
+	if _, err := os.Stat(socket); err == nil {
+		*vtySocket = socket
+		logger.Info("Using the vty socket of the speaker pod", "socket", socket)
+		return nil
+	}
+	out, err := exec.Command(*dockerBinary, "ps", "-q",
+		"--filter", "label=io.kubernetes.container.name=frr",
+		"--filter", "label=app.kubernetes.io/component=speaker").Output()
+	if err != nil {
+		return fmt.Errorf("metallb: no vty socket at %s and failed to list the containers: %s", socket, err)
+	}
+	containers := strings.Fields(string(out))
+	if len(containers) != 1 {
+		return fmt.Errorf("metallb: no vty socket at %s and %d frr containers of the speaker found", socket, len(containers))
+	}
+	*dockerContainer = containers[0]
+	logger.Info("Using the frr container of the speaker pod", "container", containers[0])
+	return nil
+}
+
+// recordMetallbMetrics : Exports, for every established neighbor, which of the locally originated
+// prefixes (the LoadBalancer addresses announced by the speaker) are advertised to it
+func recordMetallbMetrics(c *collection) {
+	metric(c, bgpMetallbPrefixAdvertised).Reset()
+	for _, afi := range []string{"ipv4", "ipv6"} {
+		o, err := c.vtysh("show bgp " + afi + " unicast")
+		if err != nil {
+			c.collectorFailed("metallb", err)
+			continue
+		}
+		var local []string
+		for _, r := range parseRoutes(o) {
+			if r.NextHop == "0.0.0.0" || r.NextHop == "::" {
+				local = append(local, r.Prefix)
+			}
+		}
+		if len(local) == 0 {
+			continue
+		}
+		for _, n := range c.exportedNeighbors() {
+			if n.State != 6 {
+				continue
+			}
+			command := "show bgp " + afi + " unicast neighbors " + n.key() + " advertised-routes"
+			if n.Vrf != "" {
+				command = "show bgp vrf " + n.Vrf + " " + afi + " unicast neighbors " + n.key() + " advertised-routes"
+			}
+			o, err := c.vtysh(command)
+			if err != nil {
+				c.collectorFailed("metallb", err)
+				continue
+			}
+			advertised := make(map[string]bool)
+			for _, r := range parseRoutes(o) {
+				advertised[r.Prefix] = true
+			}
+			for _, prefix := range local {
+				metric(c, bgpMetallbPrefixAdvertised).With(n.labels("prefix", prefix)).Set(boolToFloat(advertised[prefix]))
+			}
+		}
+	}
+}
+
+// BgpRoute : This represents a path of a BGP table
+type BgpRoute struct {
+	Prefix  string
+	NextHop string
+}
+
+// parseRoutes : Parses the paths of a BGP table such as "show bgp ipv4 unicast". The network is only
+// printed on the first path of a prefix, and long networks push the next hop to the following line.
+func parseRoutes(s string) []BgpRoute {
+	var routes []BgpRoute
+	var prefix string
+	pending := false
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if pending && len(fields) > 0 {
+			routes[len(routes)-1].NextHop = fields[0]
+			pending = false
+			continue
+		}
+		if len(line) < 4 || !strings.Contains(line[:3], "*") {
+			continue
+		}
+		fields = strings.Fields(line[3:])
+		if line[3] != ' ' && strings.Contains(fields[0], "/") {
+			prefix = fields[0]
+			fields = fields[1:]
+		}
+		r := BgpRoute{Prefix: prefix}
+		if len(fields) > 0 {
+			r.NextHop = fields[0]
+		} else {
+			pending = true
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// metallbGatherer : Returns a gatherer adding the node label to all the metrics in MetalLB mode
+func metallbGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if !*metallbMode || *metallbNode == "" {
+		return g
+	}
+	name := "node"
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		for _, mf := range mfs {
+			for _, m := range mf.Metric {
+				m.Label = append(m.Label, &dto.LabelPair{Name: &name, Value: metallbNode})
+				sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+			}
+		}
+		return mfs, err
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var metricPrefix = flag.String("metric.prefix", "bgp_", "The prefix of the exported metric names, replacing \"bgp_\" (e.g. \"frr_bgp_\")")
+
+var metricPrefixRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// checkMetricPrefix : Returns an error if the metric prefix would give invalid metric names
+func checkMetricPrefix() error {
+	if !metricPrefixRegex.MatchString(*metricPrefix) {
+		return fmt.Errorf("invalid metric prefix %q", *metricPrefix)
+	}
+	return nil
+}
+
+// prefixedGatherer : Returns a gatherer renaming the metrics of the exporter from the "bgp_" prefix to the configured one
+func prefixedGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if *metricPrefix == "bgp_" {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		for _, mf := range mfs {
+			if strings.HasPrefix(mf.GetName(), "bgp_") {
+				name := *metricPrefix + strings.TrimPrefix(mf.GetName(), "bgp_")
+				mf.Name = &name
+			}
+		}
+		return mfs, err
+	})
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var compliantNames = flag.Bool("metric.compliant-names", false, "Export the metrics under names and types following the Prometheus naming practices (counters ending with _total, unit suffixes) instead of the legacy ones, see the metric-names command")
+
+// CompliantName : This represents how a legacy metric is exported with --metric.compliant-names
+type CompliantName struct {
+	Name    string
+	Counter bool
+	Scale   float64
+	Help    string
+}
+
+// compliantMetricNames : The metrics renamed with --metric.compliant-names, by legacy name. The others are
+// exported unchanged.
+//
+//	bgp_neighbor_connections_established    -> bgp_neighbor_connections_established_total
+//	bgp_neighbor_connections_dropped        -> bgp_neighbor_connections_dropped_total
+//	bgp_neighbor_tcp_retransmitted_segments -> bgp_neighbor_tcp_retransmitted_segments_total (counter)
+//	bgp_neighbor_output_queue               -> bgp_neighbor_output_queue_messages
+//	bgp_neighbor_maximum_prefixes_threshold -> bgp_neighbor_maximum_prefixes_threshold_ratio (0-1 rather than percent)
+//	bgp_neighbors_total                     -> bgp_neighbors (a gauge, not a counter)
+var compliantMetricNames = map[string]CompliantName{
+	"bgp_neighbor_connections_established":    {Name: "bgp_neighbor_connections_established_total"},
+	"bgp_neighbor_connections_dropped":        {Name: "bgp_neighbor_connections_dropped_total"},
+	"bgp_neighbor_tcp_retransmitted_segments": {Name: "bgp_neighbor_tcp_retransmitted_segments_total", Counter: true},
+	"bgp_neighbor_output_queue":               {Name: "bgp_neighbor_output_queue_messages"},
+	"bgp_neighbor_maximum_prefixes_threshold": {
+		Name:  "bgp_neighbor_maximum_prefixes_threshold_ratio",
+		Scale: 0.01,
+		Help:  "The configured maximum prefix warning threshold (ratio of the maximum) for a given BGP neighbor and address family",
+	},
+	"bgp_neighbors_total": {Name: "bgp_neighbors"},
+}
+
+// metricName : Returns the exported name of a metric given without its "bgp_" prefix, e.g. "neighbor_state",
+// for the rules and dashboard to match the current flags
+func metricName(name string) string {
+	if c, ok := compliantMetricNames["bgp_"+name]; ok && *compliantNames {
+		name = strings.TrimPrefix(c.Name, "bgp_")
+	}
+	return *metricPrefix + name
+}
+
+// compliantGatherer : Returns a gatherer renaming the legacy metrics, and turning into counters those which
+// count since the session or the connection was established
+func compliantGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if !*compliantNames {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		for _, mf := range mfs {
+			c, ok := compliantMetricNames[mf.GetName()]
+			if !ok {
+				continue
+			}
+			name := c.Name
+			mf.Name = &name
+			if c.Help != "" {
+				help := c.Help
+				mf.Help = &help
+			}
+			for _, m := range mf.Metric {
+				if c.Scale != 0 && m.Gauge != nil {
+					m.Gauge.Value = float64Ptr(m.Gauge.GetValue() * c.Scale)
+				}
+				if c.Counter && m.Gauge != nil {
+					m.Counter = &dto.Counter{Value: m.Gauge.Value}
+					m.Gauge = nil
+				}
+			}
+			if c.Counter {
+				mf.Type = dto.MetricType_COUNTER.Enum()
+			}
+		}
+		return mfs, err
+	})
+}
+
+// printMetricNames : Prints the legacy names of the renamed metrics and their names with
+// --metric.compliant-names, with the configured prefix, for the queries to be migrated
+func printMetricNames(w io.Writer) error {
+	if err := checkMetricPrefix(); err != nil {
+		return err
+	}
+	var legacy []string
+	for name := range compliantMetricNames {
+		legacy = append(legacy, name)
+	}
+	sort.Strings(legacy)
+	for _, name := range legacy {
+		c := compliantMetricNames[name]
+		notes := ""
+		if c.Counter {
+			notes = " (counter)"
+		}
+		if c.Scale != 0 {
+			notes += fmt.Sprintf(" (value multiplied by %g)", c.Scale)
+		}
+		fmt.Fprintf(w, "%s %s%s\n", *metricPrefix+strings.TrimPrefix(name, "bgp_"), *metricPrefix+strings.TrimPrefix(c.Name, "bgp_"), notes)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborsTotal = newGauge(prometheus.GaugeOpts{
+		Name: "bgp_neighbors_total",
+		Help: "The number of BGP neighbors",
+	})
+)
+
+var (
+	bgpNeighborsByState = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbors_by_state",
+		Help: "The number of BGP neighbors in a given state",
+	},
+		[]string{
+			"state",
+		})
+)
+
+// recordNeighborCountMetrics : Exports the number of neighbors, in total and by state, for the panels
+// of the sessions down not to aggregate the per neighbor series. All the states are exported, those
+// without neighbors with 0, and the truncated neighbors are counted.
+func (c *collection) recordNeighborCountMetrics(neighbors []BgpNeighbor) {
+	counts := make([]float64, len(bgpStateNames))
+	for _, n := range neighbors {
+		if state := int(n.State); state > 0 && state < len(bgpStateNames) {
+			counts[state]++
+		}
+	}
+	metric(c, bgpNeighborsTotal).Set(float64(len(neighbors)))
+	for state := 1; state < len(bgpStateNames); state++ {
+		metric(c, bgpNeighborsByState).With(prometheus.Labels{"state": bgpStateNames[state]}).Set(counts[state])
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var neighborsJSON = flag.String("vtysh.neighbors-json", "auto", "Collect the neighbors with \"show bgp [vrf all] neighbors json\" rather than parsing \"show ip bgp [view all] neighbors\": auto (when \"show version\" gives FRR 7.0 or later), always or never")
+
+// neighborsJSONMinVersion : The first version of FRR whose JSON neighbors are complete enough, with the
+// timers in milliseconds and the per address family counters
+var neighborsJSONMinVersion = []int{7, 0}
+
+// neighborsJSONSupport : Whether each router (by target name) supports the JSON neighbors, as detected
+// from its version the first time it is collected
+var neighborsJSONSupport = struct {
+	sync.Mutex
+	targets map[string]bool
+}{targets: make(map[string]bool)}
+
+func validateNeighborsJSON() error {
+	switch *neighborsJSON {
+	case "auto", "always", "never":
+		return nil
+	}
+	return fmt.Errorf("invalid --vtysh.neighbors-json %q: must be auto, always or never", *neighborsJSON)
+}
+
+// useNeighborsJSON : Whether the neighbors of the router are collected as JSON
+func (c *collection) useNeighborsJSON() bool {
+	if c.ios() {
+		return false
+	}
+	switch *neighborsJSON {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	neighborsJSONSupport.Lock()
+	supported, ok := neighborsJSONSupport.targets[c.target]
+	neighborsJSONSupport.Unlock()
+	if ok {
+		return supported
+	}
+	// The lock is not held while the version is asked for, not to wait for the other targets
+	o, err := c.vtysh("show version")
+	if err != nil {
+		// Detected again at the next collection
+		logger.Warn("Failed to get the FRR version, parsing the neighbors as text", "target", c.target, "err", err)
+		return false
+	}
+	product, version := parseFrrVersion(o)
+	supported = product == "frrouting" && versionAtLeast(version, neighborsJSONMinVersion)
+	neighborsJSONSupport.Lock()
+	neighborsJSONSupport.targets[c.target] = supported
+	neighborsJSONSupport.Unlock()
+	logger.Debug("Detected the FRR version", "target", c.target, "product", product, "version", version, "json", supported)
+	return supported
+}
+
+// versionAtLeast : Whether the dotted version, e.g. "8.4.2" or "7.5-dev", is at least the given one
+func versionAtLeast(version string, min []int) bool {
+	parts := strings.Split(version, ".")
+	for i, m := range min {
+		if i >= len(parts) {
+			return false
+		}
+		digits := strings.TrimRightFunc(parts[i], func(r rune) bool { return r < '0' || r > '9' })
+		v, err := strconv.Atoi(digits)
+		if err != nil {
+			return false
+		}
+		if v != m {
+			return v > m
+		}
+	}
+	return true
+}
+
+// neighborsJSONCommand : Returns the command listing the neighbors of all the address families as JSON,
+// and of all the views and VRFs with --collector.all-instances, in a single vtysh run
+func neighborsJSONCommand() string {
+	if *allInstances {
+		return "show bgp vrf all neighbors json"
+	}
+	return "show bgp neighbors json"
+}
+
+// JSONNeighbor : This represents a neighbor of "show bgp neighbors json"
+type JSONNeighbor struct {
+	RemoteAS                 json.Number                    `json:"remoteAs"`
+	InternalLink             bool                           `json:"nbrInternalLink"`
+	ExternalLink             bool                           `json:"nbrExternalLink"`
+	Description              string                         `json:"nbrDesc"`
+	Hostname                 string                         `json:"hostname"`
+	PeerGroup                string                         `json:"peerGroup"`
+	NeighborAddress          string                         `json:"bgpNeighborAddr"`
+	State                    string                         `json:"bgpState"`
+	UpMsec                   float64                        `json:"bgpTimerUpMsec"`
+	LastResetDueTo           string                         `json:"lastResetDueTo"`
+	AdminShutdown            bool                           `json:"adminShutDown"`
+	HoldTimeMsecs            float64                        `json:"bgpTimerHoldTimeMsecs"`
+	KeepaliveMsecs           float64                        `json:"bgpTimerKeepAliveIntervalMsecs"`
+	ConfiguredHoldTimeMsecs  float64                        `json:"bgpTimerConfiguredHoldTimeMsecs"`
+	ConfiguredKeepaliveMsecs float64                        `json:"bgpTimerConfiguredKeepAliveIntervalMsecs"`
+	LastRead                 *float64                       `json:"bgpTimerLastRead"`
+	LastWrite                *float64                       `json:"bgpTimerLastWrite"`
+	AdvertisementRunsMsecs   *float64                       `json:"minBtwnAdvertisementRunsTimerMsecs"`
+	ConnectionsEstablished   float64                        `json:"connectionsEstablished"`
+	ConnectionsDropped       float64                        `json:"connectionsDropped"`
+	HostLocal                string                         `json:"hostLocal"`
+	PortLocal                json.Number                    `json:"portLocal"`
+	HostForeign              string                         `json:"hostForeign"`
+	PortForeign              json.Number                    `json:"portForeign"`
+	UpdateSource             string                         `json:"updateSource"`
+	MaxHopsAway              json.Number                    `json:"externalBgpNbrMaxHopsAway"`
+	Capabilities             JSONNeighborCapabilities       `json:"neighborCapabilities"`
+	GracefulRestart          JSONGracefulRestart            `json:"gracefulRestartInfo"`
+	Bfd                      *JSONBfd                       `json:"peerBfdInfo"`
+	AddressFamilies          map[string]JSONNeighborAddress `json:"addressFamilyInfo"`
+}
+
+// JSONNeighborCapabilities : This represents the capabilities of a neighbor, e.g. "advertisedAndReceived"
+type JSONNeighborCapabilities struct {
+	GracefulRestart string `json:"gracefulRestart"`
+}
+
+// JSONGracefulRestart : This represents the graceful restart information of a neighbor, with the
+// forwarding state of its address families, e.g. "ipv4Unicast": {"fBit": true}
+type JSONGracefulRestart struct {
+	Timers struct {
+		ReceivedRestartTimer float64 `json:"receivedRestartTimer"`
+	} `json:"timers"`
+	AddressFamilies map[string]JSONGracefulRestartFamily `json:"-"`
+}
+
+// JSONGracefulRestartFamily : This represents whether the neighbor preserved the forwarding state of an
+// address family
+type JSONGracefulRestartFamily struct {
+	FBit *bool `json:"fBit"`
+}
+
+func (g *JSONGracefulRestart) UnmarshalJSON(b []byte) error {
+	type timers JSONGracefulRestart
+	if err := json.Unmarshal(b, (*timers)(g)); err != nil {
+		return err
+	}
+	var families map[string]json.RawMessage
+	if err := json.Unmarshal(b, &families); err != nil {
+		return err
+	}
+	g.AddressFamilies = make(map[string]JSONGracefulRestartFamily)
+	for name, value := range families {
+		if _, ok := jsonAddressFamilies[name]; !ok {
+			continue
+		}
+		var af JSONGracefulRestartFamily
+		if err := json.Unmarshal(value, &af); err != nil {
+			return err
+		}
+		g.AddressFamilies[name] = af
+	}
+	return nil
+}
+
+// JSONBfd : This represents the BFD session of a neighbor, with its intervals in milliseconds
+type JSONBfd struct {
+	Type             string  `json:"type"`
+	DetectMultiplier float64 `json:"detectMultiplier"`
+	RxMinInterval    float64 `json:"rxMinInterval"`
+	TxMinInterval    float64 `json:"txMinInterval"`
+	Status           string  `json:"status"`
+}
+
+// JSONNeighborAddress : This represents an address family of a neighbor
+type JSONNeighborAddress struct {
+	UpdateGroupID        json.Number `json:"updateGroupId"`
+	SubGroupID           json.Number `json:"subGroupId"`
+	RouteReflectorClient bool        `json:"routeReflectorClient"`
+	SoftReconfigInbound  bool        `json:"inboundSoftConfigPermit"`
+	InboundRouteMap      string      `json:"routeMapForIncomingAdvertisements"`
+	InboundPrefixList    string      `json:"incomingUpdatePrefixFilterList"`
+	OutboundRouteMap     string      `json:"routeMapForOutgoingAdvertisements"`
+	OutboundPrefixList   string      `json:"outgoingUpdatePrefixFilterList"`
+	AcceptedPrefixes     float64     `json:"acceptedPrefixCounter"`
+	MaximumPrefixes      float64     `json:"prefixAllowedMax"`
+	MaximumPercent       float64     `json:"prefixAllowedMaxPercent"`
+}
+
+// jsonAddressFamilies : The address families as named in the JSON, e.g. "ipv4Unicast", by their label
+// as named in the text output, e.g. "ipv4_unicast" for "IPv4 Unicast"
+var jsonAddressFamilies = map[string]string{
+	"ipv4Unicast":        "ipv4_unicast",
+	"ipv4Multicast":      "ipv4_multicast",
+	"ipv4LabeledUnicast": "ipv4_labeled-unicast",
+	"ipv4Vpn":            "vpnv4_unicast",
+	"ipv4Flowspec":       "ipv4_flowspec",
+	"ipv6Unicast":        "ipv6_unicast",
+	"ipv6Multicast":      "ipv6_multicast",
+	"ipv6LabeledUnicast": "ipv6_labeled-unicast",
+	"ipv6Vpn":            "vpnv6_unicast",
+	"ipv6Flowspec":       "ipv6_flowspec",
+	"l2VpnEvpn":          "l2vpn_evpn",
+}
+
+// jsonBfdStatus : The BFD statuses in lower case, numbered as bgp_neighbor_bfd_status (RFC 5880)
+var jsonBfdStatus = map[string]float64{"admindown": 0, "down": 1, "init": 2, "up": 3}
+
+// parseNeighborsJSON : Parses "show bgp neighbors json", whose neighbors are keyed by address (or interface
+// for unnumbered neighbors), or "show bgp vrf all neighbors json", where they are grouped by VRF
+func parseNeighborsJSON(r io.Reader) ([]BgpNeighbor, error) {
+	var top map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&top); err != nil {
+		return nil, fmt.Errorf("failed to parse the JSON neighbors: %s", err)
+	}
+	var neighbors []BgpNeighbor
+	// With "vrf all" the neighbors are keyed by VRF, some of which may have none
+	vrfs := false
+	for key, value := range top {
+		var vrf struct {
+			Name *string `json:"vrfName"`
+		}
+		if err := json.Unmarshal(value, &vrf); err == nil && vrf.Name != nil {
+			vrfs = true
+			var peers map[string]json.RawMessage
+			if err := json.Unmarshal(value, &peers); err != nil {
+				return nil, fmt.Errorf("failed to parse the JSON neighbors of %s: %s", key, err)
+			}
+			name := *vrf.Name
+			if name == "default" {
+				name = ""
+			}
+			parsed, err := parseJSONPeers(peers, name)
+			if err != nil {
+				return nil, err
+			}
+			neighbors = append(neighbors, parsed...)
+		}
+	}
+	if vrfs {
+		return neighbors, nil
+	}
+	return parseJSONPeers(top, "")
+}
+
+// parseJSONPeers : Converts the neighbors of a VRF, skipping its other keys such as "vrfId"
+func parseJSONPeers(peers map[string]json.RawMessage, vrf string) ([]BgpNeighbor, error) {
+	var neighbors []BgpNeighbor
+	for key, value := range peers {
+		if key == "vrfId" || key == "vrfName" || len(value) == 0 || value[0] != '{' {
+			continue
+		}
+		var j JSONNeighbor
+		if err := json.Unmarshal(value, &j); err != nil {
+			return nil, fmt.Errorf("failed to parse the JSON neighbor %s: %s", key, err)
+		}
+		neighbors = append(neighbors, j.neighbor(key, vrf))
+	}
+	return neighbors, nil
+}
+
+// neighbor : Converts the neighbor, with the same values as parsed from the text output
+func (j *JSONNeighbor) neighbor(key string, vrf string) BgpNeighbor {
+	n := BgpNeighbor{Vrf: vrf, AddressFamilies: make(map[string]*BgpAddressFamily)}
+	if ip := net.ParseIP(key); ip != nil {
+		n.IP = ip
+	} else {
+		n.Interface = key
+		n.IP = net.ParseIP(j.NeighborAddress)
+	}
+	n.RemoteAS = j.RemoteAS.String()
+	if j.InternalLink {
+		n.Type = "ibgp"
+	} else if j.ExternalLink {
+		n.Type = "ebgp"
+	}
+	n.Description, n.Hostname, n.PeerGroup = j.Description, j.Hostname, j.PeerGroup
+	n.State = (&bgpParser{neigh: &n}).parseState(j.State)
+	if n.State == 6 {
+		n.Uptime = j.UpMsec / 1000
+	}
+	n.LastResetReason = j.LastResetDueTo
+	n.AdminShutdown = j.AdminShutdown && n.State != 6
+	n.ConnectionsEstablished, n.ConnectionsDropped = j.ConnectionsEstablished, j.ConnectionsDropped
+	n.HoldTime, n.KeepaliveInterval = j.HoldTimeMsecs/1000, j.KeepaliveMsecs/1000
+	n.ConfiguredHoldTime, n.ConfiguredKeepalive = j.ConfiguredHoldTimeMsecs/1000, j.ConfiguredKeepaliveMsecs/1000
+	if j.LastRead != nil && j.LastWrite != nil {
+		n.LastRead, n.LastWrite, n.HasLastRead = *j.LastRead/1000, *j.LastWrite/1000, true
+	}
+	if j.AdvertisementRunsMsecs != nil {
+		n.AdvertisementInterval, n.HasAdvertisementInterval = *j.AdvertisementRunsMsecs/1000, true
+	}
+	n.LocalHost, n.LocalPort = j.HostLocal, j.PortLocal.String()
+	n.ForeignHost, n.ForeignPort = j.HostForeign, j.PortForeign.String()
+	n.UpdateSource = j.UpdateSource
+	n.MultihopTTL = j.MaxHopsAway.String()
+	gr := strings.ToLower(j.Capabilities.GracefulRestart)
+	n.GRAdvertised = strings.Contains(gr, "advertised")
+	n.GRReceived = strings.Contains(gr, "received")
+	n.GRRestartTimer = j.GracefulRestart.Timers.ReceivedRestartTimer
+	if j.Bfd != nil {
+		n.BfdType = j.Bfd.Type
+		n.BfdStatus = -1
+		if status, ok := jsonBfdStatus[strings.ToLower(j.Bfd.Status)]; ok {
+			n.BfdStatus = status
+		}
+		n.BfdDetectMultiplier = j.Bfd.DetectMultiplier
+		n.BfdMinRxInterval, n.BfdMinTxInterval = j.Bfd.RxMinInterval/1000, j.Bfd.TxMinInterval/1000
+	}
+	for name, a := range j.AddressFamilies {
+		afi, ok := jsonAddressFamilies[name]
+		if !ok {
+			afi = strings.ToLower(name)
+		}
+		n.AddressFamilies[afi] = &BgpAddressFamily{
+			MaximumPrefixes:          a.MaximumPrefixes,
+			MaximumPrefixesThreshold: a.MaximumPercent,
+			UpdateGroup:              a.UpdateGroupID.String(),
+			UpdateSubgroup:           a.SubGroupID.String(),
+			AcceptedPrefixes:         a.AcceptedPrefixes,
+			SoftReconfigInbound:      a.SoftReconfigInbound,
+			InboundRouteMap:          a.InboundRouteMap,
+			InboundPrefixList:        a.InboundPrefixList,
+			OutboundRouteMap:         a.OutboundRouteMap,
+			OutboundPrefixList:       a.OutboundPrefixList,
+		}
+		n.AcceptedPrefixes += a.AcceptedPrefixes
+		n.RouteReflectorClient = n.RouteReflectorClient || a.RouteReflectorClient
+	}
+	for name, gr := range j.GracefulRestart.AddressFamilies {
+		if gr.FBit != nil {
+			af := n.addressFamily(jsonAddressFamilies[name])
+			af.GracefulRestart = true
+			af.GRForwardingPreserved = *gr.FBit
+		}
+	}
+	return n
+}
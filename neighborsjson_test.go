@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func parseJSONFile(t *testing.T, path string) []BgpNeighbor {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	neighbors, err := parseNeighborsJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The neighbors are keyed in the JSON output, so they come in no particular order
+	sort.Slice(neighbors, func(i, j int) bool { return storeKey(neighbors[i]) < storeKey(neighbors[j]) })
+	return neighbors
+}
+
+// TestParseNeighborsJSONMatchesText : Checks that the JSON output of a router gives the same neighbors as
+// its text output, but for what is not read from the JSON output
+func TestParseNeighborsJSONMatchesText(t *testing.T) {
+	tests := []struct {
+		text string
+		json string
+	}{
+		{"testdata/frr/show_ip_bgp_neighbors.txt", "testdata/frr/show_bgp_neighbors_json.txt"},
+		{"testdata/frr/show_ip_bgp_neighbors_opening.txt", "testdata/frr/show_bgp_neighbors_opening_json.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			text := parseFile(t, tt.text)
+			sort.Slice(text, func(i, j int) bool { return storeKey(text[i]) < storeKey(text[j]) })
+			// The shutdown message is only read from the text output
+			for i := range text {
+				text[i].ShutdownMessage = ""
+			}
+			neighbors := parseJSONFile(t, tt.json)
+			if len(neighbors) != len(text) {
+				t.Fatalf("got %d neighbors, %d from the text output", len(neighbors), len(text))
+			}
+			for i := range text {
+				if !reflect.DeepEqual(neighbors[i], text[i]) {
+					got, _ := json.Marshal(neighbors[i])
+					want, _ := json.Marshal(text[i])
+					t.Errorf("neighbor %s:\ngot  %s\nwant %s", text[i].key(), got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNeighborsJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		// want : The VRF, neighbor and state of the neighbors, ordered by VRF and neighbor
+		want []string
+	}{
+		{
+			name: "vrf all",
+			json: "testdata/frr/show_bgp_vrf_all_neighbors_json.txt",
+			want: []string{"/10.0.0.1/established", "red/10.1.0.1/idle"},
+		},
+		{
+			name: "opening",
+			json: "testdata/frr/show_bgp_neighbors_opening_json.txt",
+			want: []string{"/10.0.0.7/opensent", "/10.0.0.8/openconfirm"},
+		},
+		{
+			name: "no neighbors",
+			json: `{}`,
+		},
+		{
+			name: "vrf all without peers",
+			json: `{"default": {"vrfId": 0, "vrfName": "default"}, "red": {"vrfId": 5, "vrfName": "red"}}`,
+		},
+		{
+			name: "vrf all with a vrf without peers",
+			json: `{"default": {"vrfId": 0, "vrfName": "default"}, "red": {"vrfId": 5, "vrfName": "red", "10.1.0.1": {"remoteAs": 65005, "bgpState": "Active"}}}`,
+			want: []string{"red/10.1.0.1/active"},
+		},
+		{
+			name: "unnumbered",
+			json: `{"swp1": {"remoteAs": 65101, "bgpNeighborAddr": "fe80::1", "bgpState": "Connect"}}`,
+			want: []string{"/swp1/connect"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var neighbors []BgpNeighbor
+			if strings.HasPrefix(tt.json, "testdata/") {
+				neighbors = parseJSONFile(t, tt.json)
+			} else {
+				var err error
+				if neighbors, err = parseNeighborsJSON(strings.NewReader(tt.json)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got []string
+			for _, n := range neighbors {
+				got = append(got, n.Vrf+"/"+n.key()+"/"+stateName(n.State))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
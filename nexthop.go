@@ -0,0 +1,129 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNexthopValid = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_nexthop_valid",
+		Help: "Whether a given BGP nexthop is resolved through the RIB (1=valid,0=invalid)",
+	},
+		[]string{
+			"nexthop",
+		})
+)
+
+var (
+	bgpNexthopPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_nexthop_paths",
+		Help: "The number of paths using a given BGP nexthop",
+	},
+		[]string{
+			"nexthop",
+		})
+)
+
+var (
+	bgpNexthopIgpMetric = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_nexthop_igp_metric",
+		Help: "The IGP metric of the route resolving a given valid BGP nexthop",
+	},
+		[]string{
+			"nexthop",
+		})
+)
+
+var (
+	bgpNexthopResolvingNexthops = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_nexthop_resolving_nexthops",
+		Help: "The number of nexthops (gateways or interfaces) of the route resolving a given BGP nexthop",
+	},
+		[]string{
+			"nexthop",
+		})
+)
+
+var (
+	bgpNexthopResolvedInfo = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_nexthop_resolved_info",
+		Help: "A metric with a constant '1' value labeled by the prefix of the route resolving a given valid BGP nexthop, where reported by the router",
+	},
+		[]string{
+			"nexthop",
+			"prefix",
+		})
+)
+
+// BgpNexthop : This represents a nexthop of the BGP nexthop cache
+type BgpNexthop struct {
+	Address        string
+	Valid          bool
+	IgpMetric      float64
+	Paths          float64
+	ResolvedPrefix string
+	Nexthops       float64
+}
+
+// e.g. " 10.0.0.1 valid [IGP metric 0], #paths 12, peer 10.0.0.1" or " 192.0.2.1 invalid, #paths 3"
+var bgpNexthopRegex = regexp.MustCompile(`^ ([\da-fA-F.:]+) (valid|invalid)(?: \[IGP metric (\d+)\])?, #paths (\d+)`)
+
+func recordNexthopMetrics(c *collection) {
+	o, err := c.vtysh("show bgp nexthop")
+	if err != nil {
+		c.collectorFailed("nexthop", err)
+		return
+	}
+	metric(c, bgpNexthopValid).Reset()
+	metric(c, bgpNexthopPaths).Reset()
+	metric(c, bgpNexthopIgpMetric).Reset()
+	metric(c, bgpNexthopResolvingNexthops).Reset()
+	metric(c, bgpNexthopResolvedInfo).Reset()
+	for _, nh := range parseNexthops(o) {
+		labels := prometheus.Labels{"nexthop": nh.Address}
+		metric(c, bgpNexthopValid).With(labels).Set(boolToFloat(nh.Valid))
+		metric(c, bgpNexthopPaths).With(labels).Set(nh.Paths)
+		metric(c, bgpNexthopResolvingNexthops).With(labels).Set(nh.Nexthops)
+		if nh.Valid {
+			metric(c, bgpNexthopIgpMetric).With(labels).Set(nh.IgpMetric)
+		}
+		if nh.ResolvedPrefix != "" {
+			metric(c, bgpNexthopResolvedInfo).With(prometheus.Labels{"nexthop": nh.Address, "prefix": nh.ResolvedPrefix}).Set(1)
+		}
+	}
+}
+
+// parseNexthops : Parses the nexthop cache of "show bgp nexthop". The details of a nexthop are indented
+// below it: the resolving route ("Resolved prefix"), its gateways and interfaces ("gate", "if") and the
+// time of the last update. The import check cache of the network statements which may follow is skipped.
+func parseNexthops(s string) []BgpNexthop {
+	var nexthops []BgpNexthop
+	var nh *BgpNexthop
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "Current BGP import check cache") {
+			break
+		}
+		if m := bgpNexthopRegex.FindStringSubmatch(line); m != nil {
+			nexthops = append(nexthops, BgpNexthop{Address: m[1], Valid: m[2] == "valid"})
+			nh = &nexthops[len(nexthops)-1]
+			nh.IgpMetric, _ = strconv.ParseFloat(m[3], 64)
+			nh.Paths, _ = strconv.ParseFloat(m[4], 64)
+			continue
+		}
+		if nh == nil {
+			continue
+		}
+		t := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(t, "Resolved prefix "):
+			nh.ResolvedPrefix = strings.TrimPrefix(t, "Resolved prefix ")
+		case strings.HasPrefix(t, "gate ") || strings.HasPrefix(t, "if "):
+			nh.Nexthops++
+		}
+	}
+	return nexthops
+}
@@ -0,0 +1,327 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative frr/frr-northbound.proto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fiveai/bgp-exporter/frr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// NorthboundConfig : This represents the northbound gRPC interface of FRR (bgpd started with
+// "-M grpc"), queried for the configuration and operational state of the neighbors in the frr-bgp YANG model
+type NorthboundConfig struct {
+	Address string `yaml:"address"`
+	Path    string `yaml:"path"`
+}
+
+// northboundDefaultPath : The neighbors of the default BGP instance
+const northboundDefaultPath = "/frr-routing:routing/control-plane-protocols/control-plane-protocol[type='frr-bgp:bgp'][name='bgp'][vrf='default']/frr-bgp:bgp/neighbors"
+
+func (c *NorthboundConfig) enabled() bool {
+	return c.Address != ""
+}
+
+// validate : Checks that the northbound configuration is complete, filling in the defaults
+func (c *NorthboundConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("invalid address %q: %s", c.Address, err)
+	}
+	if c.Path == "" {
+		c.Path = northboundDefaultPath
+	}
+	return nil
+}
+
+// northboundConns : The connections to the northbound interfaces by address, kept open between the collections
+var northboundConns = struct {
+	sync.Mutex
+	conns map[string]*grpc.ClientConn
+}{conns: make(map[string]*grpc.ClientConn)}
+
+// northboundConn : Returns the connection to the northbound interface, which only speaks HTTP/2 without TLS.
+// The connection is only made by the first call, and made again by grpc-go when it is lost.
+func northboundConn(address string) (*grpc.ClientConn, error) {
+	northboundConns.Lock()
+	defer northboundConns.Unlock()
+	if conn := northboundConns.conns[address]; conn != nil {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	northboundConns.conns[address] = conn
+	return conn, nil
+}
+
+// closeNorthbound : Closes the connections to the northbound interfaces
+func closeNorthbound() {
+	northboundConns.Lock()
+	defer northboundConns.Unlock()
+	for address, conn := range northboundConns.conns {
+		conn.Close()
+		delete(northboundConns.conns, address)
+	}
+}
+
+// northboundGet : Calls frr.Northbound/Get for the configuration and the state of the path, returning
+// the JSON data trees. Both are needed, as most leaves of the neighbors (e.g. their description or
+// timers) are configuration.
+func northboundGet(ctx context.Context, address string, path string) ([]string, error) {
+	conn, err := northboundConn(address)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := frr.NewNorthboundClient(conn).Get(ctx, &frr.GetRequest{
+		Type:         frr.GetRequest_ALL,
+		Encoding:     frr.Encoding_JSON,
+		WithDefaults: true,
+		Path:         []string{path},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var trees []string
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return trees, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if data := resp.GetData().GetData(); data != "" {
+			trees = append(trees, data)
+		}
+	}
+}
+
+// northboundNeighbors : Returns the neighbors from the configuration and operational state of bgpd
+func northboundNeighbors(ctx context.Context, c *NorthboundConfig) ([]BgpNeighbor, error) {
+	ctx, cancel := context.WithTimeout(ctx, *vtyshTimeout)
+	defer cancel()
+	trees, err := northboundGet(ctx, c.Address, c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from the northbound interface at %s: %s", c.Path, c.Address, err)
+	}
+
+	var neighbors []BgpNeighbor
+	for _, tree := range trees {
+		// The numbers are kept as written, e.g. for 4-byte AS numbers not to be printed as floats
+		dec := json.NewDecoder(strings.NewReader(tree))
+		dec.UseNumber()
+		var data interface{}
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("failed to parse the northbound data: %s", err)
+		}
+		neighbors = append(neighbors, northboundFindNeighbors(data, northboundInstance{})...)
+	}
+	return neighbors, nil
+}
+
+// northboundInstance : The BGP instance of the neighbors being found in the data tree, which their
+// VRF and type depend on
+type northboundInstance struct {
+	vrf     string
+	localAS string
+}
+
+// northboundFindNeighbors : Returns the entries of the neighbor and unnumbered-neighbor lists in the data tree
+func northboundFindNeighbors(data interface{}, instance northboundInstance) []BgpNeighbor {
+	var neighbors []BgpNeighbor
+	switch d := data.(type) {
+	case map[string]interface{}:
+		// The entries of control-plane-protocol give the VRF, and the global container of the BGP instance its AS
+		if vrf, ok := d["vrf"].(string); ok {
+			instance.vrf = vrf
+			if vrf == "default" {
+				instance.vrf = ""
+			}
+		}
+		for key, value := range d {
+			if northboundName(key) == "global" {
+				if global, ok := value.(map[string]interface{}); ok && global["local-as"] != nil {
+					instance.localAS = fmt.Sprint(global["local-as"])
+				}
+			}
+		}
+		for key, value := range d {
+			// The names of the nodes are qualified by their module where it changes, e.g. "frr-bgp:bgp"
+			name := northboundName(key)
+			if list, ok := value.([]interface{}); ok && (name == "neighbor" || name == "unnumbered-neighbor") {
+				for _, entry := range list {
+					if e, ok := entry.(map[string]interface{}); ok {
+						neighbors = append(neighbors, northboundNeighbor(e, instance))
+					}
+				}
+				continue
+			}
+			neighbors = append(neighbors, northboundFindNeighbors(value, instance)...)
+		}
+	case []interface{}:
+		for _, value := range d {
+			neighbors = append(neighbors, northboundFindNeighbors(value, instance)...)
+		}
+	}
+	return neighbors
+}
+
+// northboundName : Returns the name of the node without the module qualifying it
+func northboundName(key string) string {
+	return key[strings.LastIndex(key, ":")+1:]
+}
+
+// northboundLeaves : Calls the function with the path and the value of the leaves and lists below the node,
+// the paths being made of the names of the containers
+func northboundLeaves(path string, node interface{}, leaf func(path string, value interface{})) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range m {
+		name := path + "/" + northboundName(key)
+		if _, ok := value.(map[string]interface{}); ok {
+			northboundLeaves(name, value, leaf)
+			continue
+		}
+		leaf(name, value)
+	}
+}
+
+// northboundNeighbor : Converts an entry of the neighbor lists of the frr-bgp model, whatever the depth of
+// its leaves, filling what "show ip bgp neighbors" gives and the model has. The timers of the model are
+// those configured, and what is negotiated (e.g. the hostname or the graceful restart capability) is not
+// part of it.
+func northboundNeighbor(entry map[string]interface{}, instance northboundInstance) BgpNeighbor {
+	n := BgpNeighbor{Vrf: instance.vrf, AddressFamilies: make(map[string]*BgpAddressFamily)}
+	remoteASType := ""
+	northboundLeaves("", entry, func(path string, value interface{}) {
+		s := fmt.Sprint(value)
+		f, _ := strconv.ParseFloat(s, 64)
+		switch path {
+		case "/remote-address":
+			n.IP = net.ParseIP(s)
+		case "/interface":
+			n.Interface = s
+		case "/neighbor-remote-as/remote-as":
+			n.RemoteAS = s
+		case "/neighbor-remote-as/remote-as-type":
+			remoteASType = northboundName(s)
+		case "/description":
+			n.Description = s
+		case "/peer-group":
+			n.PeerGroup = s
+		case "/admin-shutdown/enable":
+			n.AdminShutdown = s == "true"
+		case "/admin-shutdown/message":
+			n.ShutdownMessage = s
+		case "/session-state", "/state/session-state":
+			n.State = gnmiNeighborStates[strings.ToUpper(s)]
+		case "/established-transitions", "/state/established-transitions":
+			n.ConnectionsEstablished = f
+		case "/timers/hold-time":
+			n.ConfiguredHoldTime = f
+		case "/timers/keepalive":
+			n.ConfiguredKeepalive = f
+		case "/timers/advertise-interval":
+			n.AdvertisementInterval = f
+			n.HasAdvertisementInterval = true
+		case "/ttl-security":
+			n.TTLSecurity = f != 0
+		case "/ebgp-multihop/multihop-ttl":
+			n.MultihopTTL = s
+		case "/update-source/ip", "/update-source/interface":
+			n.UpdateSource = s
+		case "/password":
+			// FRR only authenticates the sessions with TCP MD5
+			n.Authentication = "md5"
+		case "/afi-safis/afi-safi":
+			if list, ok := value.([]interface{}); ok {
+				for _, entry := range list {
+					if e, ok := entry.(map[string]interface{}); ok {
+						northboundAddressFamily(&n, e)
+					}
+				}
+			}
+		}
+	})
+
+	switch {
+	case remoteASType == "internal":
+		// The remote AS is that of the instance, as shown by "show ip bgp neighbors"
+		n.Type = "ibgp"
+		if n.RemoteAS == "" {
+			n.RemoteAS = instance.localAS
+		}
+	case remoteASType == "external":
+		n.Type = "ebgp"
+	case n.RemoteAS != "" && instance.localAS != "":
+		n.Type = "ebgp"
+		if n.RemoteAS == instance.localAS {
+			n.Type = "ibgp"
+		}
+	}
+	for _, af := range n.AddressFamilies {
+		n.AcceptedPrefixes += af.AcceptedPrefixes
+	}
+	return n
+}
+
+// northboundAddressFamily : Adds an entry of the afi-safi list of the neighbor, unless it is disabled. Its
+// leaves are in a container named after it, e.g. "ipv4-unicast".
+func northboundAddressFamily(n *BgpNeighbor, entry map[string]interface{}) {
+	if entry["afi-safi-name"] == nil || fmt.Sprint(entry["enabled"]) == "false" {
+		return
+	}
+	name := northboundName(fmt.Sprint(entry["afi-safi-name"]))
+	af := n.addressFamily(strings.ReplaceAll(name, "-", " "))
+	northboundLeaves("", entry, func(path string, value interface{}) {
+		s := fmt.Sprint(value)
+		f, _ := strconv.ParseFloat(s, 64)
+		switch strings.TrimPrefix(path, "/"+name) {
+		case "/prefix-limit/direction-list":
+			list, _ := value.([]interface{})
+			for _, entry := range list {
+				if e, ok := entry.(map[string]interface{}); ok && fmt.Sprint(e["direction"]) == "in" {
+					northboundLeaves("", e, func(path string, value interface{}) {
+						f, _ := strconv.ParseFloat(fmt.Sprint(value), 64)
+						switch path {
+						case "/max-prefixes":
+							af.MaximumPrefixes = f
+						case "/options/shutdown-threshold-pct", "/options/tr-shutdown-threshold-pct", "/options/tw-shutdown-threshold-pct":
+							af.MaximumPrefixesThreshold = f
+						}
+					})
+				}
+			}
+		case "/soft-reconfiguration":
+			af.SoftReconfigInbound = s == "true"
+		case "/filter-config/rmap-import":
+			af.InboundRouteMap = s
+		case "/filter-config/rmap-export":
+			af.OutboundRouteMap = s
+		case "/filter-config/plist-import":
+			af.InboundPrefixList = s
+		case "/filter-config/plist-export":
+			af.OutboundPrefixList = s
+		case "/route-reflector/route-reflector-client":
+			if s == "true" {
+				n.RouteReflectorClient = true
+			}
+		case "/prefixes/received", "/state/prefixes/received":
+			af.AcceptedPrefixes = f
+		}
+	})
+}
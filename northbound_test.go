@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/fiveai/bgp-exporter/frr"
+	"google.golang.org/grpc"
+)
+
+// northboundServer : This represents the northbound interface of bgpd, answering with a data tree
+type northboundServer struct {
+	frr.UnimplementedNorthboundServer
+	tree     string
+	requests chan *frr.GetRequest
+}
+
+func (s *northboundServer) Get(req *frr.GetRequest, stream frr.Northbound_GetServer) error {
+	s.requests <- req
+	return stream.Send(&frr.GetResponse{Timestamp: 1700000000000000000, Data: &frr.DataTree{Encoding: frr.Encoding_JSON, Data: s.tree}})
+}
+
+func TestNorthboundNeighbors(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb := &northboundServer{tree: readFile(t, "testdata/northbound/bgp_neighbors.json"), requests: make(chan *frr.GetRequest, 1)}
+	s := grpc.NewServer()
+	frr.RegisterNorthboundServer(s, nb)
+	go s.Serve(l)
+	t.Cleanup(func() {
+		closeNorthbound()
+		s.Stop()
+	})
+
+	c := &NorthboundConfig{Address: l.Addr().String()}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	neighbors, err := northboundNeighbors(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := <-nb.requests
+	if req.Type != frr.GetRequest_ALL || req.Encoding != frr.Encoding_JSON || len(req.Path) != 1 || req.Path[0] != northboundDefaultPath {
+		t.Errorf("got the request %v", req)
+	}
+	sort.Slice(neighbors, func(i, j int) bool { return neighbors[i].key() < neighbors[j].key() })
+	golden(t, "testdata/northbound/bgp_neighbors.golden", neighbors)
+}
+
+func TestNorthboundNeighborsUnreachable(t *testing.T) {
+	t.Cleanup(closeNorthbound)
+	setFlags(t, map[string]string{"vtysh.timeout": "1s"})
+	c := &NorthboundConfig{Address: "127.0.0.1:1"}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := northboundNeighbors(context.Background(), c); err == nil {
+		t.Error("got the neighbors of an unreachable interface")
+	}
+}
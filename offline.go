@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+var inputFile = flag.String("input.file", "", "Parse saved \"show ip bgp neighbors\" output from this file (\"-\" for stdin) instead of running vtysh, print the resulting metrics and exit")
+
+// parseInputFile : Collects the neighbors from a file once and prints their metrics
+func parseInputFile(path string, w io.Writer) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	registry := prometheus.NewRegistry()
+	registerNeighborMetrics(registry)
+	neighbors := filterNeighbors(parseBGP(r, localTarget))
+	newCollection(context.Background(), localTargetConfig, nil).recordNeighborMetrics(neighbors)
+
+	return writeMetrics(exporterGatherer(registry), w)
+}
+
+// writeMetrics : Writes the gathered metrics in the text exposition format
+func writeMetrics(g prometheus.Gatherer, w io.Writer) error {
+	mfs, err := g.Gather()
+	if err != nil {
+		return err
+	}
+	enc := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return fmt.Errorf("failed to encode %s: %s", mf.GetName(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The expressions are matched against the lines of "show ip bgp neighbors" with the indentation
+// trimmed, and only once the start of the line has identified which one applies
+var bgpInstanceRegex = regexp.MustCompile(`^Instance (\S+):$`)
+var bgpNeighborRegex = regexp.MustCompile(`^BGP neighbor is ([\da-fA-F.:]+), `)
+
+// Unnumbered neighbors are shown by interface, with the (link-local) address once it is known
+var bgpInterfaceNeighborRegex = regexp.MustCompile(`^BGP neighbor on (\S+?)(?:: ([\da-fA-F.:]+|None))?, `)
+var bgpNeighborLinkRegex = regexp.MustCompile(`remote AS (\d+), .*?(internal|external|confed-internal|confed-external) link`)
+var bgpStateRegex = regexp.MustCompile(`^BGP state = (\w+)(?:, up for (\S+))?`)
+var bgpLastResetRegex = regexp.MustCompile(`^Last reset [^,]+, +(?:due to )?(.+)$`)
+var bgpAcceptedPrefixesRegex = regexp.MustCompile(`^(\d+) accepted prefixes$`)
+var bgpConnectionsEstablishedDroppedRegex = regexp.MustCompile(`^Connections established (\d+); dropped (\d+)$`)
+var bgpShutdownMessageRegex = regexp.MustCompile(`^Shutdown message: "?(.*?)"?$`)
+var bgpGRRestartTimerRegex = regexp.MustCompile(`^(?:Remote Restart timer is (\d+) seconds|Received Restart Time\(sec\): (\d+))$`)
+var bgpGRPreservedRegex = regexp.MustCompile(`^((?:IPv4|IPv6|VPNv4|VPNv6|L2VPN) \w+)\((preserved|not preserved)\)`)
+var bgpGRAddressFamilyRegex = regexp.MustCompile(`^((?:IPv4|IPv6|VPNv4|VPNv6|L2VPN) \w+):$`)
+var bgpTimersRegex = regexp.MustCompile(`^(Configured hold|Hold) time is (\d+)(?: seconds)?, keepalive interval is (\d+) seconds`)
+
+// Cisco IOS prints the negotiated timers after the last read and write times, and the
+// accepted prefixes as the received column of the prefix activity
+var bgpIOSTimersRegex = regexp.MustCompile(`, hold time is (\d+), keepalive interval is (\d+) seconds`)
+var bgpLastReadWriteRegex = regexp.MustCompile(`^Last read ([^,]+), [Ll]ast write ([^,]+)`)
+var bgpIOSPrefixesCurrentRegex = regexp.MustCompile(`^Prefixes Current:\s+(\d+)\s+(\d+)`)
+var bgpIOSNeighborVrfRegex = regexp.MustCompile(`, +vrf (\S+), `)
+var bgpBfdTimersRegex = regexp.MustCompile(`^Detect Multiplier: (\d+), Min Rx interval: (\d+), Min Tx interval: (\d+)`)
+var bgpBfdStatusRegex = regexp.MustCompile(`^Status: (\w+), Last update: `)
+var bgpUpdateGroupRegex = regexp.MustCompile(`^Update group (\d+), subgroup (\d+)`)
+var bgpAdvertisementIntervalRegex = regexp.MustCompile(`^(?:Default m|M)inimum time between advertisement runs is (\d+) seconds`)
+var bgpPolicyRegex = regexp.MustCompile(`^(Route map for (?:incoming|outgoing) advertisements|(?:Incoming|Outgoing) update prefix filter list) is \*?(\S+)`)
+var bgpIOSMinimumTTLRegex = regexp.MustCompile(`(?:Minimum|Mininum) incoming TTL (\d+)`)
+var bgpConnectionHostRegex = regexp.MustCompile(`^(Local|Foreign) host: (\S+), (?:Local|Foreign) port: (\d+)`)
+var bgpMultihopRegex = regexp.MustCompile(`^External BGP neighbor may be up to (\d+) hops away`)
+var bgpMaximumPrefixesRegex = regexp.MustCompile(`^Maximum prefixes allowed (\d+)`)
+var bgpMaximumPrefixesThresholdRegex = regexp.MustCompile(`^Threshold for warning message (\d+)%`)
+
+// bgpParser : This holds the state of the parsing of "show ip bgp neighbors"
+type bgpParser struct {
+	neighbors []BgpNeighbor
+	neigh     *BgpNeighbor
+	af        *BgpAddressFamily
+	grAF      *BgpAddressFamily
+	vrf       string
+	// target : The router whose neighbors are parsed, under which the errors are kept
+	target string
+	// denied : Whether the lines are those of the local policy denied prefixes of Cisco IOS
+	denied bool
+}
+
+// parseBGP : Parses the output of "show ip bgp neighbors" in a single pass over its lines
+func parseBGP(r io.Reader, target string) []BgpNeighbor {
+	p := &bgpParser{target: target}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		p.parseLine(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		recordError(target, fmt.Sprintf("Failed to read the BGP neighbors: %s", err))
+	}
+	return p.finish()
+}
+
+// finish : Returns the parsed neighbors, including the one being parsed
+func (p *bgpParser) finish() []BgpNeighbor {
+	if p.neigh != nil {
+		p.neighbors = append(p.neighbors, *p.neigh)
+		p.neigh = nil
+	}
+	return p.neighbors
+}
+
+func (p *bgpParser) parseLine(line string) {
+	t := strings.TrimSpace(line)
+	if t == "" {
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(t, "Instance "):
+		// The neighbors of all instances ("show ip bgp view all neighbors") are grouped by instance,
+		// the default one being named as if there was a single instance
+		if m := bgpInstanceRegex.FindStringSubmatch(t); m != nil {
+			p.vrf = m[1]
+			if p.vrf == "default" {
+				p.vrf = ""
+			}
+		}
+		return
+	case strings.HasPrefix(t, "BGP neighbor "):
+		p.parseNeighbor(t)
+		return
+	}
+
+	n := p.neigh
+	if n == nil {
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(t, "BGP state = "):
+		if m := bgpStateRegex.FindStringSubmatch(t); m != nil {
+			n.State = p.parseState(m[1])
+			n.Uptime, _ = parseUptime(m[2])
+		}
+	case strings.HasPrefix(t, "Last reset "):
+		// e.g. "Last reset 1d02h03m, due to Hold Timer Expired" or "Last reset 00:01:23,  Waiting for peer OPEN"
+		if m := bgpLastResetRegex.FindStringSubmatch(t); m != nil {
+			n.LastResetReason = m[1]
+		}
+		if strings.HasSuffix(t, "due to Admin. shutdown") && n.State != 6 {
+			n.AdminShutdown = true
+		}
+	case t == "Administratively shut down":
+		if n.State != 6 {
+			n.AdminShutdown = true
+		}
+	case strings.HasPrefix(t, "Shutdown message: "):
+		n.ShutdownMessage = bgpShutdownMessageRegex.FindStringSubmatch(t)[1]
+	case strings.HasPrefix(t, "Hold time is ") || strings.HasPrefix(t, "Configured hold time is "):
+		if m := bgpTimersRegex.FindStringSubmatch(t); m != nil {
+			hold, _ := strconv.ParseFloat(m[2], 64)
+			keepalive, _ := strconv.ParseFloat(m[3], 64)
+			if m[1] == "Hold" {
+				n.HoldTime = hold
+				n.KeepaliveInterval = keepalive
+			} else {
+				n.ConfiguredHoldTime = hold
+				n.ConfiguredKeepalive = keepalive
+			}
+		}
+	case strings.HasPrefix(t, "Last read "):
+		// "never" until a message was read or written
+		if m := bgpLastReadWriteRegex.FindStringSubmatch(t); m != nil {
+			read, readOk := parseUptime(m[1])
+			write, writeOk := parseUptime(m[2])
+			if readOk && writeOk {
+				n.LastRead, n.LastWrite, n.HasLastRead = read, write, true
+			}
+		}
+		if m := bgpIOSTimersRegex.FindStringSubmatch(t); m != nil {
+			n.HoldTime, _ = strconv.ParseFloat(m[1], 64)
+			n.KeepaliveInterval, _ = strconv.ParseFloat(m[2], 64)
+		}
+	case strings.HasPrefix(t, "Prefixes Current:"):
+		if m := bgpIOSPrefixesCurrentRegex.FindStringSubmatch(t); m != nil {
+			n.AcceptedPrefixes, _ = strconv.ParseFloat(m[2], 64)
+			if p.af != nil {
+				p.af.AcceptedPrefixes = n.AcceptedPrefixes
+			}
+		}
+	case strings.Contains(t, "incoming TTL "):
+		// Cisco IOS, e.g. "Connection is ECN Disabled, Mininum incoming TTL 254, Outgoing TTL 255",
+		// where a minimum means that TTL security (GTSM) is configured
+		if m := bgpIOSMinimumTTLRegex.FindStringSubmatch(t); m != nil {
+			n.TTLSecurity = m[1] != "0"
+		}
+	case strings.HasPrefix(t, "Option Flags: "):
+		// Cisco IOS, e.g. "Option Flags: nagle, path mtu capable, md5"
+		for _, option := range strings.Split(strings.TrimPrefix(t, "Option Flags: "), ", ") {
+			switch option {
+			case "md5":
+				n.Authentication = "md5"
+			case "tcp-ao", "ao":
+				n.Authentication = "tcp_ao"
+			}
+		}
+	case strings.HasPrefix(t, "Local host: ") || strings.HasPrefix(t, "Foreign host: "):
+		if m := bgpConnectionHostRegex.FindStringSubmatch(t); m != nil {
+			if m[1] == "Local" {
+				n.LocalHost, n.LocalPort = m[2], m[3]
+			} else {
+				n.ForeignHost, n.ForeignPort = m[2], m[3]
+			}
+		}
+	case strings.HasPrefix(t, "External BGP neighbor may be up to "):
+		if m := bgpMultihopRegex.FindStringSubmatch(t); m != nil {
+			n.MultihopTTL = m[1]
+		}
+	case strings.HasPrefix(t, "Update source is "):
+		n.UpdateSource = strings.TrimPrefix(t, "Update source is ")
+	case strings.HasPrefix(t, "Local Policy Denied Prefixes:"):
+		p.denied = true
+	case p.denied && strings.HasPrefix(t, "Total:"):
+		// The outbound and inbound columns, e.g. "Total:  0  3"
+		p.denied = false
+		if fields := strings.Fields(t); len(fields) == 3 && p.af != nil {
+			p.af.PolicyDenied, _ = strconv.ParseFloat(fields[2], 64)
+			p.af.HasPolicyDenied = true
+		}
+	case strings.HasPrefix(t, "Graceful Restart Capability: ") || strings.HasPrefix(t, "Graceful Restart Capabilty: "):
+		capability := t[strings.Index(t, ": ")+2:]
+		n.GRAdvertised = strings.Contains(capability, "advertised")
+		n.GRReceived = strings.Contains(capability, "received")
+	case strings.HasPrefix(t, "Remote Restart timer is ") || strings.HasPrefix(t, "Received Restart Time(sec): "):
+		if m := bgpGRRestartTimerRegex.FindStringSubmatch(t); m != nil {
+			n.GRRestartTimer, _ = strconv.ParseFloat(m[1]+m[2], 64)
+		}
+	case strings.HasPrefix(t, "The remaining time of restart timer is "):
+		n.GRRestarting = true
+	case strings.HasSuffix(t, "preserved)"):
+		if m := bgpGRPreservedRegex.FindStringSubmatch(t); m != nil {
+			af := n.addressFamily(m[1])
+			af.GracefulRestart = true
+			af.GRForwardingPreserved = m[2] == "preserved"
+		}
+	case strings.HasSuffix(t, ":") && p.af == nil:
+		// Newer FRR versions list the per address family graceful restart state as
+		// "IPv4 Unicast:" followed by its F (forwarding state) bit
+		if m := bgpGRAddressFamilyRegex.FindStringSubmatch(t); m != nil {
+			p.grAF = n.addressFamily(m[1])
+		}
+	case strings.HasPrefix(t, "F bit: "):
+		if p.grAF != nil {
+			p.grAF.GracefulRestart = true
+			p.grAF.GRForwardingPreserved = t == "F bit: True"
+		}
+	case strings.HasPrefix(t, "BFD: Type: "):
+		n.BfdType = strings.TrimPrefix(t, "BFD: Type: ")
+		n.BfdStatus = -1
+	case strings.HasPrefix(t, "Detect Multiplier: "):
+		if m := bgpBfdTimersRegex.FindStringSubmatch(t); m != nil && n.BfdType != "" {
+			mult, _ := strconv.ParseFloat(m[1], 64)
+			rx, _ := strconv.ParseFloat(m[2], 64)
+			tx, _ := strconv.ParseFloat(m[3], 64)
+			// The intervals are printed in milliseconds
+			n.BfdDetectMultiplier = mult
+			n.BfdMinRxInterval = rx / 1000
+			n.BfdMinTxInterval = tx / 1000
+		}
+	case strings.HasPrefix(t, "Status: "):
+		if m := bgpBfdStatusRegex.FindStringSubmatch(t); m != nil && n.BfdType != "" {
+			/* References from RFC 5880 section 4.1
+			AdminDown(0),
+			Down(1),
+			Init(2),
+			Up(3)
+			*/
+			switch strings.ToLower(m[1]) {
+			case "admindown":
+				n.BfdStatus = 0
+			case "down":
+				n.BfdStatus = 1
+			case "init":
+				n.BfdStatus = 2
+			case "up":
+				n.BfdStatus = 3
+			}
+		}
+	case strings.HasPrefix(t, "Description: "):
+		n.Description = strings.TrimPrefix(t, "Description: ")
+	case strings.HasPrefix(t, "Hostname: "):
+		n.Hostname = strings.Fields(t)[1]
+	case strings.HasPrefix(t, "Member of peer-group "):
+		n.PeerGroup = strings.Fields(t)[3]
+	case t == "Route-Reflector Client":
+		n.RouteReflectorClient = true
+	case strings.HasPrefix(t, "For address family: "):
+		p.af = n.addressFamily(strings.TrimPrefix(t, "For address family: "))
+		p.grAF = nil
+	case t == "Inbound soft reconfiguration allowed":
+		if p.af != nil {
+			p.af.SoftReconfigInbound = true
+		}
+	case strings.HasPrefix(t, "Route map for ") || strings.HasPrefix(t, "Incoming update prefix filter list is ") || strings.HasPrefix(t, "Outgoing update prefix filter list is "):
+		// FRR marks the policies which are not defined with a "*"
+		if m := bgpPolicyRegex.FindStringSubmatch(t); m != nil && p.af != nil {
+			switch m[1] {
+			case "Route map for incoming advertisements":
+				p.af.InboundRouteMap = m[2]
+			case "Incoming update prefix filter list":
+				p.af.InboundPrefixList = m[2]
+			case "Route map for outgoing advertisements":
+				p.af.OutboundRouteMap = m[2]
+			case "Outgoing update prefix filter list":
+				p.af.OutboundPrefixList = m[2]
+			}
+		}
+	case strings.HasPrefix(t, "Update group "):
+		if m := bgpUpdateGroupRegex.FindStringSubmatch(t); m != nil && p.af != nil {
+			p.af.UpdateGroup, p.af.UpdateSubgroup = m[1], m[2]
+		}
+	case strings.HasPrefix(t, "Minimum time between advertisement runs ") || strings.HasPrefix(t, "Default minimum time between advertisement runs "):
+		// Cisco IOS prints the default interval when none is configured
+		if m := bgpAdvertisementIntervalRegex.FindStringSubmatch(t); m != nil {
+			n.AdvertisementInterval, _ = strconv.ParseFloat(m[1], 64)
+			n.HasAdvertisementInterval = true
+		}
+	case strings.HasPrefix(t, "Maximum prefixes allowed "):
+		if m := bgpMaximumPrefixesRegex.FindStringSubmatch(t); m != nil && p.af != nil {
+			p.af.MaximumPrefixes, _ = strconv.ParseFloat(m[1], 64)
+		}
+	case strings.HasPrefix(t, "Threshold for warning message "):
+		if m := bgpMaximumPrefixesThresholdRegex.FindStringSubmatch(t); m != nil && p.af != nil {
+			p.af.MaximumPrefixesThreshold, _ = strconv.ParseFloat(m[1], 64)
+		}
+	case strings.HasSuffix(t, " accepted prefixes"):
+		if m := bgpAcceptedPrefixesRegex.FindStringSubmatch(t); m != nil {
+			n.AcceptedPrefixes, _ = strconv.ParseFloat(m[1], 64)
+			if p.af != nil {
+				p.af.AcceptedPrefixes = n.AcceptedPrefixes
+			}
+		}
+	case strings.HasPrefix(t, "Connections established "):
+		if m := bgpConnectionsEstablishedDroppedRegex.FindStringSubmatch(t); m != nil {
+			n.ConnectionsEstablished, _ = strconv.ParseFloat(m[1], 64)
+			n.ConnectionsDropped, _ = strconv.ParseFloat(m[2], 64)
+		}
+	}
+}
+
+// parseNeighbor : Starts a new neighbor from its header line
+func (p *bgpParser) parseNeighbor(t string) {
+	var n *BgpNeighbor
+	if m := bgpNeighborRegex.FindStringSubmatch(t); m != nil {
+		n = &BgpNeighbor{IP: net.ParseIP(m[1])}
+	} else if m := bgpInterfaceNeighborRegex.FindStringSubmatch(t); m != nil {
+		n = &BgpNeighbor{Interface: m[1], IP: net.ParseIP(m[2])}
+	} else {
+		return
+	}
+
+	// Some details (e.g. the last reset reason) follow the connection counters, so a
+	// neighbor is only complete once the next one starts or the output ends
+	p.finish()
+	n.Vrf = p.vrf
+	if m := bgpIOSNeighborVrfRegex.FindStringSubmatch(t); m != nil {
+		n.Vrf = m[1]
+	}
+	n.AddressFamilies = make(map[string]*BgpAddressFamily)
+	p.neigh = n
+	p.af = nil
+	p.grAF = nil
+	p.denied = false
+
+	if m := bgpNeighborLinkRegex.FindStringSubmatch(t); m != nil {
+		n.RemoteAS = m[1]
+		switch m[2] {
+		case "internal":
+			n.Type = "ibgp"
+		case "external":
+			n.Type = "ebgp"
+		case "confed-internal":
+			n.Type = "confed_ibgp"
+		case "confed-external":
+			n.Type = "confed_ebgp"
+		}
+	}
+}
+
+// parseState : Converts the state from string to int
+func (p *bgpParser) parseState(state string) float64 {
+	/* References from: https://github.com/troglobit/quagga/blob/master/bgpd/BGP4-MIB.txt
+	idle(1),
+	connect(2),
+	active(3),
+	opensent(4),
+	openconfirm(5),
+	established(6)
+	FRR also shows the states of a session being torn down, not part of the MIB:
+	clearing(7),
+	deleted(8)
+	*/
+	// FRR capitalizes the states as it pleases (e.g. "OpenSent"), so they are matched whatever their case
+	if v := stateValue(strings.ToLower(state)); v > 0 {
+		return float64(v)
+	}
+	neigh := p.neigh.key()
+	logger.Warn("Unknown BGP state", "neighbor", neigh, "state", state)
+	recordError(p.target, fmt.Sprintf("Unknown BGP state %q for neighbor %s", state, neigh))
+	return 0
+}
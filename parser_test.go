@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files of the tests with what the code returns")
+
+// golden : Compares the value, encoded as indented JSON, with the golden file, rewriting it with -update
+func golden(t *testing.T, path string, v interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from what was returned:\n%s", path, got)
+	}
+}
+
+func parseFile(t *testing.T, path string) []BgpNeighbor {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return parseBGP(f, localTarget)
+}
+
+func TestParseBGP(t *testing.T) {
+	for _, path := range []string{
+		"testdata/frr/show_ip_bgp_neighbors.txt",
+		"testdata/frr/show_ip_bgp_view_all_neighbors.txt",
+		"testdata/frr/show_ip_bgp_neighbors_opening.txt",
+		"testdata/ios/show_ip_bgp_neighbors.txt",
+	} {
+		t.Run(path, func(t *testing.T) {
+			golden(t, strings.TrimSuffix(path, ".txt")+".golden", parseFile(t, path))
+		})
+	}
+}
+
+// TestParseBGPMatchesRegexParser : Checks that the single-pass parser returns what the regex parser it
+// replaced did, for the fields the regex parser filled. testdata/regex holds what the regex parser
+// returned for the FRR outputs, and the differences are those made on purpose since.
+func TestParseBGPMatchesRegexParser(t *testing.T) {
+	// difference : A field of a neighbor, given by its view as the regex parser returned it and its
+	// address, which differs from the regex parser
+	type difference struct {
+		neighbor string
+		field    string
+	}
+	tests := []struct {
+		path        string
+		differences map[difference]string
+	}{
+		{
+			path: "testdata/frr/show_ip_bgp_neighbors.txt",
+			differences: map[difference]string{
+				{"/10.0.0.5", "State"}: "the regex did not match \"BGP state = Idle\" without an uptime, leaving the state unknown",
+			},
+		},
+		{
+			path: "testdata/frr/show_ip_bgp_view_all_neighbors.txt",
+			differences: map[difference]string{
+				{"default/10.0.0.1", "Vrf"}:                "the default instance is the empty view, as with the other backends",
+				{"default/10.0.0.5", "Vrf"}:                "the default instance is the empty view, as with the other backends",
+				{"default/fe80::4638:39ff:fe00:5c", "Vrf"}: "the default instance is the empty view, as with the other backends",
+				{"default/10.0.0.5", "State"}:              "the regex did not match \"BGP state = Idle\" without an uptime, leaving the state unknown",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata/regex", strings.TrimSuffix(filepath.Base(tt.path), ".txt")+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []map[string]json.RawMessage
+			if err := json.Unmarshal(b, &want); err != nil {
+				t.Fatal(err)
+			}
+			b, err = json.Marshal(parseFile(t, tt.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []map[string]json.RawMessage
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got %d neighbors, the regex parser %d", len(got), len(want))
+			}
+			for i := range want {
+				var vrf, ip string
+				json.Unmarshal(want[i]["Vrf"], &vrf)
+				json.Unmarshal(want[i]["IP"], &ip)
+				neighbor := vrf + "/" + ip
+				for field, w := range want[i] {
+					if field == "AddressFamilies" {
+						w, got[i][field] = regexAddressFamilies(t, w, got[i][field])
+					}
+					if _, ok := tt.differences[difference{neighbor, field}]; ok {
+						if bytes.Equal(got[i][field], w) {
+							t.Errorf("%s %s is expected to differ from the regex parser", neighbor, field)
+						}
+						continue
+					}
+					if !bytes.Equal(got[i][field], w) {
+						t.Errorf("%s %s: got %s, the regex parser %s", neighbor, field, got[i][field], w)
+					}
+				}
+			}
+		})
+	}
+}
+
+// regexAddressFamilies : Returns the address families of both parsers with only the fields the regex parser filled
+func regexAddressFamilies(t *testing.T, want json.RawMessage, got json.RawMessage) (json.RawMessage, json.RawMessage) {
+	t.Helper()
+	var w, g map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal(err)
+	}
+	for name, af := range g {
+		for field := range af {
+			if _, ok := w[name][field]; !ok {
+				delete(af, field)
+			}
+		}
+	}
+	want, _ = json.Marshal(w)
+	got, _ = json.Marshal(g)
+	return want, got
+}
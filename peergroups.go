@@ -0,0 +1,49 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpPeerGroupNeighbors = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_peer_group_neighbors",
+		Help: "The number of BGP neighbors which are members of a given peer group",
+	},
+		[]string{
+			"peer_group",
+		})
+)
+
+var (
+	bgpPeerGroupNeighborsEstablished = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_peer_group_neighbors_established",
+		Help: "The number of established BGP neighbors which are members of a given peer group",
+	},
+		[]string{
+			"peer_group",
+		})
+)
+
+var (
+	bgpPeerGroupAcceptedPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_peer_group_accepted_prefixes",
+		Help: "The total number of accepted prefixes of the BGP neighbors which are members of a given peer group",
+	},
+		[]string{
+			"peer_group",
+		})
+)
+
+func recordPeerGroupMetrics(c *collection) {
+	metric(c, bgpPeerGroupNeighbors).Reset()
+	metric(c, bgpPeerGroupNeighborsEstablished).Reset()
+	metric(c, bgpPeerGroupAcceptedPrefixes).Reset()
+	for _, n := range c.state.neighbors.List() {
+		if n.PeerGroup == "" {
+			continue
+		}
+		metric(c, bgpPeerGroupNeighbors).With(prometheus.Labels{"peer_group": n.PeerGroup}).Inc()
+		metric(c, bgpPeerGroupNeighborsEstablished).With(prometheus.Labels{"peer_group": n.PeerGroup}).Add(boolToFloat(n.State == 6))
+		metric(c, bgpPeerGroupAcceptedPrefixes).With(prometheus.Labels{"peer_group": n.PeerGroup}).Add(n.AcceptedPrefixes)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var platform = flag.String("platform", "frr", "The platform of the router: frr, or ios for Cisco IOS/IOS-XE over the SSH backend")
+
+// checkPlatform : Returns an error if the platform is unknown, or cannot be collected with the backend.
+// Cisco IOS has no vtysh, so its commands can only be run over SSH (or parsed from a file).
+func checkPlatform() error {
+	switch *platform {
+	case "frr":
+		return nil
+	case "ios":
+		if !config.SSH.enabled() && *inputFile == "" && !multiRouter() {
+			return fmt.Errorf("the ios platform requires the ssh backend")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown platform %q", *platform)
+}
+
+// summaryCommand : Returns the command listing the summary of all the address families
+func (c *collection) summaryCommand() string {
+	if c.ios() {
+		return "show bgp all summary"
+	}
+	return "show ip bgp summary"
+}
+
+// summarySafis : The subsequent address families which can be given in --collector.summary.address-families
+var summarySafis = []string{"unicast", "labeled_unicast", "multicast"}
+
+// validateSummaryAddressFamilies : Returns an error if an address family of --collector.summary.address-families is unknown
+func validateSummaryAddressFamilies() error {
+	for _, af := range summaryAddressFamilyList() {
+		afi, safi, _ := strings.Cut(af, "_")
+		if (afi != "ipv4" && afi != "ipv6") || !slices.Contains(summarySafis, safi) {
+			return fmt.Errorf("invalid address family %q in --collector.summary.address-families", af)
+		}
+	}
+	return nil
+}
+
+// summaryAddressFamilyList : Returns the address families of --collector.summary.address-families, e.g. "ipv4_labeled_unicast"
+func summaryAddressFamilyList() []string {
+	var afs []string
+	for _, af := range strings.Split(*summaryAddressFamilies, ",") {
+		if af = strings.TrimSpace(af); af != "" {
+			afs = append(afs, af)
+		}
+	}
+	return afs
+}
+
+// summaryCommands : Returns the commands listing the summaries of the collected address families.
+// FRR only lists IPv4 unicast in "show ip bgp summary", while IOS lists them all. The address families
+// given with --collector.summary.address-families are listed one by one instead, e.g. with
+// "show bgp ipv4 labeled-unicast summary".
+func (c *collection) summaryCommands() []string {
+	commands := []string{c.summaryCommand()}
+	if afs := summaryAddressFamilyList(); len(afs) > 0 && !c.ios() {
+		commands = nil
+		for _, af := range afs {
+			afi, safi, _ := strings.Cut(af, "_")
+			commands = append(commands, "show bgp "+afi+" "+strings.ReplaceAll(safi, "_", "-")+" summary")
+		}
+	}
+	if *collectVpn && !c.ios() {
+		commands = append(commands, "show bgp ipv4 vpn summary", "show bgp ipv6 vpn summary")
+	}
+	if *collectFlowspec && !c.ios() {
+		commands = append(commands, "show bgp ipv4 flowspec summary", "show bgp ipv6 flowspec summary")
+	}
+	return commands
+}
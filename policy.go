@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborPolicyDeniedPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_policy_denied_prefixes",
+		Help: "The number of prefixes received from a given BGP neighbor for an address family and denied by its inbound policy (route map and prefix list)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+			"route_map",
+			"prefix_list",
+		})
+)
+
+// e.g. "PfxCt: 12" and "Adj-in: 20" in "show bgp ipv4 unicast neighbors 10.0.0.1 prefix-counts"
+var bgpPrefixCountsRegex = regexp.MustCompile(`^(PfxCt|Adj-in): (\d+)$`)
+
+// recordPolicyMetrics : Exports the prefixes denied by the inbound policy of the established neighbors.
+// Cisco IOS counts them in "show ip bgp neighbors". FRR only keeps the prefixes received before the
+// policy with "soft-reconfiguration inbound", so the denied ones are those received (Adj-in) but not
+// accepted (PfxCt) as counted by "prefix-counts".
+func recordPolicyMetrics(c *collection) {
+	metric(c, bgpNeighborPolicyDeniedPrefixes).Reset()
+	for _, n := range c.exportedNeighbors() {
+		if n.State != 6 {
+			continue
+		}
+		for afi, af := range n.AddressFamilies {
+			labels := n.labels("afi", afi, "route_map", af.InboundRouteMap, "prefix_list", af.InboundPrefixList)
+			if af.HasPolicyDenied {
+				metric(c, bgpNeighborPolicyDeniedPrefixes).With(labels).Set(af.PolicyDenied)
+				continue
+			}
+			if !af.SoftReconfigInbound || c.ios() {
+				continue
+			}
+			denied, err := c.policyDeniedPrefixes(&n, afi)
+			if err != nil {
+				c.collectorFailed("policy", err)
+				continue
+			}
+			metric(c, bgpNeighborPolicyDeniedPrefixes).With(labels).Set(denied)
+		}
+	}
+}
+
+// policyDeniedPrefixes : Returns the prefixes received from the neighbor for the address family (e.g.
+// "ipv4_unicast") which were not accepted
+func (c *collection) policyDeniedPrefixes(n *BgpNeighbor, afi string) (float64, error) {
+	command := "show bgp " + strings.Replace(afi, "_", " ", 1) + " neighbors " + n.key() + " prefix-counts"
+	if n.Vrf != "" {
+		command = "show bgp vrf " + n.Vrf + " " + strings.Replace(afi, "_", " ", 1) + " neighbors " + n.key() + " prefix-counts"
+	}
+	o, err := c.vtysh(command)
+	if err != nil {
+		return 0, err
+	}
+	counts := make(map[string]float64)
+	for _, line := range strings.Split(o, "\n") {
+		if m := bgpPrefixCountsRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			counts[m[1]], _ = strconv.ParseFloat(m[2], 64)
+		}
+	}
+	if _, ok := counts["Adj-in"]; !ok {
+		return 0, fmt.Errorf("no Adj-in count in the output of %q", command)
+	}
+	return counts["Adj-in"] - counts["PfxCt"], nil
+}
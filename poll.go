@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/subtle"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var pollTokenFile = flag.String("web.poll-token-file", "", "File holding the bearer token required by POST /-/poll, which is open to all if none is given")
+
+// pollRequests : The requests for an immediate collection, each closing its channel once it completed
+var pollRequests = make(chan chan struct{})
+
+// pollToken : The bearer token required by /-/poll, if any
+var pollToken string
+
+// loadPollToken : Reads the token required by /-/poll
+func loadPollToken() error {
+	if *pollTokenFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(*pollTokenFile)
+	if err != nil {
+		return err
+	}
+	pollToken = strings.TrimSpace(string(content))
+	if pollToken == "" {
+		return fmt.Errorf("the token file %s is empty", *pollTokenFile)
+	}
+	return nil
+}
+
+// pollHandler : Runs a collection at once rather than at the next interval, e.g. after a maintenance,
+// and replies once it completed
+func pollHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if pollToken != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(pollToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	done := make(chan struct{})
+	select {
+	case pollRequests <- done:
+	case <-r.Context().Done():
+		return
+	}
+	select {
+	case <-done:
+		_, _ = w.Write([]byte("Collected\n"))
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"expvar"
+	"flag"
+	"net/http"
+	"net/http/pprof"
+)
+
+var enablePprof = flag.Bool("web.enable-pprof", false, "Serve the Go profiling endpoints at /debug/pprof/ and the runtime variables at /debug/vars")
+
+// registerPprofHandlers : Registers the profiling handlers on the mux. Importing net/http/pprof and
+// expvar also registers them on http.DefaultServeMux, which is why the exporter serves its own mux.
+func registerPprofHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpPrefixPresent = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_prefix_present",
+		Help: "Whether a given monitored prefix is in the BGP table",
+	},
+		[]string{
+			"prefix",
+		})
+)
+
+var (
+	bgpPrefixBestPath = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_prefix_best_path",
+		Help: "Whether a given monitored prefix has a best path",
+	},
+		[]string{
+			"prefix",
+		})
+)
+
+var (
+	bgpPrefixPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_prefix_paths",
+		Help: "The number of paths of a given monitored prefix in the BGP table",
+	},
+		[]string{
+			"prefix",
+		})
+)
+
+var (
+	bgpPrefixAdvertised = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_prefix_advertised",
+		Help: "Whether a given monitored prefix is advertised to a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"prefix",
+		})
+)
+
+// BgpPrefixEntry : This represents a prefix as returned by "show bgp ipv4 unicast <prefix> json", empty
+// if the prefix is not in the table. The neighbors it is advertised to are keyed by address, or by
+// interface for unnumbered neighbors.
+type BgpPrefixEntry struct {
+	Prefix       string                     `json:"prefix"`
+	AdvertisedTo map[string]json.RawMessage `json:"advertisedTo"`
+	Paths        []BgpPrefixPath            `json:"paths"`
+}
+
+// BgpPrefixPath : This represents a path of a prefix entry, with the neighbor it was received from
+// (none for the local paths)
+type BgpPrefixPath struct {
+	BestPath *struct {
+		Overall bool `json:"overall"`
+	} `json:"bestpath"`
+	Peer *struct {
+		PeerID string `json:"peerId"`
+	} `json:"peer"`
+}
+
+// validateMonitoredPrefixes : Checks that the monitored prefixes are networks, as printed by FRR
+func validateMonitoredPrefixes(prefixes []string) error {
+	for _, p := range prefixes {
+		ip, network, err := net.ParseCIDR(p)
+		if err != nil {
+			return err
+		}
+		if !ip.Equal(network.IP) {
+			return fmt.Errorf("%s is not a network address, did you mean %s?", p, network)
+		}
+	}
+	return nil
+}
+
+func monitoredPrefixesEnabled() bool {
+	return len(config.MonitoredPrefixes) > 0
+}
+
+// recordPrefixMetrics : Exports whether the monitored prefixes (e.g. anycast prefixes) are in the table,
+// have a best path, and are advertised to the established neighbors of the default view, for a withdrawn
+// prefix to be noticed at once
+func recordPrefixMetrics(c *collection) {
+	metric(c, bgpPrefixPresent).Reset()
+	metric(c, bgpPrefixBestPath).Reset()
+	metric(c, bgpPrefixPaths).Reset()
+	metric(c, bgpPrefixAdvertised).Reset()
+	for _, prefix := range config.MonitoredPrefixes {
+		afi := "ipv4"
+		if ip, _, _ := net.ParseCIDR(prefix); ip.To4() == nil {
+			afi = "ipv6"
+		}
+		o, err := c.vtysh("show bgp " + afi + " unicast " + prefix + " json")
+		if err != nil {
+			c.collectorFailed("prefixes", err)
+			continue
+		}
+		var entry BgpPrefixEntry
+		if err := json.Unmarshal([]byte(o), &entry); err != nil {
+			c.collectorFailed("prefixes", fmt.Errorf("failed to parse the entry of %s: %s", prefix, err))
+			continue
+		}
+		best := false
+		for _, p := range entry.Paths {
+			if p.BestPath != nil && p.BestPath.Overall {
+				best = true
+			}
+		}
+		labels := prometheus.Labels{"prefix": prefix}
+		metric(c, bgpPrefixPresent).With(labels).Set(boolToFloat(len(entry.Paths) > 0))
+		metric(c, bgpPrefixBestPath).With(labels).Set(boolToFloat(best))
+		metric(c, bgpPrefixPaths).With(labels).Set(float64(len(entry.Paths)))
+
+		for _, n := range c.exportedNeighbors() {
+			if n.State != 6 || n.Vrf != "" {
+				continue
+			}
+			_, advertised := entry.AdvertisedTo[n.key()]
+			metric(c, bgpPrefixAdvertised).With(n.labels("prefix", prefix)).Set(boolToFloat(advertised))
+		}
+	}
+}
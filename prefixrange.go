@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborAcceptedPrefixesOutOfRange = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_accepted_prefixes_out_of_range",
+		Help: "Whether the prefixes accepted from a given established BGP neighbor are below (-1), within (0) or above (1) the range configured for it, for an address family or in total (empty afi)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+// PrefixRange : This represents the acceptable number of accepted prefixes of the matching neighbors, in
+// total or for an address family (e.g. "ipv4_unicast"), e.g. 900000 to 1100000 for a full table
+type PrefixRange struct {
+	Neighbors NeighborFilter `yaml:"neighbors"`
+	AFI       string         `yaml:"afi"`
+	Min       uint64         `yaml:"min"`
+	// Max : The maximum number of prefixes, 0 for no maximum
+	Max uint64 `yaml:"max"`
+}
+
+func validatePrefixRanges(ranges []PrefixRange) error {
+	for i := range ranges {
+		r := &ranges[i]
+		if err := r.Neighbors.compile(); err != nil {
+			return fmt.Errorf("prefix range %d: %s", i+1, err)
+		}
+		if r.Max > 0 && r.Min > r.Max {
+			return fmt.Errorf("prefix range %d: min %d is above max %d", i+1, r.Min, r.Max)
+		}
+	}
+	return nil
+}
+
+// prefixRangeSamples : Returns how the accepted prefixes of the established neighbor compare with the
+// first range matching it for each address family (or the total), all neighbors matching a range
+// without neighbor filter
+func (n *BgpNeighbor) prefixRangeSamples(id neighborID) []neighborSample {
+	if n.State != 6 {
+		return nil
+	}
+	var samples []neighborSample
+	seen := make(map[string]bool)
+	for i := range config.Neighbors.PrefixRanges {
+		r := &config.Neighbors.PrefixRanges[i]
+		if seen[r.AFI] || (!r.Neighbors.empty() && !r.Neighbors.matches(n)) {
+			continue
+		}
+		accepted := n.AcceptedPrefixes
+		if r.AFI != "" {
+			af, ok := n.AddressFamilies[r.AFI]
+			if !ok {
+				continue
+			}
+			accepted = af.AcceptedPrefixes
+		}
+		seen[r.AFI] = true
+		value := 0.0
+		if accepted < float64(r.Min) {
+			value = -1
+		} else if r.Max > 0 && accepted > float64(r.Max) {
+			value = 1
+		}
+		samples = append(samples, neighborSample{bgpNeighborAcceptedPrefixesOutOfRange, id.labels("afi", r.AFI), value})
+	}
+	return samples
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var (
+	privsepUser   = flag.String("privsep.user", "", "Once the listeners are bound and the configuration and state read, switch from root to this user and its primary group, which drops all the capabilities. The files read later (e.g. the SSH keys) have to be readable by the user, and the directory of --state.file writable by it. Cannot be used with --netns or --docker.container")
+	privsepGroups = flag.String("privsep.groups", "frrvty", "The supplementary groups kept with --privsep.user, comma separated, e.g. frrvty for vtysh to reach the vty sockets. Without them, vtysh is run as another user with --vtysh.sudo-user")
+)
+
+// PrivsepIDs : This represents the user and groups which the exporter switches to
+type PrivsepIDs struct {
+	UID    int
+	GID    int
+	Groups []int
+}
+
+// privsepIDs : Returns the IDs of the user and groups of --privsep.user and --privsep.groups
+func privsepIDs() (*PrivsepIDs, error) {
+	u, err := user.Lookup(*privsepUser)
+	if err != nil {
+		return nil, fmt.Errorf("--privsep.user: %s", err)
+	}
+	ids := &PrivsepIDs{}
+	if ids.UID, err = strconv.Atoi(u.Uid); err != nil {
+		return nil, fmt.Errorf("--privsep.user: invalid uid %q", u.Uid)
+	}
+	if ids.GID, err = strconv.Atoi(u.Gid); err != nil {
+		return nil, fmt.Errorf("--privsep.user: invalid gid %q", u.Gid)
+	}
+	if ids.UID == 0 {
+		return nil, fmt.Errorf("--privsep.user: %s is root", *privsepUser)
+	}
+	ids.Groups = []int{ids.GID}
+	for _, name := range strings.Split(*privsepGroups, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return nil, fmt.Errorf("--privsep.groups: %s", err)
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return nil, fmt.Errorf("--privsep.groups: invalid gid %q", g.Gid)
+		}
+		ids.Groups = append(ids.Groups, gid)
+	}
+	return ids, nil
+}
+
+// validatePrivsep : Returns an error if the user or the groups to switch to do not exist, or if vtysh
+// is run in a way which needs the privileges dropped
+func validatePrivsep() error {
+	if *privsepUser == "" {
+		return nil
+	}
+	switch {
+	case *netns != "":
+		return fmt.Errorf("--netns cannot be used with --privsep.user, as entering the namespace requires CAP_SYS_ADMIN")
+	case *dockerContainer != "":
+		return fmt.Errorf("--docker.container cannot be used with --privsep.user, as docker exec requires root access to the Docker daemon")
+	}
+	_, err := privsepIDs()
+	return err
+}
+
+// dropPrivileges : Switches to the user of --privsep.user, if any. The switch from root clears the
+// permitted and effective capabilities of all the threads, and is checked by trying to switch back.
+func dropPrivileges() error {
+	if *privsepUser == "" {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("--privsep.user requires starting as root, running as uid %d", os.Geteuid())
+	}
+	ids, err := privsepIDs()
+	if err != nil {
+		return err
+	}
+	// The groups go first, as they can no longer be changed once the user has been
+	if err := syscall.Setgroups(ids.Groups); err != nil {
+		return fmt.Errorf("setgroups: %s", err)
+	}
+	if err := syscall.Setgid(ids.GID); err != nil {
+		return fmt.Errorf("setgid: %s", err)
+	}
+	if err := syscall.Setuid(ids.UID); err != nil {
+		return fmt.Errorf("setuid: %s", err)
+	}
+	if err := syscall.Setuid(0); err == nil {
+		return fmt.Errorf("root privileges regained after switching to %s", *privsepUser)
+	}
+	if caps := effectiveCapabilities(); caps != "" && strings.Trim(caps, "0") != "" {
+		return fmt.Errorf("capabilities %s kept after switching to %s", caps, *privsepUser)
+	}
+	// The state is saved at shutdown as the user, so a directory it cannot write to fails now rather than
+	// losing the state then
+	if *stateFile != "" {
+		f, err := os.CreateTemp(filepath.Dir(*stateFile), "."+filepath.Base(*stateFile)+".*")
+		if err != nil {
+			return fmt.Errorf("--state.file cannot be written by %s: %s", *privsepUser, err)
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+	logger.Info("Dropped the privileges", "user", *privsepUser, "uid", ids.UID, "gid", ids.GID, "groups", ids.Groups)
+	return nil
+}
+
+// effectiveCapabilities : Returns the effective capabilities of the process in hexadecimal, from
+// /proc/self/status on Linux, or an empty string if unknown
+func effectiveCapabilities() string {
+	content, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "CapEff:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "CapEff:"))
+		}
+	}
+	return ""
+}
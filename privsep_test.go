@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePrivsep(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags map[string]string
+		err   string
+	}{
+		{
+			name:  "netns",
+			flags: map[string]string{"privsep.user": "nobody", "netns": "bgp"},
+			err:   "--netns cannot be used with --privsep.user",
+		},
+		{
+			name:  "docker container",
+			flags: map[string]string{"privsep.user": "nobody", "docker.container": "frr"},
+			err:   "--docker.container cannot be used with --privsep.user",
+		},
+		{
+			name:  "unknown user",
+			flags: map[string]string{"privsep.user": "no-such-user", "privsep.groups": ""},
+			err:   "--privsep.user: user: unknown user no-such-user",
+		},
+		{
+			name:  "netns without privsep",
+			flags: map[string]string{"netns": "bgp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setFlags(t, tt.flags)
+			err := validatePrivsep()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got the error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
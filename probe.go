@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var probeOnly = flag.Bool("probe.only", false, "Only collect the routers given to /probe, without collecting the local router")
+
+// ModuleConfig : This represents a module of /probe, as for the blackbox exporter. It gives the backend,
+// credentials and platform of the probed routers, and the collectors to run (those enabled by the flags
+// if none are given).
+type ModuleConfig struct {
+	TargetConfig `yaml:",inline"`
+	Collectors   []string `yaml:"collectors"`
+}
+
+// probeStates : What is kept between the probes of a router with a module, for the flaps to be detected
+// and the counters to keep counting. They are dropped once the router is no longer probed.
+var probeStates = struct {
+	sync.Mutex
+	states map[string]*probeState
+}{states: make(map[string]*probeState)}
+
+type probeState struct {
+	target TargetConfig
+	probed time.Time
+}
+
+// probeStateExpiry : How long the state of a router no longer probed is kept
+const probeStateExpiry = time.Hour
+
+// validate : Checks the module, with a placeholder target
+func (m *ModuleConfig) validate() error {
+	for _, c := range m.Collectors {
+		if collectorNamed(c) == nil {
+			return fmt.Errorf("unknown collector %q", c)
+		}
+	}
+	t := m.TargetConfig
+	t.Name, t.Address = "module", "localhost:1"
+	return t.validate()
+}
+
+// validateModules : Checks the modules of /probe
+func validateModules(modules map[string]ModuleConfig) error {
+	for name, m := range modules {
+		if err := m.validate(); err != nil {
+			return fmt.Errorf("module %s: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// probesEnabled : Whether modules are configured for /probe
+func probesEnabled() bool {
+	return len(config.Modules) > 0
+}
+
+// moduleCollector : Whether a module runs the collector, for its metrics to be registered
+func moduleCollector(name string) bool {
+	for _, m := range config.Modules {
+		for _, c := range m.Collectors {
+			if c == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probeHandler : Collects the router given by the target parameter with the module given by the module
+// parameter, and serves its metrics along with probe_success and probe_duration_seconds
+func probeHandler(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	name := r.URL.Query().Get("module")
+	if name == "" {
+		name = "default"
+	}
+	module, ok := config.Modules[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown module %q", name), http.StatusBadRequest)
+		return
+	}
+	t, err := probeTarget(name, &module, target)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid target %q: %s", target, err), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	mfs, success := probe(r.Context(), t, &module)
+	duration := time.Since(start).Seconds()
+	logger.Debug("Probed", "target", target, "module", name, "success", success, "duration", duration)
+
+	registry := prometheus.NewRegistry()
+	probeSuccess := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Whether all the collectors of the probe succeeded",
+	})
+	probeDuration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "The duration of the probe in seconds",
+	})
+	if success {
+		probeSuccess.Set(1)
+	}
+	probeDuration.Set(duration)
+	registry.MustRegister(probeSuccess, probeDuration)
+
+	gatherer := prometheus.Gatherers{registry, prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		// The families are kept for the next probe, so they are copied with their metrics before being renamed,
+		// rescaled or labeled
+		families := make([]*dto.MetricFamily, len(mfs))
+		for i, mf := range mfs {
+			families[i] = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type, Metric: copyMetrics(mf.Metric)}
+		}
+		return families, nil
+	})}
+	promhttp.HandlerFor(exporterGatherer(gatherer), promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// probeTarget : Returns the target of the router probed with the module, keeping its state of the previous probes
+func probeTarget(name string, module *ModuleConfig, address string) (*TargetConfig, error) {
+	probeStates.Lock()
+	defer probeStates.Unlock()
+
+	key := name + "|" + address
+	for k, s := range probeStates.states {
+		if time.Since(s.probed) > probeStateExpiry {
+			delete(probeStates.states, k)
+		}
+	}
+	s, ok := probeStates.states[key]
+	if !ok {
+		t, err := discoveredTarget(module.TargetConfig, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		s = &probeState{target: t}
+		probeStates.states[key] = s
+	}
+	s.probed = time.Now()
+	return &s.target, nil
+}
+
+// probe : Collects the target with the collectors of the module, returning its metrics and whether all
+// the collectors succeeded
+func probe(ctx context.Context, t *TargetConfig, module *ModuleConfig) ([]*dto.MetricFamily, bool) {
+	var collectors map[string]bool
+	if len(module.Collectors) > 0 {
+		collectors = make(map[string]bool)
+		for _, c := range module.Collectors {
+			collectors[c] = true
+		}
+	}
+	mfs, success := collectTarget(ctx, t, collectors)
+	mfs = accumulateCounters(t, mfs)
+	targetMetrics.Lock()
+	t.state.metrics = mfs
+	targetMetrics.Unlock()
+	return mfs, success
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// probeRouter : Returns the metrics served by /probe for the query
+func probeRouter(t *testing.T, query url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	probeHandler(w, httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil))
+	return w
+}
+
+func TestProbeHandler(t *testing.T) {
+	neighbors := readFile(t, "testdata/frr/show_bgp_neighbors_json.txt")
+	summary := readFile(t, "testdata/frr/show_ip_bgp_summary.txt")
+	s := startSSHServer(t, map[string]string{"frr": "secret"}, func(user, command string) string {
+		switch {
+		case strings.Contains(command, "show bgp neighbors json"):
+			return neighbors
+		case strings.Contains(command, "show ip bgp summary"):
+			return summary
+		}
+		return "% Unknown command: " + command + "\n"
+	})
+	setFlags(t, map[string]string{"vtysh.neighbors-json": "always", "vtysh.retries": "0"})
+	module := func(password string) ModuleConfig {
+		return ModuleConfig{
+			TargetConfig: TargetConfig{SSHConfig: SSHConfig{User: "frr", Password: password, InsecureIgnoreHostKey: true}},
+			Collectors:   []string{"summary"},
+		}
+	}
+	config = &Config{Modules: map[string]ModuleConfig{"default": module("secret"), "wrong": module("wrong")}}
+	collectors := targetCollectors
+	t.Cleanup(func() { targetCollectors = collectors })
+	targetCollectors = []prometheus.Collector{bgpNeighborState, bgpNeighborPrefixesReceived, bgpScrapeCollectorSuccess}
+	t.Cleanup(func() {
+		probeStates.Lock()
+		probeStates.states = make(map[string]*probeState)
+		probeStates.Unlock()
+	})
+
+	// The router is collected with the collectors of the module only
+	w := probeRouter(t, url.Values{"target": {s.address}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("got the status %d: %s", w.Code, w.Body)
+	}
+	want := `# HELP bgp_neighbor_prefixes_received The number of prefixes received from a given BGP neighbor for an address family (PfxRcd)
+# TYPE bgp_neighbor_prefixes_received gauge
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.1",view=""} 12
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.5",view=""} 0
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="swp1",ip="",view=""} 100
+# HELP bgp_neighbor_state The state of the connection to a given BGP neighbor (1=idle,2=connect,3=active,4=opensent,5=openconfirm,6=established,7=clearing,8=deleted)
+# TYPE bgp_neighbor_state gauge
+bgp_neighbor_state{interface="",ip="10.0.0.1",view=""} 6
+bgp_neighbor_state{interface="",ip="10.0.0.5",view=""} 1
+bgp_neighbor_state{interface="swp1",ip="fe80::4638:39ff:fe00:5c",view=""} 6
+# HELP bgp_scrape_collector_success Whether the last run of a given collector succeeded
+# TYPE bgp_scrape_collector_success gauge
+bgp_scrape_collector_success{collector="neighbors"} 1
+bgp_scrape_collector_success{collector="summary"} 1
+# HELP probe_duration_seconds The duration of the probe in seconds
+# TYPE probe_duration_seconds gauge
+# HELP probe_success Whether all the collectors of the probe succeeded
+# TYPE probe_success gauge
+probe_success 1
+`
+	if got := probeBody(w); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// A router which cannot be collected with the module fails the probe, rather than the request
+	w = probeRouter(t, url.Values{"target": {s.address}, "module": {"wrong"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "\nprobe_success 0\n") {
+		t.Errorf("got the status %d: %s", w.Code, w.Body)
+	}
+}
+
+// probeBody : Returns the metrics served without the duration of the probe, which varies
+func probeBody(w *httptest.ResponseRecorder) string {
+	var lines []string
+	for _, line := range strings.SplitAfter(w.Body.String(), "\n") {
+		if !strings.HasPrefix(line, "probe_duration_seconds ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func TestProbeHandlerInvalid(t *testing.T) {
+	setFlags(t, nil)
+	config = &Config{Modules: map[string]ModuleConfig{"default": {TargetConfig: TargetConfig{SSHConfig: SSHConfig{User: "frr", Password: "secret", InsecureIgnoreHostKey: true}}}}}
+	tests := []struct {
+		name  string
+		query url.Values
+		want  string
+	}{
+		{"no target", url.Values{}, "target parameter is missing"},
+		{"unknown module", url.Values{"target": {"192.0.2.1"}, "module": {"nb"}}, `unknown module "nb"`},
+		{"invalid target", url.Values{"target": {"[192.0.2.1"}}, `invalid target "[192.0.2.1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := probeRouter(t, tt.query)
+			if w.Code != http.StatusBadRequest || !strings.HasPrefix(w.Body.String(), tt.want) {
+				t.Errorf("got the status %d: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+var (
+	pushURL      = flag.String("push.url", "", "Push the metrics to this Pushgateway after each collection, e.g. http://pushgateway:9091")
+	pushJob      = flag.String("push.job", "bgp_exporter", "The job label of the pushed metrics")
+	pushInstance = flag.String("push.instance", "", "The instance label of the pushed metrics (defaults to the hostname)")
+	pushTimeout  = flag.Duration("push.timeout", 10*time.Second, "Timeout for pushing the metrics")
+)
+
+// pushMetrics : Replaces the metrics of this exporter on the Pushgateway
+func pushMetrics() {
+	instance := *pushInstance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	err := push.New(*pushURL, *pushJob).
+		Gatherer(exporterGatherer(targetsGatherer(prometheus.DefaultGatherer))).
+		Grouping("instance", instance).
+		Client(&http.Client{Timeout: *pushTimeout}).
+		Push()
+	if err != nil {
+		logger.Error("Failed to push the metrics", "url", *pushURL, "err", err)
+		recordError(localTarget, err.Error())
+	}
+}
@@ -17,7 +17,10 @@ set -e
 
 TAG=$1
 
-go build -o bgp_exporter main.go
+COMMIT=$(git rev-parse HEAD)
+DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+
+go build -o bgp_exporter -ldflags "-X main.version=$TAG -X main.commit=$COMMIT -X main.buildDate=$DATE" .
 
 github-release release --user $USER --repo $REPO --tag $TAG --name "BGP Exporter"
 github-release upload  --user $USER --repo $REPO --tag $TAG --name bgp_exporter-$TAG.linux-amd64  --file ./bgp_exporter
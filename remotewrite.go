@@ -0,0 +1,217 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative prompb/remote.proto
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fiveai/bgp-exporter/prompb"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	remoteWriteURL        = flag.String("remote-write.url", "", "Send the metrics to this Prometheus remote_write endpoint after each collection, e.g. http://mimir/api/v1/push")
+	remoteWriteJob        = flag.String("remote-write.job", "bgp_exporter", "The job label added to the metrics sent with remote_write")
+	remoteWriteInstance   = flag.String("remote-write.instance", "", "The instance label added to the metrics sent with remote_write (defaults to the hostname)")
+	remoteWriteTimeout    = flag.Duration("remote-write.timeout", 30*time.Second, "Timeout for a remote_write request")
+	remoteWriteBufferSize = flag.Int("remote-write.buffer-size", 360, "The number of collections kept in memory while the remote_write endpoint is unavailable")
+)
+
+// remoteWriteBuffer : This holds the encoded collections which have not been sent yet, oldest first
+var remoteWriteBuffer = struct {
+	sync.Mutex
+	requests [][]byte
+}{}
+
+// remoteWriteError : This represents a request which was rejected and must not be retried
+type remoteWriteError struct {
+	status int
+	body   string
+}
+
+func (e *remoteWriteError) Error() string {
+	return fmt.Sprintf("remote_write rejected with status %d: %s", e.status, e.body)
+}
+
+// remoteWriteQueued : Wakes up the sender once a collection is queued
+var remoteWriteQueued = make(chan struct{}, 1)
+
+// remoteWrite : Queues the current metrics for the sender, without waiting for the endpoint. The oldest
+// collections are dropped once the buffer is full.
+func remoteWrite() {
+	mfs, err := exporterGatherer(targetsGatherer(prometheus.DefaultGatherer)).Gather()
+	if err != nil {
+		logger.Error("Failed to gather the metrics for remote_write", "err", err)
+		recordError(localTarget, err.Error())
+		return
+	}
+
+	instance := *remoteWriteInstance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	req, err := encodeWriteRequest(mfs, time.Now(), map[string]string{"job": *remoteWriteJob, "instance": instance})
+	if err != nil {
+		logger.Error("Failed to encode the metrics for remote_write", "err", err)
+		recordError(localTarget, err.Error())
+		return
+	}
+	queueWriteRequest(snappy.Encode(nil, req))
+	select {
+	case remoteWriteQueued <- struct{}{}:
+	default:
+	}
+}
+
+// queueWriteRequest : Queues the request after those already buffered, dropping the oldest ones once the
+// buffer is full
+func queueWriteRequest(request []byte) {
+	remoteWriteBuffer.Lock()
+	defer remoteWriteBuffer.Unlock()
+	remoteWriteBuffer.requests = append(remoteWriteBuffer.requests, request)
+	if dropped := len(remoteWriteBuffer.requests) - *remoteWriteBufferSize; dropped > 0 {
+		logger.Warn("Dropping buffered remote_write collections", "count", dropped)
+		remoteWriteBuffer.requests = remoteWriteBuffer.requests[dropped:]
+	}
+}
+
+// sendRemoteWrite : Sends the queued collections as they are queued, oldest first, until the context is
+// cancelled. Collections which could not be sent are retried once the next one is queued.
+func sendRemoteWrite(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-remoteWriteQueued:
+		}
+		for {
+			remoteWriteBuffer.Lock()
+			if len(remoteWriteBuffer.requests) == 0 {
+				remoteWriteBuffer.Unlock()
+				break
+			}
+			body := remoteWriteBuffer.requests[0]
+			remoteWriteBuffer.requests = remoteWriteBuffer.requests[1:]
+			buffered := len(remoteWriteBuffer.requests)
+			remoteWriteBuffer.Unlock()
+
+			err := sendWriteRequest(ctx, body)
+			if _, rejected := err.(*remoteWriteError); rejected {
+				logger.Error("Dropping the metrics rejected by the remote_write endpoint", "url", *remoteWriteURL, "err", err)
+				recordError(localTarget, err.Error())
+			} else if err != nil {
+				logger.Error("Failed to send the metrics with remote_write, will retry", "url", *remoteWriteURL, "buffered", buffered+1, "err", err)
+				recordError(localTarget, err.Error())
+				// The collection is sent again first, before those queued meanwhile, and dropped first
+				// once the buffer is full
+				remoteWriteBuffer.Lock()
+				remoteWriteBuffer.requests = append([][]byte{body}, remoteWriteBuffer.requests...)
+				remoteWriteBuffer.Unlock()
+				break
+			}
+		}
+	}
+}
+
+// sendWriteRequest : Sends a snappy compressed write request
+func sendWriteRequest(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, *remoteWriteTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, *remoteWriteURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "bgp_exporter")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	// Client errors other than rate limiting will fail again, so they are not retried
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests {
+		return &remoteWriteError{status: resp.StatusCode, body: string(bytes.TrimSpace(msg))}
+	}
+	return fmt.Errorf("remote_write failed with status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+}
+
+// encodeWriteRequest : Encodes the metric families as a remote_write WriteRequest protobuf message
+func encodeWriteRequest(mfs []*dto.MetricFamily, now time.Time, extra map[string]string) ([]byte, error) {
+	ts := now.UnixNano() / int64(time.Millisecond)
+	req := &prompb.WriteRequest{}
+	series := func(name string, m *dto.Metric, value float64, labels ...string) {
+		l := map[string]string{"__name__": name}
+		for k, v := range extra {
+			l[k] = v
+		}
+		for _, lp := range m.GetLabel() {
+			l[lp.GetName()] = lp.GetValue()
+		}
+		for i := 0; i+1 < len(labels); i += 2 {
+			l[labels[i]] = labels[i+1]
+		}
+		names := make([]string, 0, len(l))
+		for k, v := range l {
+			// Empty label values are the same as missing labels and must not be sent
+			if v != "" {
+				names = append(names, k)
+			}
+		}
+		sort.Strings(names)
+
+		s := &prompb.TimeSeries{Samples: []*prompb.Sample{{Value: value, Timestamp: ts}}}
+		for _, k := range names {
+			s.Labels = append(s.Labels, &prompb.Label{Name: k, Value: l[k]})
+		}
+		req.Timeseries = append(req.Timeseries, s)
+	}
+
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, m := range mf.GetMetric() {
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				series(name, m, m.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				series(name, m, m.GetGauge().GetValue())
+			case dto.MetricType_UNTYPED:
+				series(name, m, m.GetUntyped().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.GetSummary().GetQuantile() {
+					series(name, m, q.GetValue(), "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64))
+				}
+				series(name+"_sum", m, m.GetSummary().GetSampleSum())
+				series(name+"_count", m, float64(m.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				for _, b := range m.GetHistogram().GetBucket() {
+					series(name+"_bucket", m, float64(b.GetCumulativeCount()), "le", strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64))
+				}
+				series(name+"_bucket", m, float64(m.GetHistogram().GetSampleCount()), "le", "+Inf")
+				series(name+"_sum", m, m.GetHistogram().GetSampleSum())
+				series(name+"_count", m, float64(m.GetHistogram().GetSampleCount()))
+			}
+		}
+	}
+	return proto.Marshal(req)
+}
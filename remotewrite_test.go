@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fiveai/bgp-exporter/prompb"
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncodeWriteRequest(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	extra := map[string]string{"job": "bgp_exporter", "instance": ""}
+	tests := []struct {
+		name string
+		mf   *dto.MetricFamily
+		// want : The WriteRequest, field by field
+		want []string
+	}{
+		{
+			name: "gauge",
+			mf: &dto.MetricFamily{
+				Name: proto.String("bgp_neighbor_state"),
+				Type: dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{
+					Label: []*dto.LabelPair{{Name: proto.String("neighbor"), Value: proto.String("10.0.0.1")}},
+					Gauge: &dto.Gauge{Value: proto.Float64(6)},
+				}},
+			},
+			want: []string{
+				"0a5d",                                                                     // timeseries
+				"0a1e", "0a085f5f6e616d655f5f", "12126267705f6e65696768626f725f7374617465", // __name__="bgp_neighbor_state"
+				"0a13", "0a036a6f62", "120c6267705f6578706f72746572", // job="bgp_exporter"
+				"0a14", "0a086e65696768626f72", "120831302e302e302e31", // neighbor="10.0.0.1"
+				"1210", "090000000000001840", "10fbd095ffbc31", // 6 at 1700000000123
+			},
+		},
+		{
+			name: "counter without the empty labels",
+			mf: &dto.MetricFamily{
+				Name: proto.String("bgp_updates_total"),
+				Type: dto.MetricType_COUNTER.Enum(),
+				Metric: []*dto.Metric{{
+					Label:   []*dto.LabelPair{{Name: proto.String("neighbor"), Value: proto.String("")}},
+					Counter: &dto.Counter{Value: proto.Float64(1234.5)},
+				}},
+			},
+			want: []string{
+				"0a46",                                                                   // timeseries
+				"0a1d", "0a085f5f6e616d655f5f", "12116267705f757064617465735f746f74616c", // __name__="bgp_updates_total"
+				"0a13", "0a036a6f62", "120c6267705f6578706f72746572", // job="bgp_exporter"
+				"1210", "0900000000004a9340", "10fbd095ffbc31", // 1234.5 at 1700000000123
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := encodeWriteRequest([]*dto.MetricFamily{tt.mf}, now, extra)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := hex.EncodeToString(req)
+			if want := strings.Join(tt.want, ""); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+// TestRemoteWriteStalledEndpoint : Checks that the collections are queued without waiting for an endpoint
+// which does not answer, and sent once it answers again
+func TestRemoteWriteStalledEndpoint(t *testing.T) {
+	release := make(chan struct{})
+	received := make(chan *prompb.WriteRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		b, _ := io.ReadAll(r.Body)
+		b, err := snappy.Decode(nil, b)
+		req := &prompb.WriteRequest{}
+		if err == nil {
+			err = proto.Unmarshal(b, req)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- req
+	}))
+	defer server.Close()
+	defer close(release)
+	setFlags(t, map[string]string{"remote-write.url": server.URL, "remote-write.job": "bgp"})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		sendRemoteWrite(ctx)
+	}()
+	// The sender is stopped before the flags it reads are restored
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+	t.Cleanup(func() {
+		remoteWriteBuffer.Lock()
+		remoteWriteBuffer.requests = nil
+		remoteWriteBuffer.Unlock()
+	})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		remoteWrite()
+		time.Sleep(50 * time.Millisecond)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("the collections waited %s for the endpoint", d)
+	}
+
+	for i := 0; i < 3; i++ {
+		release <- struct{}{}
+		select {
+		case req := <-received:
+			if len(req.Timeseries) == 0 {
+				t.Fatal("no time series sent")
+			}
+			job := false
+			for _, l := range req.Timeseries[0].Labels {
+				job = job || l.Name == "job" && l.Value == "bgp"
+			}
+			if !job {
+				t.Errorf("the time series has no job label: %v", req.Timeseries[0].Labels)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d collections, want 3", i)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpRpkiCacheConnected = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rpki_cache_connected",
+		Help: "Whether the RTR session to a given RPKI cache (validator) is connected (1=connected,0=not connected)",
+	},
+		[]string{
+			"cache",
+			"port",
+			"preference",
+		})
+)
+
+var (
+	bgpRpkiRoaPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rpki_roa_prefixes",
+		Help: "The number of ROA prefixes received from the RPKI caches",
+	},
+		[]string{
+			"afi",
+		})
+)
+
+var (
+	bgpRpkiPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rpki_prefixes",
+		Help: "The number of prefixes in the BGP table by RPKI origin validation state (valid, invalid, notfound)",
+	},
+		[]string{
+			"afi",
+			"state",
+		})
+)
+
+// BgpRpkiCache : This represents a RPKI cache server as configured in bgpd
+type BgpRpkiCache struct {
+	Host       string
+	Port       string
+	Preference string
+	Connected  bool
+}
+
+var bgpRpkiConnectedGroupRegex = regexp.MustCompile(`^Connected to group (\d+)`)
+var bgpRpkiCacheRegex = regexp.MustCompile(`^rpki (?:tcp|ssh) cache (\S+) (\d+)(?: \S+)*? pref (\d+)(?: \((\w+)\))?`)
+var bgpRpkiPrefixCountRegex = regexp.MustCompile(`^Number of (IPv4|IPv6) Prefixes: (\d+)`)
+var bgpDisplayedRoutesRegex = regexp.MustCompile(`^Displayed\s+(\d+) routes`)
+
+var bgpRpkiStates = []string{"valid", "invalid", "notfound"}
+
+func recordRpkiMetrics(c *collection) {
+	// This fails when bgpd has not been started with the rpki module
+	o, err := c.vtysh("show rpki cache-connection")
+	if err != nil {
+		c.collectorFailed("rpki", err)
+		return
+	}
+	metric(c, bgpRpkiCacheConnected).Reset()
+	for _, cache := range parseRpkiCaches(o) {
+		metric(c, bgpRpkiCacheConnected).With(prometheus.Labels{"cache": cache.Host, "port": cache.Port, "preference": cache.Preference}).Set(boolToFloat(cache.Connected))
+	}
+
+	o, err = c.vtysh("show rpki prefix-count")
+	if err != nil {
+		c.collectorFailed("rpki", err)
+		return
+	}
+	for _, line := range strings.Split(o, "\n") {
+		if m := bgpRpkiPrefixCountRegex.FindStringSubmatch(line); m != nil {
+			count, _ := strconv.ParseFloat(m[2], 64)
+			metric(c, bgpRpkiRoaPrefixes).With(prometheus.Labels{"afi": strings.ToLower(m[1])}).Set(count)
+		}
+	}
+
+	if !*collectRpkiPrefixes {
+		return
+	}
+	// bgpd only counts the routes of a validation state by listing them, so their lines are counted as
+	// they arrive rather than buffered
+	for _, afi := range []string{"ipv4", "ipv6"} {
+		for _, state := range bgpRpkiStates {
+			var count float64
+			err = c.vtyshStream(fmt.Sprintf("show bgp %s unicast rpki %s", afi, state), func(r io.Reader) {
+				count = parseDisplayedRoutes(r)
+			})
+			if err != nil {
+				c.collectorFailed("rpki", err)
+				return
+			}
+			metric(c, bgpRpkiPrefixes).With(prometheus.Labels{"afi": afi + "_unicast", "state": state}).Set(count)
+		}
+	}
+}
+
+func parseRpkiCaches(s string) []BgpRpkiCache {
+	var caches []BgpRpkiCache
+	group := ""
+	for _, line := range strings.Split(s, "\n") {
+		if m := bgpRpkiConnectedGroupRegex.FindStringSubmatch(line); m != nil {
+			group = m[1]
+			continue
+		}
+		if m := bgpRpkiCacheRegex.FindStringSubmatch(line); m != nil {
+			caches = append(caches, BgpRpkiCache{
+				Host:       m[1],
+				Port:       m[2],
+				Preference: m[3],
+				// Older versions only print the preference of the connected group
+				Connected: m[4] == "connected" || (m[4] == "" && m[3] == group),
+			})
+		}
+	}
+	return caches
+}
+
+// parseDisplayedRoutes : Returns the number of routes from the footer of a "show bgp" table
+func parseDisplayedRoutes(r io.Reader) float64 {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if m := bgpDisplayedRoutesRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			count, _ := strconv.ParseFloat(m[1], 64)
+			return count
+		}
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseRpkiCaches(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []BgpRpkiCache
+	}{
+		{
+			name: "connected state",
+			output: `Connected to group 1
+rpki tcp cache 192.0.2.10 8282 pref 1 (connected)
+rpki tcp cache 192.0.2.11 8282 pref 2 (disconnected)
+rpki ssh cache 192.0.2.12 22 rpki-user pref 3
+`,
+			want: []BgpRpkiCache{
+				{Host: "192.0.2.10", Port: "8282", Preference: "1", Connected: true},
+				{Host: "192.0.2.11", Port: "8282", Preference: "2"},
+				{Host: "192.0.2.12", Port: "22", Preference: "3"},
+			},
+		},
+		{
+			// Older versions only print the preference of the connected group
+			name: "connected group",
+			output: `Connected to group 2
+rpki tcp cache 192.0.2.10 8282 pref 1
+rpki tcp cache 192.0.2.11 8282 pref 2
+`,
+			want: []BgpRpkiCache{
+				{Host: "192.0.2.10", Port: "8282", Preference: "1"},
+				{Host: "192.0.2.11", Port: "8282", Preference: "2", Connected: true},
+			},
+		},
+		{
+			name:   "no connection",
+			output: "No connection to RPKI cache server.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRpkiCaches(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+const rpkiValidRoutes = `BGP table version is 12, local router ID is 10.0.0.2, vrf id 0
+Default local pref 100, local AS 65000
+Status codes:  s suppressed, d damped, h history, * valid, > best, = multipath,
+               i internal, r RIB-failure, S Stale, R Removed
+Nexthop codes: @NNN nexthop's vrf id, < announce-nh-self
+Origin codes:  i - IGP, e - EGP, ? - incomplete
+RPKI validation codes: V valid, I invalid, N Not found
+
+   Network          Next Hop            Metric LocPrf Weight Path
+V*> 1.0.0.0/24       10.0.0.1                               0 65001 13335 i
+V*> 8.8.8.0/24       10.0.0.1                               0 65001 15169 i
+
+Displayed  2 routes and 2 total paths
+`
+
+func TestRecordRpkiMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show rpki cache-connection":          "Connected to group 1\nrpki tcp cache 192.0.2.10 8282 pref 1 (connected)\n",
+		"show rpki prefix-count":              "Number of IPv4 Prefixes: 480210\nNumber of IPv6 Prefixes: 110420\n",
+		"show bgp ipv4 unicast rpki valid":    rpkiValidRoutes,
+		"show bgp ipv4 unicast rpki invalid":  "",
+		"show bgp ipv4 unicast rpki notfound": "",
+		"show bgp ipv6 unicast rpki valid":    "",
+		"show bgp ipv6 unicast rpki invalid":  "",
+		"show bgp ipv6 unicast rpki notfound": "",
+	}, bgpRpkiCacheConnected, bgpRpkiRoaPrefixes, bgpRpkiPrefixes)
+	recordRpkiMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	want := `
+# HELP bgp_rpki_cache_connected Whether the RTR session to a given RPKI cache (validator) is connected (1=connected,0=not connected)
+# TYPE bgp_rpki_cache_connected gauge
+bgp_rpki_cache_connected{cache="192.0.2.10",port="8282",preference="1"} 1
+# HELP bgp_rpki_roa_prefixes The number of ROA prefixes received from the RPKI caches
+# TYPE bgp_rpki_roa_prefixes gauge
+bgp_rpki_roa_prefixes{afi="ipv4"} 480210
+bgp_rpki_roa_prefixes{afi="ipv6"} 110420
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+
+	// The routes of each validation state are only listed on demand
+	setFlags(t, map[string]string{"collector.rpki.prefixes": "true"})
+	recordRpkiMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	want += `# HELP bgp_rpki_prefixes The number of prefixes in the BGP table by RPKI origin validation state (valid, invalid, notfound)
+# TYPE bgp_rpki_prefixes gauge
+bgp_rpki_prefixes{afi="ipv4_unicast",state="invalid"} 0
+bgp_rpki_prefixes{afi="ipv4_unicast",state="notfound"} 0
+bgp_rpki_prefixes{afi="ipv4_unicast",state="valid"} 2
+bgp_rpki_prefixes{afi="ipv6_unicast",state="invalid"} 0
+bgp_rpki_prefixes{afi="ipv6_unicast",state="notfound"} 0
+bgp_rpki_prefixes{afi="ipv6_unicast",state="valid"} 0
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRecordRpkiMetricsWithoutRpki : Checks that the collector fails on a bgpd without the rpki module
+func TestRecordRpkiMetricsWithoutRpki(t *testing.T) {
+	c := testCollection(t, nil, bgpRpkiCacheConnected)
+	recordRpkiMetrics(c)
+	if c.failures != 1 {
+		t.Errorf("got %d failures, want 1", c.failures)
+	}
+}
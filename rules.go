@@ -0,0 +1,144 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+var rulesFor = flag.Duration("rules.for", 5*time.Minute, "generate-rules: how long a condition must hold before the alert fires")
+var rulesPrefixDropPercent = flag.Float64("rules.prefix-drop-percent", 20, "generate-rules: the drop of the accepted prefixes of a neighbor over the last hour (percent) which is alerted on")
+var rulesFlapsPerHour = flag.Int("rules.flaps-per-hour", 3, "generate-rules: the number of flaps of a neighbor in an hour which is alerted on")
+var rulesPrefixLimitPercent = flag.Float64("rules.prefix-limit-percent", 90, "generate-rules: the share of the configured maximum prefixes (percent) which is alerted on")
+
+// RuleGroups : This represents a Prometheus rules file
+type RuleGroups struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup : This represents a group of recording and alerting rules
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule : This represents a recording rule (Record set) or an alerting rule (Alert set)
+type Rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// generateRules : Prints recording and alerting rules matching the metric names and labels
+// exported with the current flags (metric prefix, compliant names and state set)
+func generateRules(w io.Writer) error {
+	if err := checkMetricPrefix(); err != nil {
+		return err
+	}
+	m := metricName
+	neighbor := "on(instance, ip, interface, view)"
+	notEstablished := m("neighbor_state") + " != 6"
+	if *stateSet {
+		notEstablished = m("neighbor_state") + `{state="established"} == 0`
+	}
+	forDuration := promDuration(*rulesFor)
+
+	rules := RuleGroups{Groups: []RuleGroup{
+		{
+			Name: "bgp.rules",
+			Rules: []Rule{
+				{
+					Record: "instance:" + m("neighbors_established") + ":count",
+					Expr:   "sum by (instance) (" + m("neighbors_by_state") + `{state="established"})`,
+				},
+				{
+					Record: "instance:" + m("neighbor_accepted_prefixes") + ":sum",
+					Expr:   "sum by (instance) (" + m("neighbor_accepted_prefixes") + ")",
+				},
+				{
+					Record: "neighbor:" + m("neighbor_flaps") + ":increase1h",
+					Expr:   "increase(" + m("neighbor_flaps_total") + "[1h])",
+				},
+			},
+		},
+		{
+			Name: "bgp.alerts",
+			Rules: []Rule{
+				{
+					Alert: "BgpNeighborDown",
+					// Neighbors shut down on purpose are not alerted on
+					Expr:        notEstablished + " unless " + neighbor + " " + m("neighbor_admin_shutdown") + " == 1",
+					For:         forDuration,
+					Labels:      map[string]string{"severity": "critical"},
+					Annotations: map[string]string{"summary": "BGP session to {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} is down"},
+				},
+				{
+					Alert:       "BgpNeighborMissing",
+					Expr:        m("neighbor_expected_missing") + " == 1",
+					For:         forDuration,
+					Labels:      map[string]string{"severity": "critical"},
+					Annotations: map[string]string{"summary": "Expected BGP neighbor {{ $labels.ip }}{{ $labels.interface }} (AS {{ $labels.remote_as }}) is not configured on {{ $labels.instance }}"},
+				},
+				{
+					Alert:       "BgpNeighborPrefixesDropped",
+					Expr:        fmt.Sprintf("%s < %g * max_over_time(%s[1h])", m("neighbor_accepted_prefixes"), 1-*rulesPrefixDropPercent/100, m("neighbor_accepted_prefixes")),
+					For:         forDuration,
+					Labels:      map[string]string{"severity": "warning"},
+					Annotations: map[string]string{"summary": fmt.Sprintf("BGP neighbor {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} lost more than %g%% of its accepted prefixes in the last hour", *rulesPrefixDropPercent)},
+				},
+				{
+					Alert:       "BgpNeighborPrefixesOutOfRange",
+					Expr:        m("neighbor_accepted_prefixes_out_of_range") + " != 0",
+					For:         forDuration,
+					Labels:      map[string]string{"severity": "warning"},
+					Annotations: map[string]string{"summary": "BGP neighbor {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} accepts {{ if eq $value -1.0 }}fewer{{ else }}more{{ end }} prefixes than its configured range"},
+				},
+				{
+					Alert:       "BgpNeighborFlapping",
+					Expr:        fmt.Sprintf("increase(%s[1h]) >= %d", m("neighbor_flaps_total"), *rulesFlapsPerHour),
+					Labels:      map[string]string{"severity": "warning"},
+					Annotations: map[string]string{"summary": "BGP session to {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} flapped {{ $value }} times in the last hour"},
+				},
+				{
+					Alert: "BgpNeighborPrefixLimitApproaching",
+					// The received prefixes are exported per address family from the summary, as the maximum prefixes are
+					Expr:        fmt.Sprintf("100 * %s / on(instance, ip, interface, view, afi) %s > %g", m("neighbor_prefixes_received"), m("neighbor_maximum_prefixes"), *rulesPrefixLimitPercent),
+					For:         forDuration,
+					Labels:      map[string]string{"severity": "warning"},
+					Annotations: map[string]string{"summary": "BGP neighbor {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} is at {{ $value }}% of its maximum prefixes for {{ $labels.afi }}"},
+				},
+				{
+					Alert: "BgpNeighborHoldTimerExpiring",
+					// Less than a keepalive interval left means that the last keepalives were not read
+					Expr:        fmt.Sprintf("%s < %s", m("neighbor_hold_timer_remaining_seconds"), m("neighbor_keepalive_interval_seconds")),
+					Labels:      map[string]string{"severity": "warning"},
+					Annotations: map[string]string{"summary": "BGP session to {{ $labels.ip }}{{ $labels.interface }} on {{ $labels.instance }} has not been read from for a while, its hold timer expires in {{ $value }}s"},
+				},
+			},
+		},
+	}}
+
+	out, err := yaml.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// promDuration : Formats a duration the way Prometheus durations are usually written, e.g. "5m" rather than "5m0s"
+func promDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPSDGroup : This represents a group of targets in the Prometheus http_sd format
+type HTTPSDGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// sdHandler : Serves the routers of multi-router mode, or with ?type=neighbors their neighbors, for the
+// http_sd_configs of Prometheus. A group is served per router or neighbor so that each gets its labels.
+func sdHandler(w http.ResponseWriter, r *http.Request) {
+	groups := []HTTPSDGroup{}
+	switch r.URL.Query().Get("type") {
+	case "", "routers":
+		for _, t := range activeTargets() {
+			groups = append(groups, HTTPSDGroup{
+				Targets: []string{t.Address},
+				Labels:  map[string]string{"router": t.Name, "backend": t.Backend, "platform": t.Platform},
+			})
+		}
+	case "neighbors":
+		if multiRouter() {
+			for _, t := range activeTargets() {
+				groups = append(groups, neighborGroups(t.Name, t.state.neighbors.List())...)
+			}
+		} else {
+			groups = neighborGroups("", localTargetConfig.state.neighbors.List())
+		}
+	default:
+		http.Error(w, "type must be routers or neighbors", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(groups); err != nil {
+		logger.Error("Failed to encode the service discovery targets", "err", err)
+	}
+}
+
+// neighborGroups : Returns a group per neighbor, with the labels of the per neighbor metrics
+func neighborGroups(router string, neighbors []BgpNeighbor) []HTTPSDGroup {
+	var groups []HTTPSDGroup
+	for _, n := range neighbors {
+		if n.IP == nil {
+			continue
+		}
+		labels := map[string]string(n.labels())
+		if router != "" {
+			labels["router"] = router
+		}
+		groups = append(groups, HTTPSDGroup{Targets: []string{n.IP.String()}, Labels: labels})
+	}
+	return groups
+}
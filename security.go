@@ -0,0 +1,115 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborTTLSecurity = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_ttl_security",
+		Help: "Whether TTL security (GTSM) is configured for a given BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborAuthentication = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_authentication",
+		Help: "The TCP authentication of the session to a given BGP neighbor (method none, md5 or tcp_ao)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"method",
+		})
+)
+
+// BgpNeighborSecurity : This represents the security settings of a neighbor (or peer group) in the configuration
+type BgpNeighborSecurity struct {
+	TTLSecurity bool
+	Password    bool
+}
+
+var bgpRouterRegex = regexp.MustCompile(`^router bgp \d+(?: vrf (\S+))?`)
+var bgpNeighborSecurityRegex = regexp.MustCompile(`^ neighbor (\S+) (password|ttl-security hops) `)
+
+// recordSecurityMetrics : Exports whether TTL security and TCP authentication apply to the neighbors.
+// Cisco IOS shows them in "show ip bgp neighbors", while FRR only has them in its configuration, where
+// they are set per neighbor or inherited from its peer group. FRR only supports MD5 authentication.
+func recordSecurityMetrics(c *collection) {
+	var settings map[string]map[string]*BgpNeighborSecurity
+	if !c.ios() {
+		o, err := c.vtysh("show running-config")
+		if err != nil {
+			c.collectorFailed("security", err)
+			return
+		}
+		settings = parseNeighborSecurity(o)
+	}
+
+	metric(c, bgpNeighborTTLSecurity).Reset()
+	metric(c, bgpNeighborAuthentication).Reset()
+	for _, n := range c.exportedNeighbors() {
+		if !c.ios() {
+			n.Authentication = ""
+			for _, name := range []string{n.PeerGroup, n.key()} {
+				if s, ok := settings[n.Vrf][name]; ok {
+					n.TTLSecurity = n.TTLSecurity || s.TTLSecurity
+					if s.Password {
+						n.Authentication = "md5"
+					}
+				}
+			}
+		}
+		method := n.Authentication
+		if method == "" {
+			method = "none"
+		}
+		metric(c, bgpNeighborTTLSecurity).With(n.labels()).Set(boolToFloat(n.TTLSecurity))
+		metric(c, bgpNeighborAuthentication).With(n.labels("method", method)).Set(1)
+	}
+}
+
+// parseNeighborSecurity : Parses the passwords and TTL security of the neighbors and peer groups in the
+// configuration of bgpd, by view (VRF) and neighbor address, interface or peer group
+func parseNeighborSecurity(s string) map[string]map[string]*BgpNeighborSecurity {
+	settings := make(map[string]map[string]*BgpNeighborSecurity)
+	var view map[string]*BgpNeighborSecurity
+	for _, line := range strings.Split(s, "\n") {
+		if m := bgpRouterRegex.FindStringSubmatch(line); m != nil {
+			if settings[m[1]] == nil {
+				settings[m[1]] = make(map[string]*BgpNeighborSecurity)
+			}
+			view = settings[m[1]]
+			continue
+		}
+		// The router blocks end with the first line which is not indented
+		if line != "" && line[0] != ' ' {
+			view = nil
+		}
+		if view == nil {
+			continue
+		}
+		m := bgpNeighborSecurityRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if view[m[1]] == nil {
+			view[m[1]] = new(BgpNeighborSecurity)
+		}
+		if m[2] == "password" {
+			view[m[1]].Password = true
+		} else {
+			view[m[1]].TTLSecurity = true
+		}
+	}
+	return settings
+}
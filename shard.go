@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+var shardFlag = flag.String("shard", "", "In multi-router mode, only collect the targets of this shard, given as N/M (1 <= N <= M), for M replicas of the exporter to split the targets by a hash of their name")
+
+// shard : The shard of the targets collected, and the number of shards, 0 if the targets are not sharded
+var shard struct {
+	index, count uint32
+}
+
+// parseShard : Parses --shard
+func parseShard() error {
+	if *shardFlag == "" {
+		return nil
+	}
+	n, m, ok := strings.Cut(*shardFlag, "/")
+	index, err1 := strconv.ParseUint(n, 10, 32)
+	count, err2 := strconv.ParseUint(m, 10, 32)
+	if !ok || err1 != nil || err2 != nil || index < 1 || index > count {
+		return fmt.Errorf("invalid --shard %q: must be N/M with 1 <= N <= M", *shardFlag)
+	}
+	shard.index, shard.count = uint32(index), uint32(count)
+	return nil
+}
+
+// inShard : Whether the target of the given name belongs to the shard. The same name always hashes to
+// the same shard, whichever the other targets, so the replicas split them without coordinating.
+func inShard(name string) bool {
+	if shard.count == 0 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return h.Sum32()%shard.count == shard.index-1
+}
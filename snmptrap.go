@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	snmpTrapReceiver  = flag.String("snmp-trap.receiver", "", "Send BGP4-MIB established and backward transition traps (SNMPv2c) to this receiver on state changes, e.g. nms:162")
+	snmpTrapCommunity = flag.String("snmp-trap.community", "public", "The community of the SNMP traps")
+)
+
+// The OIDs of the BGP4-MIB (RFC 4273) notifications and of the objects they carry, indexed by the
+// address of the neighbor
+const (
+	oidSysUpTime             = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID           = "1.3.6.1.6.3.1.1.4.1.0"
+	oidBgpEstablished        = "1.3.6.1.2.1.15.0.1"
+	oidBgpBackwardTransition = "1.3.6.1.2.1.15.0.2"
+	oidBgpPeerState          = "1.3.6.1.2.1.15.3.1.2"
+	oidBgpPeerRemoteAddr     = "1.3.6.1.2.1.15.3.1.7"
+	oidBgpPeerLastError      = "1.3.6.1.2.1.15.3.1.14"
+)
+
+// The BER tags of the SNMP types
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berOID         = 0x06
+	berSequence    = 0x30
+	berIPAddress   = 0x40
+	berTimeTicks   = 0x43
+	berTrapV2      = 0xa7
+)
+
+// snmpStart : When the exporter started, for the sysUpTime of the traps
+var snmpStart = time.Now()
+
+// sendStateTraps : Sends the bgpEstablishedNotification of the neighbors which became established, and the
+// bgpBackwardTransNotification of those which moved to a lower state, as the BGP4-MIB defines them. FRR's
+// clearing and deleted states, past established, are torn down sessions: moving to them is a backward
+// transition, and they are notified as idle. The neighbors seen for the first time or which went away are
+// not notified, nor those without an IPv4 address which the BGP4-MIB cannot index.
+func sendStateTraps(changes []StateChange) {
+	for _, c := range changes {
+		if c.OldState == "" || c.NewState == "" {
+			continue
+		}
+		ip := net.ParseIP(c.Neighbor).To4()
+		if ip == nil {
+			continue
+		}
+		old, state := stateValue(c.OldState), stateValue(c.NewState)
+		trap := ""
+		switch {
+		case state == 6:
+			trap = oidBgpEstablished
+		case mibPeerState(state) < mibPeerState(old), state >= 7 && old < 7:
+			trap = oidBgpBackwardTransition
+		default:
+			continue
+		}
+		if err := sendTrap(snmpTrapPDU(trap, ip, mibPeerState(state), rand.Int31(), time.Since(snmpStart))); err != nil {
+			logger.Error("Failed to send the SNMP trap", "receiver", *snmpTrapReceiver, "neighbor", c.Neighbor, "err", err)
+			recordError(localTarget, err.Error())
+		}
+	}
+}
+
+// stateValue : Returns the value of a BGP state name, which is also that of bgpPeerState
+func stateValue(name string) int {
+	for i, n := range bgpStateNames {
+		if n == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// mibPeerState : Returns the value of bgpPeerState for a state, which ranges from idle(1) to established(6)
+func mibPeerState(state int) int {
+	if state > 6 {
+		return 1
+	}
+	return state
+}
+
+// sendTrap : Sends the message to the trap receiver, on port 162 unless another is given
+func sendTrap(message []byte) error {
+	address := *snmpTrapReceiver
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "162")
+	}
+	conn, err := net.DialTimeout("udp", address, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write(message)
+	return err
+}
+
+// snmpTrapPDU : Returns the SNMPv2c message of the notification of a neighbor, with its address, last
+// error (unknown, so 0) and state. The request ID and the sysUpTime (how long the exporter has been up)
+// are given by the caller, for the message to only depend on its arguments.
+func snmpTrapPDU(trap string, ip net.IP, state int, requestID int32, uptime time.Duration) []byte {
+	index := ip.String()
+	varbinds := berEncode(berSequence,
+		varbind(oidSysUpTime, berEncode(berTimeTicks, berUint(uint64(uptime/(10*time.Millisecond))))),
+		varbind(oidSnmpTrapOID, berEncode(berOID, berOIDContent(trap))),
+		varbind(oidBgpPeerRemoteAddr+"."+index, berEncode(berIPAddress, ip)),
+		varbind(oidBgpPeerLastError+"."+index, berEncode(berOctetString, []byte{0, 0})),
+		varbind(oidBgpPeerState+"."+index, berEncode(berInteger, berUint(uint64(state)))),
+	)
+	pdu := berEncode(berTrapV2,
+		berEncode(berInteger, berUint(uint64(requestID))),
+		berEncode(berInteger, berUint(0)),
+		berEncode(berInteger, berUint(0)),
+		varbinds,
+	)
+	// Version 1 is SNMPv2c
+	return berEncode(berSequence,
+		berEncode(berInteger, berUint(1)),
+		berEncode(berOctetString, []byte(*snmpTrapCommunity)),
+		pdu,
+	)
+}
+
+func varbind(oid string, value []byte) []byte {
+	return berEncode(berSequence, berEncode(berOID, berOIDContent(oid)), value)
+}
+
+// berEncode : Returns the BER encoding of the tag and of the concatenated contents
+func berEncode(tag byte, contents ...[]byte) []byte {
+	var content []byte
+	for _, c := range contents {
+		content = append(content, c...)
+	}
+	b := []byte{tag}
+	if n := len(content); n < 0x80 {
+		b = append(b, byte(n))
+	} else {
+		var length []byte
+		for ; n > 0; n >>= 8 {
+			length = append([]byte{byte(n)}, length...)
+		}
+		b = append(b, 0x80|byte(len(length)))
+		b = append(b, length...)
+	}
+	return append(b, content...)
+}
+
+// berUint : Returns the content of a non negative integer, with a leading zero when its high bit is set
+func berUint(v uint64) []byte {
+	b := []byte{byte(v)}
+	for v >>= 8; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+// berOIDContent : Returns the content of a dotted OID, whose first two arcs are encoded together
+func berOIDContent(oid string) []byte {
+	var arcs []uint64
+	for _, s := range strings.Split(oid, ".") {
+		arc, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			panic(fmt.Sprintf("invalid OID %s", oid))
+		}
+		arcs = append(arcs, arc)
+	}
+	b := []byte{byte(arcs[0]*40 + arcs[1])}
+	for _, arc := range arcs[2:] {
+		encoded := []byte{byte(arc & 0x7f)}
+		for arc >>= 7; arc > 0; arc >>= 7 {
+			encoded = append([]byte{byte(arc&0x7f) | 0x80}, encoded...)
+		}
+		b = append(b, encoded...)
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBerUint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want string
+	}{
+		{0, "00"},
+		{6, "06"},
+		{127, "7f"},
+		// A leading zero keeps the integer positive
+		{128, "0080"},
+		{256, "0100"},
+		{0x12345678, "12345678"},
+		{0xffffffff, "00ffffffff"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(berUint(tt.v)); got != tt.want {
+			t.Errorf("berUint(%d) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestBerOIDContent(t *testing.T) {
+	tests := []struct {
+		oid  string
+		want string
+	}{
+		{oidSysUpTime, "2b06010201010300"},
+		{oidBgpEstablished, "2b060102010f0001"},
+		{oidBgpPeerState + ".10.0.0.1", "2b060102010f0301020a000001"},
+		// Arcs above 127 span several bytes
+		{"1.3.6.1.4.1.2636", "2b06010401944c"},
+		{oidBgpPeerRemoteAddr + ".192.0.2.255", "2b060102010f03010781400002817f"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(berOIDContent(tt.oid)); got != tt.want {
+			t.Errorf("berOIDContent(%s) = %s, want %s", tt.oid, got, tt.want)
+		}
+	}
+}
+
+func TestBerEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents [][]byte
+		// want : The tag and length
+		want string
+	}{
+		{"empty", nil, "0400"},
+		{"short", [][]byte{bytes.Repeat([]byte("x"), 127)}, "047f"},
+		{"long", [][]byte{bytes.Repeat([]byte("x"), 200)}, "0481c8"},
+		{"long over two bytes", [][]byte{bytes.Repeat([]byte("x"), 100), bytes.Repeat([]byte("x"), 200)}, "0482012c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := berEncode(berOctetString, tt.contents...)
+			content := bytes.Join(tt.contents, nil)
+			if header := hex.EncodeToString(got[:len(got)-len(content)]); header != tt.want {
+				t.Errorf("got the header %s, want %s", header, tt.want)
+			}
+			if !bytes.HasSuffix(got, content) {
+				t.Errorf("the contents are not at the end of %x", got)
+			}
+		})
+	}
+}
+
+func TestSnmpTrapPDU(t *testing.T) {
+	got := hex.EncodeToString(snmpTrapPDU(oidBgpEstablished, net.ParseIP("10.0.0.1").To4(), 6, 0x12345678, 12340*time.Millisecond))
+	want := strings.Join([]string{
+		"308183", "020101", "04067075626c6963", // SNMPv2c, community "public"
+		"a776", "020412345678", "020100", "020100", // request ID, error status and index
+		"3068",
+		"300e", "06082b06010201010300", "430204d2", // sysUpTime.0 = 1234
+		"3016", "060a2b06010603010104010006082b060102010f0001", // snmpTrapOID.0 = bgpEstablishedNotification
+		"3015", "060d2b060102010f0301070a000001", "40040a000001", // bgpPeerRemoteAddr.10.0.0.1
+		"3013", "060d2b060102010f03010e0a000001", "04020000", // bgpPeerLastError.10.0.0.1
+		"3012", "060d2b060102010f0301020a000001", "020106", // bgpPeerState.10.0.0.1 = established
+	}, "")
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestMibPeerState(t *testing.T) {
+	for _, name := range bgpStateNames[1:] {
+		want := stateValue(name)
+		if name == "clearing" || name == "deleted" {
+			// The torn down sessions are idle for the BGP4-MIB
+			want = 1
+		}
+		if got := mibPeerState(stateValue(name)); got != want {
+			t.Errorf("mibPeerState(%s) = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHConfig : This represents the remote host on which the show commands are run over SSH
+type SSHConfig struct {
+	Address               string `yaml:"address"`
+	User                  string `yaml:"user"`
+	Password              string `yaml:"password"`
+	PrivateKeyFile        string `yaml:"private_key_file"`
+	KnownHostsFile        string `yaml:"known_hosts_file"`
+	InsecureIgnoreHostKey bool   `yaml:"insecure_ignore_host_key"`
+
+	Secret PasswordSource `yaml:",inline"`
+}
+
+func (c *SSHConfig) enabled() bool {
+	return c.Address != ""
+}
+
+// validate : Checks that the SSH configuration is complete
+func (c *SSHConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("invalid address %q: %s", c.Address, err)
+	}
+	if c.User == "" {
+		return fmt.Errorf("no user given")
+	}
+	if !c.Secret.given(c.Password) && c.PrivateKeyFile == "" {
+		return fmt.Errorf("either a password or a private key file is required")
+	}
+	if err := c.Secret.validate(c.Password); err != nil {
+		return err
+	}
+	if c.KnownHostsFile == "" && !c.InsecureIgnoreHostKey {
+		return fmt.Errorf("a known hosts file is required unless the host key is ignored")
+	}
+	return nil
+}
+
+// clientConfig : Builds the client configuration with the password, reading the key and known hosts files
+func (c *SSHConfig) clientConfig(password string) (*ssh.ClientConfig, error) {
+	cc := &ssh.ClientConfig{
+		User:    c.User,
+		Timeout: *vtyshTimeout,
+	}
+	if c.PrivateKeyFile != "" {
+		key, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", c.PrivateKeyFile, err)
+		}
+		cc.Auth = append(cc.Auth, ssh.PublicKeys(signer))
+	}
+	if password != "" {
+		cc.Auth = append(cc.Auth, ssh.Password(password))
+	}
+	if c.InsecureIgnoreHostKey {
+		cc.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		callback, err := knownhosts.New(c.KnownHostsFile)
+		if err != nil {
+			return nil, err
+		}
+		cc.HostKeyCallback = callback
+	}
+	return cc, nil
+}
+
+// sshClient : This represents a connection to a remote host and the password it was made with
+type sshClient struct {
+	*ssh.Client
+	password string
+}
+
+// sshClientKey : The host and credentials a connection is made with, for the targets and modules with
+// different credentials for the same host not to share a connection
+type sshClientKey struct {
+	address        string
+	user           string
+	privateKeyFile string
+}
+
+// sshClients : The connections to the remote hosts by host and credentials, kept open between the commands
+var sshClients = struct {
+	sync.Mutex
+	clients map[sshClientKey]*sshClient
+}{clients: make(map[sshClientKey]*sshClient)}
+
+// sshSession : Opens a session on the remote host, connecting (again) when needed. The password is
+// read at each session, for the connection to be made again once it is rotated. The sessions are opened
+// and the connections made without holding the lock, for a slow or half-dead host not to hold up the
+// targets collected concurrently.
+func sshSession(c *SSHConfig) (*ssh.Session, error) {
+	password, err := c.Secret.read(c.Password)
+	if err != nil {
+		return nil, err
+	}
+	key := sshClientKey{address: c.Address, user: c.User, privateKeyFile: c.PrivateKeyFile}
+	sshClients.Lock()
+	client := sshClients.clients[key]
+	sshClients.Unlock()
+	if client != nil {
+		if client.password == password {
+			if session, err := client.NewSession(); err == nil {
+				return session, nil
+			}
+		}
+		// The connection was lost, e.g. the remote host restarted, or the password changed
+		sshClients.Lock()
+		if sshClients.clients[key] == client {
+			delete(sshClients.clients, key)
+		}
+		sshClients.Unlock()
+		client.Close()
+	}
+
+	cc, err := c.clientConfig(password)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ssh.Dial("tcp", c.Address, cc)
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.NewSession()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sshClients.Lock()
+	if previous := sshClients.clients[key]; previous != nil {
+		previous.Close()
+	}
+	sshClients.clients[key] = &sshClient{Client: conn, password: password}
+	sshClients.Unlock()
+	return session, nil
+}
+
+// runSSH : Runs the command line on the remote host, returning its output
+func runSSH(ctx context.Context, c *SSHConfig, line string) (stdout string, stderr string, err error) {
+	var sout bytes.Buffer
+	stderr, err = runSSHTo(ctx, c, line, &sout)
+	return sout.String(), stderr, err
+}
+
+// runSSHTo : Runs the command line on the remote host, writing its output as it arrives. Opening the
+// session is bounded by the context too, as a half-dead host may never answer it.
+func runSSHTo(ctx context.Context, c *SSHConfig, line string, stdout io.Writer) (stderr string, err error) {
+	var serr bytes.Buffer
+	var mutex sync.Mutex
+	var session *ssh.Session
+	cancelled := false
+	done := make(chan error, 1)
+	go func() {
+		s, err := sshSession(c)
+		if err != nil {
+			done <- fmt.Errorf("ssh %s: %s", c.Address, err)
+			return
+		}
+		defer s.Close()
+		mutex.Lock()
+		if cancelled {
+			mutex.Unlock()
+			return
+		}
+		session = s
+		mutex.Unlock()
+		s.Stdout = stdout
+		s.Stderr = &serr
+		done <- s.Run(line)
+	}()
+	select {
+	case err = <-done:
+		return serr.String(), err
+	case <-ctx.Done():
+		mutex.Lock()
+		cancelled = true
+		if session != nil {
+			session.Close()
+		}
+		mutex.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+// shellQuote : Joins the arguments into a command line for the remote shell
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// closeSSH : Closes the connections to the remote hosts, if any
+func closeSSH() {
+	sshClients.Lock()
+	defer sshClients.Unlock()
+	for key, client := range sshClients.clients {
+		client.Close()
+		delete(sshClients.clients, key)
+	}
+}
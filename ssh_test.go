@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshServer : This represents an SSH server running the commands with a handler, for the tests
+type sshServer struct {
+	address string
+	// connections : The number of connections accepted
+	connections atomic.Int32
+	// stalled : Whether the sessions are left unanswered, as by a half-dead host
+	stalled atomic.Bool
+
+	mutex sync.Mutex
+	conns []net.Conn
+}
+
+// startSSHServer : Starts an SSH server accepting the password of the users, and answering the commands
+// with the handler given the user and the command
+func startSSHServer(t *testing.T, passwords map[string]string, handler func(user, command string) string) *sshServer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := &ssh.ServerConfig{
+		PasswordCallback: func(m ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if p, ok := passwords[m.User()]; ok && p == string(password) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password for %s", m.User())
+		},
+	}
+	sc.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &sshServer{address: l.Addr().String()}
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		l.Close()
+		s.closeConns()
+		closeSSH()
+		wg.Wait()
+	})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mutex.Lock()
+			s.conns = append(s.conns, conn)
+			s.mutex.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				sconn, chans, reqs, err := ssh.NewServerConn(conn, sc)
+				if err != nil {
+					return
+				}
+				s.connections.Add(1)
+				go ssh.DiscardRequests(reqs)
+				for nc := range chans {
+					if s.stalled.Load() {
+						continue
+					}
+					ch, requests, err := nc.Accept()
+					if err != nil {
+						continue
+					}
+					go func() {
+						defer ch.Close()
+						for r := range requests {
+							if r.Type != "exec" {
+								r.Reply(false, nil)
+								continue
+							}
+							command := string(r.Payload[4:])
+							r.Reply(true, nil)
+							fmt.Fprint(ch, handler(sconn.User(), command))
+							ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, 0))
+							return
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return s
+}
+
+// closeConns : Closes the connections accepted, failing the sessions in progress
+func (s *sshServer) closeConns() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func TestSSHSessionCredentials(t *testing.T) {
+	passwords := map[string]string{"frr": "secret", "admin": "secret"}
+	s := startSSHServer(t, passwords, func(user, command string) string {
+		return user + "\n"
+	})
+	run := func(user string) string {
+		t.Helper()
+		c := &SSHConfig{Address: s.address, User: user, Password: passwords[user], InsecureIgnoreHostKey: true}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stdout, _, err := runSSH(ctx, c, "whoami")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stdout
+	}
+
+	// The targets with other credentials for the same host get their own connection
+	for _, user := range []string{"frr", "admin", "frr", "admin"} {
+		if got := run(user); got != user+"\n" {
+			t.Errorf("ran the command as %q, want %s", got, user)
+		}
+	}
+	if n := s.connections.Load(); n != 2 {
+		t.Errorf("got %d connections, want one per user", n)
+	}
+}
+
+// TestSSHSessionStalledHost : Checks that a host no longer answering does not hold up the sessions on the others
+func TestSSHSessionStalledHost(t *testing.T) {
+	passwords := map[string]string{"frr": "secret"}
+	handler := func(user, command string) string { return "ok" }
+	stalled := startSSHServer(t, passwords, handler)
+	other := startSSHServer(t, passwords, handler)
+	run := func(s *sshServer) error {
+		c := &SSHConfig{Address: s.address, User: "frr", Password: "secret", InsecureIgnoreHostKey: true}
+		_, _, err := runSSH(context.Background(), c, "true")
+		return err
+	}
+	if err := run(stalled); err != nil {
+		t.Fatal(err)
+	}
+	stalled.stalled.Store(true)
+	go run(stalled)
+	// The session on the stalled host is being opened
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() { done <- run(other) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		stalled.closeConns()
+		t.Fatal("the session on the other host waited for the stalled one")
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var stateFile = flag.String("state.file", "", "Save the neighbors, their flap counts and the state history to this file on shutdown, and restore them on start")
+
+// PersistedState : This represents the state file, with what is kept between the collections of each
+// router by name (local for the local router) and the state history
+type PersistedState struct {
+	Time    time.Time                  `json:"time"`
+	Routers map[string]PersistedRouter `json:"routers"`
+	History []StateChange              `json:"history"`
+}
+
+// PersistedRouter : This represents the last known neighbors of a router and their flap counts
+type PersistedRouter struct {
+	Neighbors []BgpNeighbor    `json:"neighbors"`
+	Flaps     []PersistedFlaps `json:"flaps,omitempty"`
+}
+
+// PersistedFlaps : This represents the value of bgp_neighbor_flaps_total for a neighbor
+type PersistedFlaps struct {
+	IP        string  `json:"ip"`
+	Interface string  `json:"interface,omitempty"`
+	View      string  `json:"view,omitempty"`
+	Count     float64 `json:"count"`
+}
+
+// persistedTargets : Returns the routers whose state is saved and restored by name, i.e. the targets
+// of the configuration in the shard (the discovered ones may change between restarts) or the local router
+func persistedTargets() map[string]*TargetConfig {
+	targets := make(map[string]*TargetConfig)
+	if !multiRouter() {
+		targets[localTarget] = localTargetConfig
+		return targets
+	}
+	for i := range config.Targets {
+		if inShard(config.Targets[i].Name) {
+			targets[config.Targets[i].Name] = &config.Targets[i]
+		}
+	}
+	return targets
+}
+
+// countersCollected : Whether the counters of the target are accumulated in its metric families, rather
+// than in the global metrics as for the local router collected alone
+func countersCollected() bool {
+	return multiRouter() || probesEnabled()
+}
+
+// persistState : Saves the state on shutdown, once the collection in progress has completed
+func persistState() {
+	if err := saveState(); err != nil {
+		logger.Error("Failed to save the state", "path", *stateFile, "err", err)
+	}
+}
+
+// saveState : Writes the state file atomically
+func saveState() error {
+	state := PersistedState{Time: time.Now(), Routers: make(map[string]PersistedRouter)}
+	for name, t := range persistedTargets() {
+		state.Routers[name] = PersistedRouter{Neighbors: t.state.neighbors.List(), Flaps: savedFlaps(t)}
+	}
+	stateHistory.Lock()
+	state.History = append(append(state.History, stateHistory.changes[stateHistory.next:]...), stateHistory.changes[:stateHistory.next]...)
+	stateHistory.Unlock()
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(*stateFile), "."+filepath.Base(*stateFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), *stateFile)
+}
+
+// savedFlaps : Returns the flap counts of the neighbors of the target
+func savedFlaps(t *TargetConfig) []PersistedFlaps {
+	var metrics []*dto.Metric
+	if countersCollected() {
+		targetMetrics.RLock()
+		for _, mf := range t.state.metrics {
+			if mf.GetName() == "bgp_neighbor_flaps_total" {
+				metrics = mf.Metric
+			}
+		}
+		targetMetrics.RUnlock()
+	} else {
+		ch := make(chan prometheus.Metric)
+		go func() {
+			bgpNeighborFlaps.Collect(ch)
+			close(ch)
+		}()
+		for m := range ch {
+			metric := new(dto.Metric)
+			if err := m.Write(metric); err == nil {
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+
+	var flaps []PersistedFlaps
+	for _, m := range metrics {
+		f := PersistedFlaps{Count: m.GetCounter().GetValue()}
+		for _, l := range m.GetLabel() {
+			switch l.GetName() {
+			case "ip":
+				f.IP = l.GetValue()
+			case "interface":
+				f.Interface = l.GetValue()
+			case "view":
+				f.View = l.GetValue()
+			}
+		}
+		flaps = append(flaps, f)
+	}
+	return flaps
+}
+
+// restoreState : Reads the state file, if any, for the first collection to only report the changes since
+// the shutdown and the flap counts to carry on
+func restoreState() error {
+	content, err := os.ReadFile(*stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var state PersistedState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return err
+	}
+
+	targets := persistedTargets()
+	for name, r := range state.Routers {
+		t, ok := targets[name]
+		if !ok {
+			continue
+		}
+		t.state.neighbors.Replace(r.Neighbors)
+		t.state.neighbors.SetCollected(state.Time)
+		restoreFlaps(t, name, r.Flaps)
+		if !countersCollected() {
+			// The per neighbor metrics are served at once, from the last known neighbors until they are collected
+			c := newCollection(context.Background(), t, nil)
+			c.recordNeighborMetrics(c.limitNeighbors(t.state.neighbors.List()))
+			c.recordNeighborCountMetrics(r.Neighbors)
+			warmStarted.Store(true)
+		}
+	}
+	stateHistory.Lock()
+	for _, c := range state.History {
+		if len(stateHistory.changes) < *historySize {
+			stateHistory.changes = append(stateHistory.changes, c)
+		}
+	}
+	stateHistory.Unlock()
+	logger.Info("Restored the state", "path", *stateFile, "saved", state.Time, "routers", len(state.Routers))
+	return nil
+}
+
+// restoreFlaps : Sets the flap counts of the neighbors of the target, for the next collections to add to them
+func restoreFlaps(t *TargetConfig, name string, flaps []PersistedFlaps) {
+	if !countersCollected() {
+		for _, f := range flaps {
+			bgpNeighborFlaps.With(prometheus.Labels{"ip": f.IP, "interface": f.Interface, "view": f.View}).Add(f.Count)
+		}
+		return
+	}
+
+	family := &dto.MetricFamily{Name: stringPtr("bgp_neighbor_flaps_total"), Type: dto.MetricType_COUNTER.Enum()}
+	for _, f := range flaps {
+		labels := map[string]string{"ip": f.IP, "interface": f.Interface, "view": f.View}
+		// The local router is not labeled with the router
+		if t != localTargetConfig {
+			labels["router"] = name
+		}
+		m := &dto.Metric{Counter: &dto.Counter{Value: float64Ptr(f.Count)}}
+		for k, v := range labels {
+			m.Label = append(m.Label, &dto.LabelPair{Name: stringPtr(k), Value: stringPtr(v)})
+		}
+		sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+		family.Metric = append(family.Metric, m)
+	}
+	targetMetrics.Lock()
+	t.state.metrics = []*dto.MetricFamily{family}
+	targetMetrics.Unlock()
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
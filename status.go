@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var statusAddress = flag.String("address", "localhost:9114", "The host:port of the running exporter queried by the status command")
+
+// statusTemplate : The landing page, with a table of the neighbors of each router as last collected
+var statusTemplate = template.Must(template.New("status").Parse(`<html>
+<head>
+<title>BGP Exporter</title>
+<meta http-equiv="refresh" content="30">
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+td.established { background: #cfc; }
+td.down { background: #fcc; }
+td.shutdown { background: #eee; }
+</style>
+</head>
+<body>
+<h1>BGP Exporter</h1>
+<p><a href='/metrics'>Metrics</a> - <a href='/dashboard.json'>Grafana dashboard</a> - <a href='/api/v1/errors'>Errors</a></p>
+{{range .}}
+<h2>{{.Router}}</h2>
+{{with .LastError}}<p>Last collection error at {{.Time.Format "2006-01-02 15:04:05"}}: {{.Message}}</p>{{end}}
+<table>
+<tr><th>Neighbor</th><th>View</th><th>Description</th><th>ASN</th><th>State</th><th>Uptime</th><th>Prefixes</th><th>Last error</th></tr>
+{{range .Neighbors}}
+<tr><td>{{.Name}}</td><td>{{.View}}</td><td>{{.Description}}</td><td>{{.ASN}}</td><td class='{{.Class}}'>{{.State}}</td><td>{{.Uptime}}</td><td>{{.Prefixes}}</td><td>{{.LastError}}</td></tr>
+{{else}}
+<tr><td colspan='8'>No neighbors</td></tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>
+`))
+
+// statusRouter : This represents the neighbors of a router on the status page
+type statusRouter struct {
+	Router    string           `json:"router"`
+	LastError *CollectionError `json:"last_error,omitempty"`
+	Neighbors []statusNeighbor `json:"neighbors"`
+}
+
+// statusNeighbor : This represents a row of the status page
+type statusNeighbor struct {
+	Name        string  `json:"name"`
+	View        string  `json:"view"`
+	Description string  `json:"description"`
+	ASN         string  `json:"asn"`
+	State       string  `json:"state"`
+	Class       string  `json:"-"`
+	Uptime      string  `json:"uptime"`
+	Prefixes    float64 `json:"prefixes"`
+	LastError   string  `json:"last_error"`
+}
+
+// statusHandler : Serves the landing page, with the state of the neighbors for a quick look without
+// opening Grafana
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, statusRouters()); err != nil {
+		logger.Error("Failed to render the status page", "err", err)
+	}
+}
+
+// neighborsHandler : Serves the rows of the status page as JSON, for the status command
+func neighborsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statusRouters()); err != nil {
+		logger.Error("Failed to encode the neighbors", "err", err)
+	}
+}
+
+// statusRouters : Returns the neighbors of each router as last collected
+func statusRouters() []statusRouter {
+	routers := []statusRouter{}
+	if multiRouter() {
+		for _, t := range activeTargets() {
+			routers = append(routers, statusRouterOf(t.Name, t.state.neighbors.List()))
+		}
+	} else {
+		routers = append(routers, statusRouterOf(localTarget, localTargetConfig.state.neighbors.List()))
+	}
+	return routers
+}
+
+// statusRouterOf : Returns the rows of the neighbors of the router, with its last collection error
+func statusRouterOf(router string, neighbors []BgpNeighbor) statusRouter {
+	s := statusRouter{Router: router}
+	collectionErrors.Lock()
+	if errs := collectionErrors.errors[router]; len(errs) > 0 {
+		last := errs[len(errs)-1]
+		s.LastError = &last
+	}
+	collectionErrors.Unlock()
+
+	for _, n := range neighbors {
+		row := statusNeighbor{
+			Name:        n.key(),
+			View:        n.Vrf,
+			Description: n.Description,
+			ASN:         n.RemoteAS,
+			State:       stateName(n.State),
+			Class:       "down",
+			Prefixes:    n.AcceptedPrefixes,
+			LastError:   n.LastResetReason,
+		}
+		switch {
+		case n.State == 6:
+			row.Class = "established"
+		case n.AdminShutdown:
+			row.Class = "shutdown"
+		}
+		if n.Uptime > 0 {
+			row.Uptime = (time.Duration(n.Uptime) * time.Second).String()
+		}
+		if n.Interface != "" && n.IP != nil {
+			row.Name += " (" + n.IP.String() + ")"
+		}
+		s.Neighbors = append(s.Neighbors, row)
+	}
+	return s
+}
+
+// printStatus : Queries the neighbors of the exporter running at --address and prints them as a table,
+// for a quick look over SSH
+func printStatus(w io.Writer) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get("http://" + *statusAddress + "/api/v1/neighbors")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, *statusAddress)
+	}
+	var routers []statusRouter
+	if err := json.NewDecoder(resp.Body).Decode(&routers); err != nil {
+		return fmt.Errorf("failed to decode the neighbors: %s", err)
+	}
+
+	for i, r := range routers {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		// The local router is only named in multi-router mode
+		if len(routers) > 1 || r.Router != localTarget {
+			fmt.Fprintf(w, "%s:\n", r.Router)
+		}
+		if r.LastError != nil {
+			fmt.Fprintf(w, "Last collection error at %s: %s\n", r.LastError.Time.Format("2006-01-02 15:04:05"), r.LastError.Message)
+		}
+		if len(r.Neighbors) == 0 {
+			fmt.Fprintln(w, "No neighbors")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NEIGHBOR\tVIEW\tDESCRIPTION\tASN\tSTATE\tUPTIME\tPREFIXES\tLAST ERROR")
+		for _, n := range r.Neighbors {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%g\t%s\n", n.Name, statusCell(n.View), statusCell(n.Description), statusCell(n.ASN),
+				n.State, statusCell(n.Uptime), n.Prefixes, statusCell(n.LastError))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statusCell : Returns the value of a cell of the status table, "-" when empty for the columns to stay aligned
+func statusCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, "\t", " ")
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// NeighborStore : This holds the BGP neighbors of the latest collection, keyed by VRF and neighbor.
+// It is safe for concurrent use by the collection loop and the HTTP handlers.
+type NeighborStore struct {
+	mutex     sync.RWMutex
+	neighbors map[string]BgpNeighbor
+	collected time.Time
+}
+
+// NewNeighborStore : Returns an empty neighbor store
+func NewNeighborStore() *NeighborStore {
+	return &NeighborStore{neighbors: make(map[string]BgpNeighbor)}
+}
+
+// storeKey : Returns the key of a neighbor in the store
+func storeKey(n BgpNeighbor) string {
+	return n.Vrf + "|" + n.key()
+}
+
+// Replace : Replaces the content of the store with the neighbors of a new collection, returning the previous ones.
+// Neighbors which are no longer present are dropped, so the store never grows beyond what the router reports.
+func (s *NeighborStore) Replace(neighbors []BgpNeighbor) []BgpNeighbor {
+	m := make(map[string]BgpNeighbor, len(neighbors))
+	for _, n := range neighbors {
+		m[storeKey(n)] = n
+	}
+
+	s.mutex.Lock()
+	previous := s.neighbors
+	s.neighbors = m
+	s.mutex.Unlock()
+
+	return sortedNeighbors(previous)
+}
+
+// List : Returns a copy of the neighbors in the store, sorted by VRF and neighbor
+func (s *NeighborStore) List() []BgpNeighbor {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return sortedNeighbors(s.neighbors)
+}
+
+// SetCollected : Records when the neighbors in the store were collected
+func (s *NeighborStore) SetCollected(t time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.collected = t
+}
+
+// Collected : Returns when the neighbors in the store were collected, or the zero time before the first collection
+func (s *NeighborStore) Collected() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.collected
+}
+
+// Len : Returns the number of neighbors in the store
+func (s *NeighborStore) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.neighbors)
+}
+
+func sortedNeighbors(m map[string]BgpNeighbor) []BgpNeighbor {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	neighbors := make([]BgpNeighbor, 0, len(keys))
+	for _, k := range keys {
+		neighbors = append(neighbors, m[k])
+	}
+	return neighbors
+}
+
+// neighborSample : This represents the value of a per neighbor metric
+type neighborSample struct {
+	vec    *prometheus.GaugeVec
+	labels sampleLabels
+	value  float64
+}
+
+// neighborID : This represents the labels identifying a neighbor, computed once for all its samples
+type neighborID struct {
+	ip, iface, view string
+}
+
+// sampleLabels : This represents the labels of a sample, whose map is only built when the series is
+// created or deleted
+type sampleLabels struct {
+	id    neighborID
+	extra []string
+}
+
+// seriesKey : This identifies the series of a sample
+type seriesKey struct {
+	vec   *prometheus.GaugeVec
+	id    neighborID
+	extra string
+}
+
+// id : Returns the labels identifying the neighbor
+func (n *BgpNeighbor) id() neighborID {
+	id := neighborID{iface: n.Interface, view: n.Vrf}
+	if n.IP != nil {
+		id.ip = n.IP.String()
+	} else if n.Overflow {
+		id.ip = "other"
+	}
+	return id
+}
+
+// labels : Returns the labels of a sample of the neighbor with the given extra label names and values
+func (id neighborID) labels(extra ...string) sampleLabels {
+	return sampleLabels{id: id, extra: extra}
+}
+
+// labels : Returns the labels of the sample as given to the metric
+func (l sampleLabels) labels() prometheus.Labels {
+	labels := prometheus.Labels{"ip": l.id.ip, "interface": l.id.iface, "view": l.id.view}
+	for i := 0; i+1 < len(l.extra); i += 2 {
+		labels[l.extra[i]] = l.extra[i+1]
+	}
+	return labels
+}
+
+// key : Returns the key identifying the series of the sample
+func (s neighborSample) key() seriesKey {
+	return seriesKey{vec: s.vec, id: s.labels.id, extra: strings.Join(s.labels.extra, "\xff")}
+}
+
+// neighborSeriesEntry : This represents a series of a per neighbor metric set by the last collection, with
+// its sample for it to be deleted
+type neighborSeriesEntry struct {
+	gauge  prometheus.Gauge
+	sample neighborSample
+}
+
+// TotalVec : This represents counters whose values are the totals kept by the router rather than
+// incremented by the exporter, so that they are set as gauges but exposed as counters. A total lower
+// than the previous one, e.g. when bgpd restarted, is a counter reset for rate() and increase().
+type TotalVec struct {
+	*prometheus.GaugeVec
+}
+
+// totalNames : The names of the counters which are totals, not to be accumulated across collections
+var totalNames = make(map[string]bool)
+
+// NewTotalVec : Returns the counters of the given name and labels
+func NewTotalVec(opts prometheus.CounterOpts, labelNames []string) *TotalVec {
+	totalNames[opts.Name] = true
+	v := &TotalVec{prometheus.NewGaugeVec(prometheus.GaugeOpts(opts), labelNames)}
+	metricCopies[v] = func() prometheus.Collector {
+		return &TotalVec{prometheus.NewGaugeVec(prometheus.GaugeOpts(opts), labelNames)}
+	}
+	metricNames[v] = prometheus.BuildFQName(opts.Namespace, opts.Subsystem, opts.Name)
+	return v
+}
+
+// Collect : Implements prometheus.Collector, passing the series on as counters
+func (v *TotalVec) Collect(ch chan<- prometheus.Metric) {
+	gauges := make(chan prometheus.Metric)
+	go func() {
+		v.GaugeVec.Collect(gauges)
+		close(gauges)
+	}()
+	for m := range gauges {
+		ch <- totalMetric{m}
+	}
+}
+
+type totalMetric struct {
+	prometheus.Metric
+}
+
+func (m totalMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	out.Counter = &dto.Counter{Value: out.Gauge.Value}
+	out.Gauge = nil
+	return nil
+}
+
+// recordNeighborMetrics : Sets the per neighbor metrics of the neighbors, and deletes the series of
+// neighbors which went away or whose labels (e.g. the shutdown message) changed since the previous
+// collection. The series which remain are set directly, without building their labels again.
+func (c *collection) recordNeighborMetrics(neighbors []BgpNeighbor) {
+	previous := c.state.series
+	if countersCollected() {
+		previous = nil
+	}
+	current := make(map[seriesKey]neighborSeriesEntry, len(previous))
+	for i := range neighbors {
+		for _, s := range neighbors[i].samples() {
+			k := s.key()
+			e, ok := previous[k]
+			if !ok {
+				e = neighborSeriesEntry{gauge: metric(c, s.vec).With(s.labels.labels()), sample: s}
+			}
+			e.gauge.Set(s.value)
+			current[k] = e
+		}
+	}
+	for k, e := range previous {
+		if _, ok := current[k]; !ok {
+			metric(c, e.sample.vec).Delete(e.sample.labels.labels())
+		}
+	}
+	if !countersCollected() {
+		c.state.series = current
+	}
+}
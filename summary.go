@@ -0,0 +1,313 @@
+package main
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpRibEntries = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rib_entries",
+		Help: "The number of RIB entries (prefixes) for an address family",
+	},
+		[]string{
+			"afi",
+		})
+)
+
+var (
+	bgpRibEntriesPeak = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rib_entries_peak",
+		Help: "The highest number of RIB entries for an address family seen since the exporter started",
+	},
+		[]string{
+			"afi",
+		})
+)
+
+var (
+	bgpRibPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_rib_paths",
+		Help: "The number of paths in the RIB for an address family, where reported by the router",
+	},
+		[]string{
+			"afi",
+		})
+)
+
+var (
+	bgpMemoryBytes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_memory_bytes",
+		Help: "The memory used by bgpd for an address family, by kind of object (rib, paths, peers, peer_groups)",
+	},
+		[]string{
+			"afi",
+			"kind",
+		})
+)
+
+var (
+	bgpNeighborPrefixesReceived = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_prefixes_received",
+		Help: "The number of prefixes received from a given BGP neighbor for an address family (PfxRcd)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborOutputQueue = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_output_queue",
+		Help: "The number of messages queued to be sent to a given BGP neighbor for an address family (OutQ)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborPrefixesSent = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_prefixes_sent",
+		Help: "The number of prefixes sent to a given BGP neighbor for an address family (PfxSnt)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+var (
+	bgpNeighborAddressFamilyState = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_address_family_state",
+		Help: "The state of a given BGP neighbor for an address family as listed in its summary, numbered as in bgp_neighbor_state, or -1 if the session is established without the address family negotiated (NoNeg)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+			"afi",
+		})
+)
+
+// BgpSummary : This represents the summary of a BGP table for an address family
+type BgpSummary struct {
+	RibEntries float64
+	RibPaths   float64
+	Memory     map[string]float64
+	Peers      map[string]*BgpSummaryPeer
+}
+
+// BgpSummaryPeer : This represents a BGP Neighbor as listed in the summary
+type BgpSummaryPeer struct {
+	RemoteAS         string
+	Description      string
+	State            float64
+	Uptime           float64
+	PrefixesReceived float64
+	PrefixesSent     float64
+	HasPrefixesSent  bool
+	OutputQueue      float64
+}
+
+var bgpSummaryAfiRegex = regexp.MustCompile(`^(?:(.+) Summary(?: \(VRF .*\))?:|For address family: (.+))\s*$`)
+var bgpSummaryRibRegex = regexp.MustCompile(`^(?:RIB entries (\d+), using|(\d+) network entries using) (\d+) (\w+) of memory`)
+var bgpSummaryPathsRegex = regexp.MustCompile(`^(\d+) path entries using (\d+) (\w+) of memory`)
+var bgpSummaryPeersRegex = regexp.MustCompile(`^Peers (\d+), using (\d+) (\w+) of memory`)
+var bgpSummaryPeerGroupsRegex = regexp.MustCompile(`^Peer groups (\d+), using (\d+) (\w+) of memory`)
+var bgpSummaryHeaderRegex = regexp.MustCompile(`^Neighbor\s+V\s+AS\s+`)
+
+func recordSummaryMetrics(c *collection) {
+	summaries := make(map[string]*BgpSummary)
+	for _, command := range c.summaryCommands() {
+		o, ok := c.summaryOutput(command)
+		if !ok {
+			var err error
+			if o, err = c.vtysh(command); err != nil {
+				c.collectorFailed("summary", err)
+				continue
+			}
+		}
+		for afi, s := range parseSummary(o) {
+			summaries[afi] = s
+		}
+	}
+
+	metric(c, bgpNeighborPrefixesReceived).Reset()
+	metric(c, bgpNeighborPrefixesSent).Reset()
+	metric(c, bgpNeighborOutputQueue).Reset()
+	metric(c, bgpNeighborAddressFamilyState).Reset()
+	for afi, s := range summaries {
+		metric(c, bgpRibEntries).With(prometheus.Labels{"afi": afi}).Set(s.RibEntries)
+		if s.RibEntries > c.state.ribPeak[afi] {
+			c.state.ribPeak[afi] = s.RibEntries
+		}
+		metric(c, bgpRibEntriesPeak).With(prometheus.Labels{"afi": afi}).Set(c.state.ribPeak[afi])
+		if s.RibPaths > 0 {
+			metric(c, bgpRibPaths).With(prometheus.Labels{"afi": afi}).Set(s.RibPaths)
+		}
+		for kind, bytes := range s.Memory {
+			metric(c, bgpMemoryBytes).With(prometheus.Labels{"afi": afi, "kind": kind}).Set(bytes)
+		}
+		// The neighbors beyond the maximum are summed
+		other := BgpNeighbor{Overflow: true}
+		var otherReceived, otherSent, otherOutputQueue float64
+		for ip, p := range s.Peers {
+			n := BgpNeighbor{IP: net.ParseIP(ip), RemoteAS: p.RemoteAS, Description: p.Description}
+			if config.Neighbors.filtered(&n) {
+				continue
+			}
+			if c.neighborTruncated(ip) {
+				otherReceived += p.PrefixesReceived
+				otherSent += p.PrefixesSent
+				otherOutputQueue += p.OutputQueue
+				continue
+			}
+			metric(c, bgpNeighborPrefixesReceived).With(neighborLabels(ip, "afi", afi)).Set(p.PrefixesReceived)
+			metric(c, bgpNeighborAddressFamilyState).With(neighborLabels(ip, "afi", afi)).Set(p.State)
+			metric(c, bgpNeighborOutputQueue).With(neighborLabels(ip, "afi", afi)).Set(p.OutputQueue)
+			if p.HasPrefixesSent {
+				metric(c, bgpNeighborPrefixesSent).With(neighborLabels(ip, "afi", afi)).Set(p.PrefixesSent)
+			}
+		}
+		if len(c.state.truncated) > 0 {
+			metric(c, bgpNeighborPrefixesReceived).With(other.labels("afi", afi)).Set(otherReceived)
+			metric(c, bgpNeighborPrefixesSent).With(other.labels("afi", afi)).Set(otherSent)
+			metric(c, bgpNeighborOutputQueue).With(other.labels("afi", afi)).Set(otherOutputQueue)
+		}
+	}
+}
+
+// parseSummary : Parses "show ip bgp summary" (or "show bgp ... summary") into a summary per address family
+func parseSummary(s string) map[string]*BgpSummary {
+	summaries := make(map[string]*BgpSummary)
+	var summary *BgpSummary
+	var pfxSnt bool
+	inTable := false
+	// wrapped : The neighbor printed alone on its line, the columns being on the next one
+	wrapped := ""
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		if m := bgpSummaryAfiRegex.FindStringSubmatch(line); m != nil {
+			summary = nil
+			inTable = false
+			wrapped = ""
+			// Cisco IOS prints "For address family: IPv4 Unicast"
+			afi := afiLabel(m[1] + m[2])
+			if _, ok := summaries[afi]; !ok {
+				summaries[afi] = &BgpSummary{Memory: make(map[string]float64), Peers: make(map[string]*BgpSummaryPeer)}
+			}
+			summary = summaries[afi]
+			continue
+		}
+		// Older versions print a single table without an address family header
+		if summary == nil {
+			// Nothing is listed for an address family without neighbors, e.g. "% No BGP neighbors found"
+			if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "%") {
+				continue
+			}
+			summary = &BgpSummary{Memory: make(map[string]float64), Peers: make(map[string]*BgpSummaryPeer)}
+			summaries["ipv4_unicast"] = summary
+		}
+		if m := bgpSummaryRibRegex.FindStringSubmatch(line); m != nil {
+			summary.RibEntries, _ = strconv.ParseFloat(m[1]+m[2], 64)
+			summary.Memory["rib"] = parseMemory(m[3], m[4])
+			continue
+		}
+		if m := bgpSummaryPathsRegex.FindStringSubmatch(line); m != nil {
+			summary.RibPaths, _ = strconv.ParseFloat(m[1], 64)
+			summary.Memory["paths"] = parseMemory(m[2], m[3])
+			continue
+		}
+		if m := bgpSummaryPeersRegex.FindStringSubmatch(line); m != nil {
+			summary.Memory["peers"] = parseMemory(m[2], m[3])
+			continue
+		}
+		if m := bgpSummaryPeerGroupsRegex.FindStringSubmatch(line); m != nil {
+			summary.Memory["peer_groups"] = parseMemory(m[2], m[3])
+			continue
+		}
+		if bgpSummaryHeaderRegex.MatchString(line) {
+			inTable = true
+			pfxSnt = strings.Contains(line, "PfxSnt")
+			continue
+		}
+		if !inTable {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 1 && wrapped == "" {
+			// The neighbors too long for the column (e.g. IPv6 addresses) are printed alone, with their
+			// columns on the next line
+			wrapped = fields[0]
+			continue
+		}
+		if wrapped != "" {
+			fields = append([]string{wrapped}, fields...)
+			wrapped = ""
+		}
+		if len(fields) < 10 {
+			// The table ends with a blank line
+			inTable = len(fields) > 0
+			continue
+		}
+		// Neighbor V AS MsgRcvd MsgSent TblVer InQ OutQ Up/Down State/PfxRcd [PfxSnt] [Desc]
+		neighbor := fields[0]
+		peer := &BgpSummaryPeer{RemoteAS: fields[2]}
+		peer.OutputQueue, _ = strconv.ParseFloat(fields[7], 64)
+		if pfx, err := strconv.ParseFloat(fields[9], 64); err == nil {
+			// The prefixes received are only listed once established
+			peer.State = 6
+			peer.Uptime, _ = parseUptime(fields[8])
+			peer.PrefixesReceived = pfx
+			fields = fields[10:]
+		} else {
+			peer.State = float64(stateValue(strings.ToLower(fields[9])))
+			if fields[9] == "NoNeg" {
+				peer.State = -1
+			}
+			// The state may span several fields, e.g. "Idle (Admin)"
+			fields = fields[10:]
+			for len(fields) > 0 && strings.HasSuffix(fields[0], ")") {
+				fields = fields[1:]
+			}
+		}
+		if pfxSnt && len(fields) > 0 {
+			if snt, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				peer.PrefixesSent = snt
+				peer.HasPrefixesSent = true
+				fields = fields[1:]
+			}
+		}
+		if len(fields) > 0 && fields[0] != "N/A" {
+			peer.Description = strings.Join(fields, " ")
+		}
+		summary.Peers[neighbor] = peer
+	}
+	return summaries
+}
+
+// parseMemory : Converts the memory amounts printed by bgpd (e.g. "43 KiB") to bytes
+func parseMemory(value string, unit string) float64 {
+	v, _ := strconv.ParseFloat(value, 64)
+	switch unit {
+	case "KiB":
+		return v * 1024
+	case "MiB":
+		return v * 1024 * 1024
+	case "GiB":
+		return v * 1024 * 1024 * 1024
+	}
+	return v
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func parseSummaryFile(t *testing.T, path string) map[string]*BgpSummary {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseSummary(string(b))
+}
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		path string
+		want map[string]*BgpSummary
+	}{
+		{
+			path: "testdata/frr/show_ip_bgp_summary.txt",
+			want: map[string]*BgpSummary{
+				"ipv4_unicast": {
+					RibEntries: 15,
+					Memory:     map[string]float64{"rib": 2880, "peers": 43 * 1024, "peer_groups": 64},
+					Peers: map[string]*BgpSummaryPeer{
+						"10.0.0.1": {RemoteAS: "65001", Description: "transit-a", State: 6, Uptime: 3723, PrefixesReceived: 12, PrefixesSent: 5, HasPrefixesSent: true},
+						"10.0.0.5": {RemoteAS: "65005", State: 1, HasPrefixesSent: true},
+						"swp1":     {RemoteAS: "65101", State: 6, Uptime: 183840, PrefixesReceived: 100, PrefixesSent: 3, HasPrefixesSent: true},
+					},
+				},
+			},
+		},
+		{
+			path: "testdata/frr/show_bgp_ipv4_labeled-unicast_summary.txt",
+			want: map[string]*BgpSummary{
+				"ipv4_labeled_unicast": {
+					RibEntries: 15,
+					Memory:     map[string]float64{"rib": 2880, "peers": 43 * 1024, "peer_groups": 64},
+					Peers: map[string]*BgpSummaryPeer{
+						// The address family was not negotiated with the neighbor
+						"10.0.0.1": {RemoteAS: "65001", Description: "transit-a", State: -1, PrefixesSent: 5, HasPrefixesSent: true},
+						"10.0.0.5": {RemoteAS: "65005", State: 1, HasPrefixesSent: true},
+					},
+				},
+			},
+		},
+		{
+			// The IPv6 addresses too long for the column are printed alone, with the columns on the next line
+			path: "testdata/frr/show_bgp_ipv6_summary.txt",
+			want: map[string]*BgpSummary{
+				"ipv6_unicast": {
+					RibEntries: 7,
+					Memory:     map[string]float64{"rib": 1344, "peers": 64 * 1024},
+					Peers: map[string]*BgpSummaryPeer{
+						"2001:db8:ffff:1::1":      {RemoteAS: "65001", Description: "transit-a-v6", State: 6, Uptime: 3723, PrefixesReceived: 4, PrefixesSent: 3, HasPrefixesSent: true},
+						"fe80::4638:39ff:fe00:5c": {RemoteAS: "65101", State: 6, Uptime: 183840, PrefixesReceived: 2, PrefixesSent: 3, HasPrefixesSent: true, OutputQueue: 2},
+						"2001:db8::5":             {RemoteAS: "65005", State: 3, HasPrefixesSent: true},
+					},
+				},
+			},
+		},
+		{
+			path: "testdata/ios/show_bgp_all_summary.txt",
+			want: map[string]*BgpSummary{
+				"ipv4_unicast": {
+					RibEntries: 130,
+					RibPaths:   135,
+					Memory:     map[string]float64{"rib": 32240, "paths": 18360},
+					Peers: map[string]*BgpSummaryPeer{
+						"192.0.2.1":    {RemoteAS: "65010", State: 6, Uptime: 183600, PrefixesReceived: 120},
+						"198.51.100.7": {RemoteAS: "65020", State: 1},
+					},
+				},
+				"ipv6_unicast": {
+					RibEntries: 5,
+					RibPaths:   5,
+					Memory:     map[string]float64{"rib": 1360, "paths": 760},
+					Peers: map[string]*BgpSummaryPeer{
+						"2001:DB8:0:CC00::1": {RemoteAS: "65010", State: 6, Uptime: 183600, PrefixesReceived: 5},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := parseSummaryFile(t, tt.path)
+			if !reflect.DeepEqual(got, tt.want) {
+				for afi, s := range got {
+					t.Logf("%s: %+v", afi, *s)
+					for ip, p := range s.Peers {
+						t.Logf("  %s: %+v", ip, *p)
+					}
+				}
+				t.Errorf("the summaries differ from those expected")
+			}
+		})
+	}
+}
+
+func TestParseSummaryWithoutNeighbors(t *testing.T) {
+	got := parseSummary("% No BGP neighbors found in VRF default\n")
+	if len(got) != 0 {
+		t.Errorf("got %d summaries, want none", len(got))
+	}
+}
+
+// TestParseSummaryMatchesNeighbors : Checks that the summary of a router gives its neighbors the same
+// state, uptime and prefixes as "show ip bgp neighbors"
+func TestParseSummaryMatchesNeighbors(t *testing.T) {
+	peers := parseSummaryFile(t, "testdata/frr/show_ip_bgp_summary.txt")["ipv4_unicast"].Peers
+	neighbors := parseFile(t, "testdata/frr/show_ip_bgp_neighbors.txt")
+	if len(peers) != len(neighbors) {
+		t.Fatalf("got %d peers in the summary, %d neighbors", len(peers), len(neighbors))
+	}
+	for _, n := range neighbors {
+		p, ok := peers[n.key()]
+		if !ok {
+			t.Errorf("neighbor %s is not in the summary", n.key())
+			continue
+		}
+		if p.RemoteAS != n.RemoteAS || p.State != n.State || p.Uptime != n.Uptime || p.PrefixesReceived != n.AcceptedPrefixes {
+			t.Errorf("neighbor %s: the summary gives %+v", n.key(), *p)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sdNotify : Sends the state to systemd when running as a Type=notify service, see sd_notify(3).
+// It does nothing when the NOTIFY_SOCKET is not set.
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	// Abstract sockets are given with a leading "@"
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		logger.Warn("Failed to notify systemd", "state", state, "err", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		logger.Warn("Failed to notify systemd", "state", state, "err", err)
+	}
+}
+
+// notifyCollected : Marks the exporter as ready after its first successful collection of the
+// neighbors, and reports their number to systemd after each one
+func notifyCollected(neighbors int) {
+	notifyReady()
+	sdNotify(fmt.Sprintf("STATUS=Collected %d neighbors", neighbors))
+}
+
+// notifyReady : Marks the exporter as ready, once only
+func notifyReady() {
+	if !ready.Swap(true) {
+		sdNotify("READY=1")
+	}
+}
+
+// collectionLoopTime : When the collection loop last completed a collection, in Unix nanoseconds
+var collectionLoopTime atomic.Int64
+
+// petWatchdog : Pets the systemd watchdog every half WatchdogSec= until the context is cancelled, as long as
+// the collection loop completed within WatchdogSec=. With WatchdogSec= set longer than a few polls, systemd
+// restarts the exporter when the collection wedges, but not when the router cannot be collected.
+func petWatchdog(ctx context.Context) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return
+	}
+	timeout := time.Duration(usec) * time.Microsecond
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if time.Since(time.Unix(0, collectionLoopTime.Load())) < timeout {
+				sdNotify("WATCHDOG=1")
+			}
+		}
+	}
+}
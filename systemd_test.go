@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// notifySocket : Listens as systemd for the notifications of the exporter
+func notifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+// receiveNotification : Returns the next notification, or an empty string if none comes within the timeout
+func receiveNotification(t *testing.T, conn *net.UnixConn, timeout time.Duration) string {
+	t.Helper()
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 256)
+	n, err := conn.Read(b)
+	if err != nil {
+		return ""
+	}
+	return string(b[:n])
+}
+
+func TestPetWatchdog(t *testing.T) {
+	conn := notifySocket(t)
+	t.Setenv("WATCHDOG_USEC", "200000")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collectionLoopTime.Store(time.Now().UnixNano())
+	go petWatchdog(ctx)
+
+	// The watchdog is petted without a successful collection, as long as the loop goes on
+	if got := receiveNotification(t, conn, time.Second); got != "WATCHDOG=1" {
+		t.Fatalf("got %q, want the watchdog petted", got)
+	}
+
+	// The loop wedges
+	collectionLoopTime.Store(time.Now().Add(-time.Second).UnixNano())
+	receiveNotification(t, conn, 150*time.Millisecond)
+	if got := receiveNotification(t, conn, 500*time.Millisecond); got != "" {
+		t.Errorf("got %q once the collection wedged", got)
+	}
+}
+
+func TestNotifyReady(t *testing.T) {
+	conn := notifySocket(t)
+	t.Cleanup(func() { ready.Store(false) })
+	ready.Store(false)
+
+	notifyReady()
+	if got := receiveNotification(t, conn, time.Second); got != "READY=1" {
+		t.Errorf("got %q, want READY=1", got)
+	}
+	notifyCollected(3)
+	if got := receiveNotification(t, conn, time.Second); got != "STATUS=Collected 3 neighbors" {
+		t.Errorf("got %q, want the status only", got)
+	}
+}
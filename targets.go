@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	targetsConcurrency = flag.Int("targets.concurrency", 1, "In multi-router mode, the number of targets collected concurrently")
+	targetsTimeout     = flag.Duration("targets.timeout", 30*time.Second, "In multi-router mode, the time allowed to collect a target, beyond which it keeps its metrics of the last collection")
+)
+
+// TargetConfig : This represents a router collected in multi-router mode, over SSH (vtysh, or the
+// show commands on IOS) or the northbound gRPC interface. The SSH settings also give the address.
+type TargetConfig struct {
+	Name      string `yaml:"name"`
+	Backend   string `yaml:"backend"`
+	Platform  string `yaml:"platform"`
+	SSHConfig `yaml:",inline"`
+	// Path : The northbound path of the neighbors
+	Path string `yaml:"path"`
+
+	state *targetState
+}
+
+// targetState : This holds what is kept between the collections of a target
+type targetState struct {
+	// mutex : Serializes the collections of the target, e.g. concurrent probes of a router
+	mutex     sync.Mutex
+	neighbors *NeighborStore
+	ribPeak   map[string]float64
+	watchfrr  map[string]WatchfrrDaemon
+	details   map[string]time.Time
+	// truncated : The neighbors of the last collection beyond the maximum, by store key
+	truncated map[string]bool
+	// series : The series of the per neighbor metrics set by the last collection. They are only kept while
+	// the collections set the metrics themselves, i.e. when the counters are not collected per target.
+	series map[seriesKey]neighborSeriesEntry
+	// metrics : The metric families of the last collection, labeled with the router
+	metrics []*dto.MetricFamily
+}
+
+// validate : Checks that the target is complete, filling in the defaults
+func (t *TargetConfig) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("no name given")
+	}
+	if t.Platform == "" {
+		t.Platform = *platform
+	}
+	switch t.Backend {
+	case "", "ssh":
+		t.Backend = "ssh"
+		if err := t.SSHConfig.validate(); err != nil {
+			return err
+		}
+		if !t.SSHConfig.enabled() {
+			return fmt.Errorf("no address given")
+		}
+	case "northbound":
+		if t.Platform != "frr" {
+			return fmt.Errorf("the northbound backend requires the frr platform")
+		}
+		nb := t.northbound()
+		if err := nb.validate(); err != nil {
+			return err
+		}
+		if !nb.enabled() {
+			return fmt.Errorf("no address given")
+		}
+		t.Path = nb.Path
+	default:
+		return fmt.Errorf("unknown backend %q", t.Backend)
+	}
+	if t.Platform != "frr" && t.Platform != "ios" {
+		return fmt.Errorf("unknown platform %q", t.Platform)
+	}
+	t.state = newTargetState()
+	return nil
+}
+
+// newTargetState : Returns the state of a target not collected yet
+func newTargetState() *targetState {
+	return &targetState{neighbors: NewNeighborStore(), ribPeak: make(map[string]float64), watchfrr: make(map[string]WatchfrrDaemon), details: make(map[string]time.Time)}
+}
+
+func (t *TargetConfig) northbound() NorthboundConfig {
+	return NorthboundConfig{Address: t.Address, Path: t.Path}
+}
+
+// validateTargets : Checks the targets and that their names are unique
+func validateTargets(targets []TargetConfig) error {
+	names := make(map[string]bool)
+	for i := range targets {
+		t := &targets[i]
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("target %d (%s): %s", i+1, t.Name, err)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("duplicate target name %q", t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
+
+// collectorTracker : A registerer remembering the collectors registered through it, for the
+// collections of the targets to get their own copies
+type collectorTracker struct {
+	prometheus.Registerer
+	collectors []prometheus.Collector
+}
+
+func (r *collectorTracker) Register(c prometheus.Collector) error {
+	r.collectors = append(r.collectors, c)
+	return r.Registerer.Register(c)
+}
+
+func (r *collectorTracker) MustRegister(cs ...prometheus.Collector) {
+	r.collectors = append(r.collectors, cs...)
+	r.Registerer.MustRegister(cs...)
+}
+
+// targetCollectors : The collectors of the per router metrics
+var targetCollectors []prometheus.Collector
+
+// targetMetrics : Guards the metric families of the targets, read by the HTTP handlers
+var targetMetrics sync.RWMutex
+
+// localTargetConfig : The local router (or the single router of the configuration), whose backend is
+// that of the flags and the configuration, and its state
+var localTargetConfig = &TargetConfig{Name: localTarget, state: newTargetState()}
+
+// collectTargets : Collects the targets, --targets.concurrency at a time, keeping their metrics aside
+// labeled with the router. The targets not collected within --targets.timeout keep their metrics of the
+// last collection.
+func collectTargets(ctx context.Context) {
+	refreshTargets()
+	targets := activeTargets()
+	jobs := make(chan *TargetConfig)
+	var wg sync.WaitGroup
+	for w := 0; w < *targetsConcurrency && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				collectTargetWithin(ctx, t, *targetsTimeout)
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- &targets[i]
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// collectTargetWithin : Collects the target, keeping its metrics unless the collection did not complete in time
+// or was stopped by the exporter shutting down
+func collectTargetWithin(ctx context.Context, t *TargetConfig, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	mfs, _ := collectTarget(ctx, t, nil)
+	if ctx.Err() == context.DeadlineExceeded {
+		collectorFailed(t.Name, "targets", fmt.Errorf("the collection did not complete within %s", timeout))
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	storeTargetMetrics(t, mfs)
+}
+
+// collectLocal : Collects the local router as a target, for its metrics to be served along with those of the probes
+func collectLocal(ctx context.Context) {
+	mfs, _ := collectTarget(ctx, localTargetConfig, nil)
+	if ctx.Err() != nil {
+		return
+	}
+	storeTargetMetrics(localTargetConfig, mfs)
+}
+
+// collectTarget : Collects the target, with only the given collectors if any, and returns its per router
+// metric families and whether all the collectors succeeded. The target is collected into its own copies
+// of the metrics, so that the targets and the probes are collected concurrently.
+func collectTarget(ctx context.Context, t *TargetConfig, collectors map[string]bool) ([]*dto.MetricFamily, bool) {
+	t.state.mutex.Lock()
+	defer t.state.mutex.Unlock()
+
+	c := newCollection(ctx, t, collectors)
+	c.ownMetrics()
+	c.collect()
+	mfs, err := c.registry.Gather()
+	if err != nil {
+		c.collectorFailed("targets", err)
+	}
+	var families []*dto.MetricFamily
+	for _, mf := range mfs {
+		if targetMetric(mf.GetName()) {
+			families = append(families, mf)
+		}
+	}
+	return families, c.failures == 0
+}
+
+// storeTargetMetrics : Keeps the metric families of the last collection of the target to be served
+func storeTargetMetrics(t *TargetConfig, mfs []*dto.MetricFamily) {
+	metrics := labelTargetMetrics(t, mfs)
+	targetMetrics.Lock()
+	t.state.metrics = metrics
+	targetMetrics.Unlock()
+}
+
+// targetMetric : Whether the metric family is per router and kept from the collection of the target, rather
+// than about the exporter itself or computed when scraped
+func targetMetric(name string) bool {
+	return strings.HasPrefix(name, "bgp_") && !strings.HasPrefix(name, "bgp_exporter_") && !freshnessMetric(name)
+}
+
+// labelTargetMetrics : Returns the per router metric families of the collection with the router label,
+// which the local router does not get
+func labelTargetMetrics(t *TargetConfig, mfs []*dto.MetricFamily) []*dto.MetricFamily {
+	if t != localTargetConfig {
+		name := "router"
+		for _, mf := range mfs {
+			for _, m := range mf.Metric {
+				m.Label = append(m.Label, &dto.LabelPair{Name: &name, Value: &t.Name})
+				sort.Slice(m.Label, func(i, j int) bool { return m.Label[i].GetName() < m.Label[j].GetName() })
+			}
+		}
+	}
+	return accumulateCounters(t, mfs)
+}
+
+// accumulateCounters : The counters only counted this collection, so their values of the previous
+// collections of the target are added, except for the totals kept by the router
+func accumulateCounters(t *TargetConfig, mfs []*dto.MetricFamily) []*dto.MetricFamily {
+	targetMetrics.RLock()
+	previous := make(map[string]*dto.MetricFamily)
+	for _, mf := range t.state.metrics {
+		previous[mf.GetName()] = mf
+	}
+	targetMetrics.RUnlock()
+
+	var accumulated []*dto.MetricFamily
+	for _, mf := range mfs {
+		if totalNames[mf.GetName()] {
+			delete(previous, mf.GetName())
+			accumulated = append(accumulated, mf)
+			continue
+		}
+		if p, ok := previous[mf.GetName()]; ok && mf.GetType() == dto.MetricType_COUNTER {
+			mf.Metric = addCounters(p.Metric, mf.Metric)
+		}
+		delete(previous, mf.GetName())
+		accumulated = append(accumulated, mf)
+	}
+	// Counters which were not incremented in this collection
+	for _, mf := range previous {
+		if mf.GetType() == dto.MetricType_COUNTER && !totalNames[mf.GetName()] {
+			accumulated = append(accumulated, mf)
+		}
+	}
+	return accumulated
+}
+
+// addCounters : Adds the previous values of the counters to the current ones, keeping the previous
+// series which are not in the current ones
+func addCounters(previous []*dto.Metric, current []*dto.Metric) []*dto.Metric {
+	values := make(map[string]*dto.Metric)
+	for _, m := range current {
+		values[fmt.Sprint(m.Label)] = m
+	}
+	for _, p := range previous {
+		m, ok := values[fmt.Sprint(p.Label)]
+		if !ok {
+			current = append(current, p)
+			continue
+		}
+		v := m.GetCounter().GetValue() + p.GetCounter().GetValue()
+		m.Counter = &dto.Counter{Value: &v}
+	}
+	return current
+}
+
+// targetsGatherer : Returns a gatherer serving the per router metrics of all the targets in multi-router
+// mode, along with the metrics about the exporter itself
+func targetsGatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	if !multiRouter() && !probesEnabled() {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		mfs, err := g.Gather()
+		var merged []*dto.MetricFamily
+		for _, mf := range mfs {
+			if !targetMetric(mf.GetName()) {
+				merged = append(merged, mf)
+			}
+		}
+
+		targets := activeTargets()
+		if !multiRouter() {
+			targets = []TargetConfig{*localTargetConfig}
+		}
+		targetMetrics.RLock()
+		defer targetMetrics.RUnlock()
+		families := make(map[string]*dto.MetricFamily)
+		for _, t := range targets {
+			for _, mf := range t.state.metrics {
+				f, ok := families[mf.GetName()]
+				if !ok {
+					f = &dto.MetricFamily{Name: mf.Name, Help: mf.Help, Type: mf.Type}
+					families[mf.GetName()] = f
+					merged = append(merged, f)
+				}
+				f.Metric = append(f.Metric, copyMetrics(mf.Metric)...)
+			}
+		}
+		sort.Slice(merged, func(i, j int) bool { return merged[i].GetName() < merged[j].GetName() })
+		return merged, err
+	})
+}
+
+// copyMetrics : Returns deep copies of the metrics kept for the next gathers, for the gatherers wrapping
+// them (renaming, rescaling or labeling the metrics) not to change the kept ones
+func copyMetrics(metrics []*dto.Metric) []*dto.Metric {
+	copies := make([]*dto.Metric, len(metrics))
+	for i, m := range metrics {
+		copies[i] = proto.Clone(m).(*dto.Metric)
+	}
+	return copies
+}
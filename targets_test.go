@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets []TargetConfig
+		err     string
+	}{
+		{
+			name:    "no address",
+			targets: []TargetConfig{{Name: "edge1", SSHConfig: SSHConfig{User: "frr", Password: "secret"}}},
+			err:     "target 1 (edge1): no address given",
+		},
+		{
+			name: "duplicate name",
+			targets: []TargetConfig{
+				{Name: "edge1", Backend: "northbound", SSHConfig: SSHConfig{Address: "192.0.2.1:50051"}},
+				{Name: "edge1", Backend: "northbound", SSHConfig: SSHConfig{Address: "192.0.2.2:50051"}},
+			},
+			err: `duplicate target name "edge1"`,
+		},
+		{
+			name:    "northbound on ios",
+			targets: []TargetConfig{{Name: "edge1", Backend: "northbound", Platform: "ios", SSHConfig: SSHConfig{Address: "192.0.2.1:50051"}}},
+			err:     "target 1 (edge1): the northbound backend requires the frr platform",
+		},
+		{
+			name:    "unknown backend",
+			targets: []TargetConfig{{Name: "edge1", Backend: "netconf", SSHConfig: SSHConfig{Address: "192.0.2.1:830"}}},
+			err:     `target 1 (edge1): unknown backend "netconf"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTargets(tt.targets); err == nil || err.Error() != tt.err {
+				t.Errorf("got the error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestCollectTargets : Checks that the targets are labeled with the router, and that a target not collected
+// in time keeps its metrics of the last collection
+func TestCollectTargets(t *testing.T) {
+	neighbors := readFile(t, "testdata/frr/show_bgp_neighbors_json.txt")
+	handler := func(user, command string) string {
+		if strings.Contains(command, "show bgp neighbors json") {
+			return neighbors
+		}
+		return "% Unknown command: " + command + "\n"
+	}
+	edge1 := startSSHServer(t, map[string]string{"frr": "secret"}, handler)
+	edge2 := startSSHServer(t, map[string]string{"frr": "secret"}, handler)
+	setFlags(t, map[string]string{"vtysh.neighbors-json": "always", "vtysh.retries": "0", "targets.concurrency": "2", "targets.timeout": "1s"})
+	targets := []TargetConfig{
+		{Name: "edge1", SSHConfig: SSHConfig{Address: edge1.address, User: "frr", Password: "secret", InsecureIgnoreHostKey: true}},
+		{Name: "edge2", SSHConfig: SSHConfig{Address: edge2.address, User: "frr", Password: "secret", InsecureIgnoreHostKey: true}},
+	}
+	if err := validateTargets(targets); err != nil {
+		t.Fatal(err)
+	}
+	config = &Config{Targets: targets}
+	collectors := targetCollectors
+	t.Cleanup(func() { targetCollectors = collectors })
+	targetCollectors = []prometheus.Collector{bgpNeighborState}
+
+	want := `
+# HELP bgp_neighbor_state The state of the connection to a given BGP neighbor (1=idle,2=connect,3=active,4=opensent,5=openconfirm,6=established,7=clearing,8=deleted)
+# TYPE bgp_neighbor_state gauge
+bgp_neighbor_state{interface="",ip="10.0.0.1",router="edge1",view=""} 6
+bgp_neighbor_state{interface="",ip="10.0.0.1",router="edge2",view=""} 6
+bgp_neighbor_state{interface="",ip="10.0.0.5",router="edge1",view=""} 1
+bgp_neighbor_state{interface="",ip="10.0.0.5",router="edge2",view=""} 1
+bgp_neighbor_state{interface="swp1",ip="fe80::4638:39ff:fe00:5c",router="edge1",view=""} 6
+bgp_neighbor_state{interface="swp1",ip="fe80::4638:39ff:fe00:5c",router="edge2",view=""} 6
+`
+	g := prometheus.Gatherers{targetsGatherer(prometheus.NewRegistry())}
+	collectTargets(context.Background())
+	if err := testutil.GatherAndCompare(g, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+
+	edge2.stalled.Store(true)
+	edge2.closeConns()
+	collectTargets(context.Background())
+	if err := testutil.GatherAndCompare(g, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+	collectionErrors.Lock()
+	errs := collectionErrors.errors["edge2"]
+	collectionErrors.Unlock()
+	if len(errs) == 0 || !strings.Contains(errs[len(errs)-1].Message, "did not complete within 1s") {
+		t.Errorf("got the errors %v", errs)
+	}
+}
+
+// TestTargetsGathererKeepsTheMetrics : Checks that renaming, rescaling and labeling the metrics of the
+// targets as they are gathered leaves those kept for the next gathers unchanged
+func TestTargetsGathererKeepsTheMetrics(t *testing.T) {
+	defer func(c *Config, compliant, metallb bool, node string) {
+		config, *compliantNames, *metallbMode, *metallbNode = c, compliant, metallb, node
+	}(config, *compliantNames, *metallbMode, *metallbNode)
+	*compliantNames, *metallbMode, *metallbNode = true, true, "node-1"
+
+	r1 := TargetConfig{Name: "r1", state: newTargetState()}
+	r1.state.metrics = []*dto.MetricFamily{{
+		Name: proto.String("bgp_neighbor_maximum_prefixes_threshold"),
+		Help: proto.String("The configured maximum prefix warning threshold"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{{
+			Label: []*dto.LabelPair{
+				{Name: proto.String("ip"), Value: proto.String("10.0.0.1")},
+				{Name: proto.String("router"), Value: proto.String("r1")},
+			},
+			Gauge: &dto.Gauge{Value: proto.Float64(80)},
+		}},
+	}}
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{"targets", &Config{Targets: []TargetConfig{r1}}},
+		{"probe modules", &Config{Modules: map[string]ModuleConfig{"default": {}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = tt.config
+			defer func(s *targetState) { localTargetConfig.state = s }(localTargetConfig.state)
+			localTargetConfig.state = r1.state
+
+			g := exporterGatherer(targetsGatherer(prometheus.NewRegistry()))
+			for i := 0; i < 3; i++ {
+				mfs, err := g.Gather()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(mfs) != 1 || mfs[0].GetName() != "bgp_neighbor_maximum_prefixes_threshold_ratio" {
+					t.Fatalf("gather %d: got %v", i, mfs)
+				}
+				m := mfs[0].Metric[0]
+				if m.GetGauge().GetValue() != 0.8 || len(m.Label) != 3 {
+					t.Errorf("gather %d: got %v", i, m)
+				}
+			}
+			if m := r1.state.metrics[0].Metric[0]; r1.state.metrics[0].GetName() != "bgp_neighbor_maximum_prefixes_threshold" || m.GetGauge().GetValue() != 80 || len(m.Label) != 2 {
+				t.Errorf("the kept metrics were changed: %v", r1.state.metrics[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpNeighborTCPRtt = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_tcp_rtt_seconds",
+		Help: "The smoothed round trip time of the TCP connection to a given established BGP neighbor",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborTCPRetransmits = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_tcp_retransmitted_segments",
+		Help: "The number of segments retransmitted on the TCP connection to a given established BGP neighbor since it was established",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+var (
+	bgpNeighborTCPSendQueue = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_neighbor_tcp_send_queue_bytes",
+		Help: "The number of bytes not yet acknowledged by a given established BGP neighbor on its TCP connection (Send-Q)",
+	},
+		[]string{
+			"ip",
+			"interface",
+			"view",
+		})
+)
+
+// TCPSocket : This represents a TCP connection as listed by "ss -tin"
+type TCPSocket struct {
+	Peer        net.IP
+	Interface   string
+	SendQueue   float64
+	Rtt         float64
+	Retransmits float64
+}
+
+// recordTCPMetrics : Exports the statistics of the TCP connections of the established neighbors, found
+// by their address among the connections on port 179, to tell lossy links before the sessions drop
+func recordTCPMetrics(c *collection) {
+	o, err := c.runBackendCommand("ss", "-tin", "( sport = :179 or dport = :179 )")
+	if err != nil {
+		c.collectorFailed("tcp", err)
+		return
+	}
+	sockets := parseSockets(o)
+
+	metric(c, bgpNeighborTCPRtt).Reset()
+	metric(c, bgpNeighborTCPRetransmits).Reset()
+	metric(c, bgpNeighborTCPSendQueue).Reset()
+	for _, n := range c.exportedNeighbors() {
+		if n.State != 6 || n.IP == nil {
+			continue
+		}
+		for _, s := range sockets {
+			// Link local addresses are scoped by the interface
+			if !s.Peer.Equal(n.IP) || (s.Interface != "" && n.Interface != "" && s.Interface != n.Interface) {
+				continue
+			}
+			metric(c, bgpNeighborTCPRtt).With(n.labels()).Set(s.Rtt)
+			metric(c, bgpNeighborTCPRetransmits).With(n.labels()).Set(s.Retransmits)
+			metric(c, bgpNeighborTCPSendQueue).With(n.labels()).Set(s.SendQueue)
+			break
+		}
+	}
+}
+
+// parseSockets : Parses the established connections of "ss -tin", each listed on a line, e.g.
+// "ESTAB 0 0 10.0.0.2:179 10.0.0.1:43210", followed by an indented line of details, e.g.
+// "cubic wscale:7,7 rto:204 rtt:0.5/0.25 ... retrans:0/3 ..."
+func parseSockets(s string) []TCPSocket {
+	var sockets []TCPSocket
+	var socket *TCPSocket
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			socket = nil
+			if fields[0] != "ESTAB" || len(fields) < 5 {
+				continue
+			}
+			peer, iface := parseSocketAddress(fields[4])
+			if peer == nil {
+				continue
+			}
+			sockets = append(sockets, TCPSocket{Peer: peer, Interface: iface})
+			socket = &sockets[len(sockets)-1]
+			socket.SendQueue, _ = strconv.ParseFloat(fields[2], 64)
+			continue
+		}
+		if socket == nil {
+			continue
+		}
+		for _, f := range fields {
+			switch {
+			case strings.HasPrefix(f, "rtt:"):
+				// The smoothed round trip time and its variation in milliseconds
+				rtt, _ := strconv.ParseFloat(strings.Split(strings.TrimPrefix(f, "rtt:"), "/")[0], 64)
+				socket.Rtt = rtt / 1000
+			case strings.HasPrefix(f, "retrans:"):
+				// The retransmissions of the current segment and in total
+				retrans := strings.Split(strings.TrimPrefix(f, "retrans:"), "/")
+				socket.Retransmits, _ = strconv.ParseFloat(retrans[len(retrans)-1], 64)
+			}
+		}
+	}
+	return sockets
+}
+
+// parseSocketAddress : Returns the address and the interface of link local addresses of an address and
+// port as printed by ss, e.g. "10.0.0.1:43210", "[fe80::1%swp1]:179" or "[::ffff:10.0.0.1]:179"
+func parseSocketAddress(s string) (net.IP, string) {
+	host, _, err := net.SplitHostPort(s)
+	if err != nil {
+		return nil, ""
+	}
+	var iface string
+	if i := strings.Index(host, "%"); i >= 0 {
+		host, iface = host[:i], host[i+1:]
+	}
+	return net.ParseIP(host), iface
+}
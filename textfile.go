@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var textfilePath = flag.String("output.textfile", "", "Write the metrics to this .prom file for the node_exporter textfile collector after each collection, instead of serving them over HTTP")
+
+// writeTextfile : Writes the metrics to the textfile atomically, so that node_exporter never reads a partial file
+func writeTextfile() {
+	if err := writeTextfileTo(*textfilePath); err != nil {
+		logger.Error("Failed to write the textfile", "path", *textfilePath, "err", err)
+		recordError(localTarget, err.Error())
+	}
+}
+
+func writeTextfileTo(path string) error {
+	// The temporary file does not end in .prom, so that it is ignored by node_exporter
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := writeMetrics(exporterGatherer(targetsGatherer(prometheus.DefaultGatherer)), f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The build details are set at build time, e.g.
+// go build -ldflags "-X main.version=0.1.0 -X main.commit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+var showVersion = flag.Bool("version", false, "Print the version and exit")
+
+var (
+	bgpExporterBuildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_exporter_build_info",
+		Help: "A metric with a constant '1' value labeled by the version, commit, build date and Go version of the exporter",
+	},
+		[]string{
+			"version",
+			"commit",
+			"build_date",
+			"goversion",
+		})
+)
+
+// versionString : Returns the build details in a human readable form
+func versionString() string {
+	return fmt.Sprintf("bgp_exporter, version %s (commit: %s, built: %s, %s)", version, commit, buildDate, runtime.Version())
+}
+
+// registerBuildInfo : Registers the build info metric with its single series
+func registerBuildInfo(r prometheus.Registerer) {
+	bgpExporterBuildInfo.WithLabelValues(version, commit, buildDate, runtime.Version()).Set(1)
+	r.MustRegister(bgpExporterBuildInfo)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpVpnRdPrefixes = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_vpn_rd_prefixes",
+		Help: "The number of VPN prefixes for a given route distinguisher",
+	},
+		[]string{
+			"afi",
+			"rd",
+		})
+)
+
+var (
+	bgpVpnRdPaths = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_vpn_rd_paths",
+		Help: "The number of VPN paths for a given route distinguisher",
+	},
+		[]string{
+			"afi",
+			"rd",
+		})
+)
+
+// BgpRdRoutes : This represents the routes of a route distinguisher
+type BgpRdRoutes struct {
+	Prefixes float64
+	Paths    float64
+}
+
+// recordVpnMetrics : Counts the routes per route distinguisher. The per neighbor prefixes of the VPN
+// address families are part of the summary.
+func recordVpnMetrics(c *collection) {
+	metric(c, bgpVpnRdPrefixes).Reset()
+	metric(c, bgpVpnRdPaths).Reset()
+	for _, afi := range []string{"ipv4", "ipv6"} {
+		o, err := c.vtysh("show bgp " + afi + " vpn")
+		if err != nil {
+			c.collectorFailed("vpn", err)
+			continue
+		}
+		for rd, r := range parseRdRoutes(o) {
+			metric(c, bgpVpnRdPrefixes).With(prometheus.Labels{"afi": afi + "_vpn", "rd": rd}).Set(r.Prefixes)
+			metric(c, bgpVpnRdPaths).With(prometheus.Labels{"afi": afi + "_vpn", "rd": rd}).Set(r.Paths)
+		}
+	}
+}
+
+// parseRdRoutes : Counts the routes listed under each "Route Distinguisher:" header. The network is
+// printed after three columns of status codes (e.g. "*>i"), and only on the first path of a prefix.
+func parseRdRoutes(s string) map[string]*BgpRdRoutes {
+	routes := make(map[string]*BgpRdRoutes)
+	var rd *BgpRdRoutes
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(line, "Route Distinguisher: ") {
+			name := strings.Fields(strings.TrimPrefix(line, "Route Distinguisher: "))[0]
+			if routes[name] == nil {
+				routes[name] = &BgpRdRoutes{}
+			}
+			rd = routes[name]
+			continue
+		}
+		if rd == nil || len(line) < 4 || !strings.Contains(line[:3], "*") {
+			continue
+		}
+		rd.Paths++
+		if line[3] != ' ' && strings.Contains(strings.Fields(line[3:])[0], "/") {
+			rd.Prefixes++
+		}
+	}
+	return routes
+}
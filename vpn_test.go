@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseRdRoutes(t *testing.T) {
+	got := parseRdRoutes(readFile(t, "testdata/frr/show_bgp_ipv4_vpn.txt"))
+	// The second path of 10.2.0.0/24 has no network, and the lines of the labels are not paths
+	want := map[string]*BgpRdRoutes{
+		"65001:100": {Prefixes: 2, Paths: 3},
+		"65001:200": {Prefixes: 1, Paths: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestRecordVpnMetrics(t *testing.T) {
+	c := testCollection(t, map[string]string{
+		"show ip bgp summary":       "testdata/frr/show_ip_bgp_summary.txt",
+		"show bgp ipv4 vpn summary": "testdata/frr/show_bgp_ipv4_vpn_summary.txt",
+		"show bgp ipv6 vpn summary": "% No BGP neighbors found in VRF default\n",
+		"show bgp ipv4 vpn":         "testdata/frr/show_bgp_ipv4_vpn.txt",
+		"show bgp ipv6 vpn":         "",
+	}, bgpVpnRdPrefixes, bgpVpnRdPaths, bgpNeighborPrefixesReceived, bgpNeighborPrefixesSent)
+	setFlags(t, map[string]string{"collector.vpn": "true"})
+	recordVpnMetrics(c)
+	recordSummaryMetrics(c)
+	if c.failures != 0 {
+		t.Fatalf("got %d failures", c.failures)
+	}
+	// The VPN neighbors are part of the summary, alongside the unicast ones
+	want := `
+# HELP bgp_neighbor_prefixes_received The number of prefixes received from a given BGP neighbor for an address family (PfxRcd)
+# TYPE bgp_neighbor_prefixes_received gauge
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.1",view=""} 12
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="",ip="10.0.0.5",view=""} 0
+bgp_neighbor_prefixes_received{afi="ipv4_unicast",interface="swp1",ip="",view=""} 100
+bgp_neighbor_prefixes_received{afi="ipv4_vpn",interface="",ip="10.0.0.2",view=""} 1
+bgp_neighbor_prefixes_received{afi="ipv4_vpn",interface="",ip="10.0.0.3",view=""} 1
+# HELP bgp_neighbor_prefixes_sent The number of prefixes sent to a given BGP neighbor for an address family (PfxSnt)
+# TYPE bgp_neighbor_prefixes_sent gauge
+bgp_neighbor_prefixes_sent{afi="ipv4_unicast",interface="",ip="10.0.0.1",view=""} 5
+bgp_neighbor_prefixes_sent{afi="ipv4_unicast",interface="",ip="10.0.0.5",view=""} 0
+bgp_neighbor_prefixes_sent{afi="ipv4_unicast",interface="swp1",ip="",view=""} 3
+bgp_neighbor_prefixes_sent{afi="ipv4_vpn",interface="",ip="10.0.0.2",view=""} 2
+bgp_neighbor_prefixes_sent{afi="ipv4_vpn",interface="",ip="10.0.0.3",view=""} 2
+# HELP bgp_vpn_rd_paths The number of VPN paths for a given route distinguisher
+# TYPE bgp_vpn_rd_paths gauge
+bgp_vpn_rd_paths{afi="ipv4_vpn",rd="65001:100"} 3
+bgp_vpn_rd_paths{afi="ipv4_vpn",rd="65001:200"} 1
+# HELP bgp_vpn_rd_prefixes The number of VPN prefixes for a given route distinguisher
+# TYPE bgp_vpn_rd_prefixes gauge
+bgp_vpn_rd_prefixes{afi="ipv4_vpn",rd="65001:100"} 2
+bgp_vpn_rd_prefixes{afi="ipv4_vpn",rd="65001:200"} 1
+`
+	if err := testutil.GatherAndCompare(c.registry, strings.NewReader(want), "bgp_neighbor_prefixes_received", "bgp_neighbor_prefixes_sent", "bgp_vpn_rd_paths", "bgp_vpn_rd_prefixes"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+)
+
+var vtySocket = flag.String("vty.socket", "", "Send the commands to this vty socket of bgpd (e.g. /var/run/frr/bgpd.vty) instead of running vtysh")
+
+// vtyCommand : Returns the command as understood by bgpd. vtysh routes "show memory bgpd" to bgpd
+// as "show memory", while bgpd only knows its own memory.
+func vtyCommand(command string) string {
+	if command == "show memory bgpd" {
+		return "show memory"
+	}
+	return command
+}
+
+// runVty : Runs the command on the vty socket of bgpd with the protocol of vtysh: the command is
+// terminated by a NUL byte, and the output by three NUL bytes followed by the status of the command.
+// The output is written as it arrives, but for its last bytes which may be the end marker.
+func runVty(ctx context.Context, path string, command string, stdout io.Writer) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(append([]byte(vtyCommand(command)), 0)); err != nil {
+		return err
+	}
+
+	var pending []byte
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := conn.Read(buf)
+		pending = append(pending, buf[:n]...)
+		if len(pending) >= 4 && bytes.Equal(pending[len(pending)-4:len(pending)-1], []byte{0, 0, 0}) {
+			status := pending[len(pending)-1]
+			if _, err := stdout.Write(pending[:len(pending)-4]); err != nil {
+				return err
+			}
+			if status != 0 {
+				return fmt.Errorf("command failed with status %d", status)
+			}
+			return nil
+		}
+		if len(pending) > 4 {
+			if _, err := stdout.Write(pending[:len(pending)-4]); err != nil {
+				return err
+			}
+			pending = append(pending[:0], pending[len(pending)-4:]...)
+		}
+		if err == io.EOF {
+			_, _ = stdout.Write(pending)
+			return fmt.Errorf("connection closed before the end of the output")
+		}
+		if err != nil {
+			_, _ = stdout.Write(pending)
+			return err
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bgpFrrWatchfrrDaemonState = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_frr_watchfrr_daemon_state",
+		Help: "Whether a given daemon supervised by watchfrr is in a given state (init, down, connecting, up or unresponsive)",
+	},
+		[]string{
+			"daemon",
+			"state",
+		})
+)
+
+var (
+	bgpFrrWatchfrrRestarting = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_frr_watchfrr_restarting",
+		Help: "Whether watchfrr is restarting a given daemon, or waiting to restart it",
+	},
+		[]string{
+			"daemon",
+		})
+)
+
+var (
+	bgpFrrWatchfrrRestartBackoff = newGaugeVec(prometheus.GaugeOpts{
+		Name: "bgp_frr_watchfrr_restart_backoff_seconds",
+		Help: "The backoff interval of watchfrr before restarting a given daemon which is down, doubled at every restart from the minimum restart interval",
+	},
+		[]string{
+			"daemon",
+		})
+)
+
+var (
+	bgpFrrDaemonRestarts = newCounterVec(prometheus.CounterOpts{
+		Name: "bgp_frr_daemon_restarts_total",
+		Help: "The number of times a given daemon was seen going down or being restarted by watchfrr since the exporter started",
+	},
+		[]string{
+			"daemon",
+		})
+)
+
+// watchfrrStateNames : The daemon states printed by "show watchfrr"
+var watchfrrStateNames = []string{"init", "down", "connecting", "up", "unresponsive"}
+
+// WatchfrrDaemon : This represents the status of a daemon in "show watchfrr"
+type WatchfrrDaemon struct {
+	Name       string
+	State      string
+	Restarting bool
+	Backoff    float64
+	HasBackoff bool
+}
+
+// watchfrrDaemonRegex : A daemon, e.g. "  bgpd                 Up" or "  zebra                Up/Ignoring Timeout"
+var watchfrrDaemonRegex = regexp.MustCompile(`^\s+(\S+)\s+(Init|Down|Connecting|Up|Unresponsive)(?:/Ignoring Timeout)?\s*$`)
+
+// watchfrrRestartingRegex : The restart of the daemon above, e.g. "      restart running, pid 1234" or
+// "      restarting in 42 seconds (60s backoff interval)"
+var watchfrrRestartingRegex = regexp.MustCompile(`^\s+(?:restart running, pid \d+|restarting in -?\d+ seconds \((\d+)s backoff interval\))\s*$`)
+
+// recordWatchfrrMetrics : Exports the state of the daemons supervised by watchfrr, from "show watchfrr",
+// and counts their restarts, for a crash-looping bgpd to show even when its sessions come back between
+// the collections
+func recordWatchfrrMetrics(c *collection) {
+	o, err := c.vtysh("show watchfrr")
+	if err != nil {
+		c.collectorFailed("watchfrr", err)
+		return
+	}
+	daemons := parseWatchfrr(o)
+	metric(c, bgpFrrWatchfrrDaemonState).Reset()
+	metric(c, bgpFrrWatchfrrRestarting).Reset()
+	metric(c, bgpFrrWatchfrrRestartBackoff).Reset()
+	for _, d := range daemons {
+		for _, state := range watchfrrStateNames {
+			metric(c, bgpFrrWatchfrrDaemonState).With(prometheus.Labels{"daemon": d.Name, "state": state}).Set(boolToFloat(d.State == state))
+		}
+		metric(c, bgpFrrWatchfrrRestarting).With(prometheus.Labels{"daemon": d.Name}).Set(boolToFloat(d.Restarting))
+		if d.HasBackoff {
+			metric(c, bgpFrrWatchfrrRestartBackoff).With(prometheus.Labels{"daemon": d.Name}).Set(d.Backoff)
+		}
+		// A daemon is counted once per restart: when it is first seen down or restarting after being up
+		counter := metric(c, bgpFrrDaemonRestarts).With(prometheus.Labels{"daemon": d.Name})
+		if previous, ok := c.state.watchfrr[d.Name]; ok && previous.State == "up" && !previous.Restarting && (d.State != "up" || d.Restarting) {
+			counter.Inc()
+		}
+	}
+	for name := range c.state.watchfrr {
+		delete(c.state.watchfrr, name)
+	}
+	for _, d := range daemons {
+		c.state.watchfrr[d.Name] = d
+	}
+}
+
+// parseWatchfrr : Returns the daemons of "show watchfrr", in their order
+func parseWatchfrr(s string) []WatchfrrDaemon {
+	var daemons []WatchfrrDaemon
+	for _, line := range strings.Split(s, "\n") {
+		if m := watchfrrDaemonRegex.FindStringSubmatch(line); m != nil {
+			daemons = append(daemons, WatchfrrDaemon{Name: m[1], State: strings.ToLower(m[2])})
+		} else if m := watchfrrRestartingRegex.FindStringSubmatch(line); m != nil && len(daemons) > 0 {
+			d := &daemons[len(daemons)-1]
+			d.Restarting = true
+			if m[1] != "" {
+				d.Backoff, _ = strconv.ParseFloat(m[1], 64)
+				d.HasBackoff = true
+			}
+		}
+	}
+	return daemons
+}